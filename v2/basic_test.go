@@ -1,9 +1,15 @@
 package httpsimp
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 )
 
@@ -44,6 +50,24 @@ func TestGetInvalidContentType200(t *testing.T) {
 	}
 }
 
+func TestGetMissingContentType200(t *testing.T) {
+	var data []byte
+	if err := get(http.StatusOK, "", []byte("raw"), Bytes(&data)); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "raw" {
+		t.Fatalf("data = %q", data)
+	}
+
+	var resp struct {
+		Foo int `json:"foo"`
+	}
+	err := get(http.StatusOK, "", []byte(`{"foo": 42}`), JSON(&resp))
+	if err == nil {
+		t.Fatal("err is nil for a JSON parser and no Content-Type")
+	}
+}
+
 func TestGetAltJSON200(t *testing.T) {
 	var resp struct {
 		Foo int `json:"foo"`
@@ -100,3 +124,248 @@ func TestGetDefaultText400(t *testing.T) {
 	}
 	t.Fatal(respErr)
 }
+
+func TestContentLanguage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Header().Set("Content-Language", "fr-CA")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"erreur": "mauvais"}`))
+	}))
+	defer srv.Close()
+
+	var en, fr map[string]string
+	err := Do(MakeGet(srv.URL, "", nil, nil), http.DefaultClient,
+		JSON(&en, Status4xx, ContentLanguage("en"), ReturnError()),
+		JSON(&fr, Status4xx, ContentLanguage("fr"), ReturnError()))
+	if err == nil {
+		t.Fatal("err is nil")
+	}
+	if en != nil || fr["erreur"] != "mauvais" {
+		t.Fatalf("wrong parser matched: en = %v, fr = %v", en, fr)
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	var resp map[string]interface{}
+	err := get(http.StatusOK, ContentTypeJSON, []byte(`{"foo": 42}`), JSON(&resp, MaxBytes(5)))
+	if e := getResponseError(err); e == nil || !errors.Is(e, ErrBodyTooLarge) {
+		t.Fatalf("unexpected error with known length: %v", err)
+	}
+
+	var b []byte
+	large := []byte(strings.Repeat("x", 100000))
+	err = get(http.StatusOK, ContentTypeTextPlain, large, Bytes(&b, MaxBytes(1000)))
+	if e := getResponseError(err); e == nil || !errors.Is(e, ErrBodyTooLarge) {
+		t.Fatalf("unexpected error with streamed body: %v", err)
+	}
+
+	err = get(http.StatusOK, ContentTypeTextPlain, large, Bytes(&b, MaxBytes(int64(len(large)))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != len(large) {
+		t.Fatalf("read %d bytes, wanted %d", len(b), len(large))
+	}
+}
+
+func TestUnmatchedBodyDrained(t *testing.T) {
+	var conns int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(strings.Repeat("<p>upstream is down</p>", 1000)))
+	}))
+	srv.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	for i := 0; i < 5; i++ {
+		var resp interface{}
+		err := Do(MakeGet(srv.URL, "", nil, nil), client, JSON(&resp))
+		if StatusCode(err) != http.StatusBadGateway {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Fatalf("used %d connections, wanted 1", n)
+	}
+}
+
+func TestResponseErrorAs(t *testing.T) {
+	err := get(http.StatusConflict, ContentTypeJSON, []byte(`{"error": "conflict"}`), None())
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("errors.As failed for %T: %v", err, err)
+	}
+	if respErr.StatusCode != http.StatusConflict || respErr.Body.(map[string]interface{})["error"] != "conflict" {
+		t.Fatalf("unexpected ResponseError: %+v", respErr)
+	}
+	if !Is4xx(fmt.Errorf("wrapped: %w", err)) {
+		t.Fatal("Is4xx is false for a wrapped error")
+	}
+
+	err = get(http.StatusOK, ContentTypeJSON, []byte(`{"foo": 42}`), JSON(nil, MaxBytes(5)))
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("errors.Is(err, ErrBodyTooLarge) is false for %v", err)
+	}
+}
+
+type acceptRecorder struct {
+	accept string
+}
+
+func (c *acceptRecorder) Do(r *http.Request) (*http.Response, error) {
+	c.accept = r.Header.Get("Accept")
+	return &http.Response{StatusCode: http.StatusNoContent, Header: make(http.Header), Body: http.NoBody}, nil
+}
+
+func TestAcceptHeader(t *testing.T) {
+	tests := []struct {
+		header   http.Header
+		parsers  []Parser
+		expected string
+	}{
+		{nil, []Parser{PlainText(nil, ContentType(ContentTypeTextPlain))}, "text/plain"},
+		{nil, []Parser{JSON(nil), PlainText(nil), JSON(nil, Status4xx)}, "application/json, */*;q=0.1"},
+		{nil, []Parser{JSON(nil, Vendor(VendorType{"a", "v1", "json"})), JSON(nil, Status4xx)}, "application/vnd.a.v1+json, application/json"},
+		{nil, []Parser{None(), Meta(new(ResponseMeta))}, ""},
+		{http.Header{"Accept": {"text/*"}}, []Parser{JSON(nil)}, "text/*"},
+		{nil, []Parser{JSON(nil), PlainText(nil, ContentType("text/*"))}, "application/json, text/*"},
+		{nil, []Parser{JSON(nil, ContentType(ContentTypeAnyJSON))}, "application/json, */*;q=0.1"},
+	}
+	for _, tt := range tests {
+		client := new(acceptRecorder)
+		Do(MakeGet("http://example.com", "", nil, tt.header), client, tt.parsers...)
+		if client.accept != tt.expected {
+			t.Errorf("Accept = %q, wanted %q", client.accept, tt.expected)
+		}
+	}
+}
+
+func TestMatchContentType(t *testing.T) {
+	tests := []struct {
+		pattern, ctype string
+		expected       bool
+	}{
+		{"", "text/html", true},
+		{"application/json", "application/json", true},
+		{"application/json", "application/hal+json", false},
+		{"application/*+json", "application/json", true},
+		{"application/*+json", "application/hal+json", true},
+		{"application/*+json", "application/vnd.api+json", true},
+		{"application/*+json", "application/+json", false},
+		{"application/*+json", "application/xml", false},
+		{"application/*+json", "text/x+json", false},
+		{"text/*", "text/plain", true},
+		{"text/*", "text/html", true},
+		{"text/*", "text/", false},
+		{"text/*", "texts/plain", false},
+		{"text/*", "application/json", false},
+		{"*/*", "image/png", true},
+		{"*/*", "", false},
+	}
+	for _, tt := range tests {
+		if actual := matchContentType(tt.pattern, tt.ctype); actual != tt.expected {
+			t.Errorf("matchContentType(%q, %q) = %v, wanted %v", tt.pattern, tt.ctype, actual, tt.expected)
+		}
+	}
+
+	var resp map[string]interface{}
+	err := get(http.StatusOK, "application/problem+json", []byte(`{"title": "x"}`), JSON(&resp, ContentType(ContentTypeAnyJSON)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp["title"] != "x" {
+		t.Fatalf("invalid value of resp: %v", resp)
+	}
+
+	var text string
+	err = get(http.StatusBadGateway, "text/html", []byte("<h1>Bad Gateway</h1>"), None(), PlainText(&text, Status5xx, ContentType("text/*")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "<h1>Bad Gateway</h1>" {
+		t.Fatalf("invalid value of text: %q", text)
+	}
+}
+
+func TestMatchFunc(t *testing.T) {
+	var v1, v2 map[string]interface{}
+	isV1 := MatchFunc(func(resp *http.Response) bool {
+		head, err := PeekBody(resp, 20)
+		if err != nil {
+			t.Error(err)
+		}
+		return bytes.Contains(head, []byte(`"version": 1`))
+	})
+	hasHeader := MatchFunc(func(resp *http.Response) bool {
+		return resp.Header.Get("Content-Type") != ""
+	})
+
+	err := get(http.StatusOK, ContentTypeJSON, []byte(`{"version": 2, "foo": 42}`), JSON(&v1, isV1, hasHeader), JSON(&v2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1 != nil || v2["foo"] != 42.0 {
+		t.Fatalf("v1 = %v, v2 = %v", v1, v2)
+	}
+
+	v2 = nil
+	err = get(http.StatusOK, ContentTypeJSON, []byte(`{"version": 1, "foo": 42}`), JSON(&v1, isV1, hasHeader), JSON(&v2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1["foo"] != 42.0 || v2 != nil {
+		t.Fatalf("v1 = %v, v2 = %v", v1, v2)
+	}
+}
+
+func TestJSONDecodingOptions(t *testing.T) {
+	var v map[string]interface{}
+	err := get(http.StatusOK, ContentTypeJSON, []byte(`{"amount": 12345678901234567890.01}`), JSON(&v, UseNumber()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := v["amount"].(json.Number); !ok || n.String() != "12345678901234567890.01" {
+		t.Errorf("got %#v", v["amount"])
+	}
+
+	var s struct {
+		A int `json:"a"`
+	}
+	err = get(http.StatusOK, ContentTypeJSON, []byte(`{"a": 1, "b": 2}`), JSON(&s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = get(http.StatusOK, ContentTypeJSON, []byte(`{"a": 1, "b": 2}`), JSON(&s, DisallowUnknownFields()))
+	if err == nil || !strings.Contains(err.Error(), `unknown field "b"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMismatchErrorsJoined(t *testing.T) {
+	var resp struct{}
+	var b []byte
+	err := get(http.StatusOK, "text/html", []byte("<html>"), JSON(&resp), Bytes(&b, ContentType("image/png")))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "wanted application/json") || !strings.Contains(msg, "wanted image/png") {
+		t.Errorf("error does not explain all mismatches: %q", msg)
+	}
+	if StatusCode(err) != http.StatusOK {
+		t.Errorf("StatusCode = %d", StatusCode(err))
+	}
+
+	err = get(http.StatusOK, "text/html", []byte("<html>"), JSON(&resp))
+	if _, ok := errors.Unwrap(err).(*ResponseError); !ok {
+		t.Errorf("a single mismatch should not be joined, got %T", errors.Unwrap(err))
+	}
+}
@@ -0,0 +1,206 @@
+package httpsimp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// ContentTypeHTTP is the content type of the parts of a batch request,
+// each holding a complete HTTP request or response.
+const ContentTypeHTTP = "application/http"
+
+/*
+Batch packs multiple requests into a single multipart/mixed request,
+as accepted by the batch endpoints of Google APIs and OData services,
+and dispatches the parts of the multipart response to the parsers of
+the corresponding requests:
+
+    var a, b Item
+    batch := httpsimp.NewBatch()
+    batch.Add(httpsimp.MakeGet(baseURL, "/items/a", nil, nil), httpsimp.JSON(&a))
+    batch.Add(httpsimp.MakeGet(baseURL, "/items/b", nil, nil), httpsimp.JSON(&b))
+    if err := batch.Do(baseURL, "/batch", client); err != nil {
+        return err
+    }
+    for i, err := range batch.Errors() {
+        ...
+    }
+
+Responses are correlated to requests via the Content-ID headers of
+the parts (a request with Content-ID <item1> gets a response with
+Content-ID <response-item1>), or by position if the server omits them.
+*/
+type Batch struct {
+	items []*batchItem
+}
+
+type batchItem struct {
+	r       *http.Request
+	parsers []Parser
+	err     error
+}
+
+/*
+NewBatch returns an empty Batch.
+*/
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+/*
+Add adds a request to the batch, to be handled by the given parsers,
+and returns its index in Errors.
+*/
+func (b *Batch) Add(r *http.Request, parsers ...Parser) int {
+	b.items = append(b.items, &batchItem{r: r, parsers: parsers})
+	return len(b.items) - 1
+}
+
+/*
+Len returns the number of requests in the batch.
+*/
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+/*
+Request builds the POST request carrying the batch, to be sent to
+the given URL together with Parser. If any of the requests could not be
+built, so can't this one (see BuildError).
+*/
+func (b *Batch) Request(base, path string) *http.Request {
+	r := makeRequest(http.MethodPost, base, path, nil, make(http.Header))
+	if requestBuildError(r) != nil {
+		return r
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for i, item := range b.items {
+		item.err = errBatchNoResponse
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {ContentTypeHTTP},
+			"Content-Transfer-Encoding": {"binary"},
+			"Content-Id":                {"<" + batchItemID(i) + ">"},
+		})
+		if err == nil {
+			err = writeBatchRequest(w, item.r)
+		}
+		if err != nil {
+			return setBuildError(r, fmt.Errorf("batch request %d: %w", i, err))
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return setBuildError(r, err)
+	}
+
+	r.Header.Set("Content-Type", ContentTypeMultipartMixed+"; boundary="+mw.Boundary())
+	return SetBody(r, buf.Bytes())
+}
+
+var errBatchNoResponse = errors.New("no response in batch")
+
+func batchItemID(i int) string {
+	return "item" + strconv.Itoa(i+1)
+}
+
+// writeBatchRequest serializes a request as an application/http part.
+func writeBatchRequest(w io.Writer, r *http.Request) error {
+	if be := requestBuildError(r); be != nil {
+		return be
+	}
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	method := r.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	fmt.Fprintf(w, "%s %s HTTP/1.1\r\n", method, r.URL.RequestURI())
+	if r.URL.Host != "" {
+		fmt.Fprintf(w, "Host: %s\r\n", r.URL.Host)
+	}
+	if err := r.Header.Write(w); err != nil {
+		return err
+	}
+	if len(body) > 0 {
+		fmt.Fprintf(w, "Content-Length: %d\r\n", len(body))
+	}
+	io.WriteString(w, "\r\n")
+	_, err := w.Write(body)
+	return err
+}
+
+/*
+Parser returns a parser handling the multipart response to the batch
+request, which records the outcome of each request (see Errors).
+The parser fails if the batch response itself cannot be parsed;
+failures of individual requests are only reported via Errors.
+*/
+func (b *Batch) Parser(mopt ...ParseOption) Parser {
+	return Multipart(func(i int, part *http.Response) error {
+		item := b.itemFor(i, part.Header.Get("Content-Id"))
+		if item == nil {
+			return fmt.Errorf("unexpected batch response part %d (Content-ID %q)", i, part.Header.Get("Content-Id"))
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(part.Body), item.r)
+		if err != nil {
+			return fmt.Errorf("batch response part %d: %w", i, err)
+		}
+		item.err = Parse(resp, item.parsers...)
+		return nil
+	}, mopt...)
+}
+
+func (b *Batch) itemFor(i int, contentID string) *batchItem {
+	id := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(contentID), "<"), ">")
+	if id != "" {
+		id = strings.TrimPrefix(id, "response-")
+		for j, item := range b.items {
+			if batchItemID(j) == id {
+				return item
+			}
+		}
+		return nil
+	}
+	if i < len(b.items) {
+		return b.items[i]
+	}
+	return nil
+}
+
+/*
+Do sends the batch to the given URL via the given client, and parses
+the response. The returned error only reports failures of the batch
+request itself; see Errors for the individual requests.
+*/
+func (b *Batch) Do(base, path string, client HTTPClient) error {
+	return Do(b.Request(base, path), client, b.Parser())
+}
+
+/*
+Errors returns the errors of the individual requests after Do or Parse
+with Parser, in the order they have been added; nil means success.
+*/
+func (b *Batch) Errors() []error {
+	errs := make([]error, len(b.items))
+	for i, item := range b.items {
+		errs[i] = item.err
+	}
+	return errs
+}
@@ -0,0 +1,98 @@
+package httpsimp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+)
+
+// batchHandler emulates a Google-style batch endpoint, responding to
+// the sub-requests in reverse order.
+func batchHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		type sub struct {
+			id   string
+			resp string
+		}
+		var subs []sub
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			if p.Header.Get("Content-Type") != ContentTypeHTTP {
+				t.Errorf("part Content-Type = %q", p.Header.Get("Content-Type"))
+			}
+			sr, err := http.ReadRequest(bufio.NewReader(p))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := ioutil.ReadAll(sr.Body)
+			var resp string
+			switch sr.URL.Path {
+			case "/items/a":
+				resp = "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: 13\r\n\r\n{\"name\":\"a\"}\n"
+			case "/items":
+				s := fmt.Sprintf(`{"echo":%q}`, sr.Header.Get("X-Test")+" "+string(body))
+				resp = fmt.Sprintf("HTTP/1.1 201 Created\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", len(s), s)
+			default:
+				resp = "HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n"
+			}
+			subs = append(subs, sub{p.Header.Get("Content-Id"), resp})
+		}
+
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for i := len(subs) - 1; i >= 0; i-- {
+			id := subs[i].id
+			pw, _ := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type": {ContentTypeHTTP},
+				"Content-Id":   {"<response-" + id[1:]},
+			})
+			pw.Write([]byte(subs[i].resp))
+		}
+		mw.Close()
+		w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+		w.Write(buf.Bytes())
+	}
+}
+
+func TestBatch(t *testing.T) {
+	srv := httptest.NewServer(batchHandler(t))
+	defer srv.Close()
+
+	var a, created struct {
+		Name string `json:"name"`
+		Echo string `json:"echo"`
+	}
+	batch := NewBatch()
+	batch.Add(MakeGet(srv.URL, "/items/a", nil, nil), JSON(&a))
+	batch.Add(MakeGet(srv.URL, "/items/missing", nil, nil), None())
+	batch.Add(MakeJSON(http.MethodPost, srv.URL, "/items", nil, map[string]int{"x": 1}, http.Header{"X-Test": {"hi"}}), JSON(&created))
+	if err := batch.Do(srv.URL, "/batch", http.DefaultClient); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := batch.Errors()
+	if errs[0] != nil || a.Name != "a" {
+		t.Errorf("item 0: err = %v, a = %+v", errs[0], a)
+	}
+	if StatusCode(errs[1]) != http.StatusNotFound {
+		t.Errorf("item 1: err = %v", errs[1])
+	}
+	if errs[2] != nil || created.Echo != `hi {"x":1}` {
+		t.Errorf("item 2: err = %v, created = %+v", errs[2], created)
+	}
+}
@@ -0,0 +1,120 @@
+package httpsimp
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// cannedClient returns the same response to every request without
+// touching the network, so that benchmarks measure this package only.
+type cannedClient struct {
+	statusCode int
+	ctype      string
+	body       []byte
+}
+
+func (c *cannedClient) Do(r *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode:    c.statusCode,
+		Header:        http.Header{"Content-Type": {c.ctype}},
+		Body:          ioutil.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       r,
+	}, nil
+}
+
+type benchResult struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Score float64  `json:"score"`
+}
+
+type benchError struct {
+	Message string `json:"message"`
+}
+
+var benchJSON = []byte(`{"id": 42, "name": "widget", "tags": ["a", "b", "c"], "score": 4.5}`)
+
+func benchmarkDo(b *testing.B, client HTTPClient, parsers func() []Parser) {
+	r := MakeGet("http://example.com", "/items/42", nil, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Do(r, client, parsers()...)
+	}
+}
+
+func BenchmarkDoJSON(b *testing.B) {
+	client := &cannedClient{http.StatusOK, "application/json; charset=utf-8", benchJSON}
+	benchmarkDo(b, client, func() []Parser {
+		var result benchResult
+		return []Parser{JSON(&result)}
+	})
+}
+
+func BenchmarkDoJSONWithErrorParser(b *testing.B) {
+	client := &cannedClient{http.StatusOK, "application/json; charset=utf-8", benchJSON}
+	benchmarkDo(b, client, func() []Parser {
+		var result benchResult
+		var e benchError
+		return []Parser{JSON(&result), JSON(&e, Status4xx5xx)}
+	})
+}
+
+func BenchmarkDoErrorResponse(b *testing.B) {
+	client := &cannedClient{http.StatusNotFound, "application/json; charset=utf-8", []byte(`{"message": "not found"}`)}
+	benchmarkDo(b, client, func() []Parser {
+		var result benchResult
+		var bytes []byte
+		var e benchError
+		return []Parser{JSON(&result), Bytes(&bytes, ContentType("image/png")), JSON(&e, Status4xx5xx, ReturnError())}
+	})
+}
+
+func BenchmarkDoPlainText(b *testing.B) {
+	client := &cannedClient{http.StatusOK, "text/plain; charset=utf-8", bytes.Repeat([]byte("hello world\n"), 100)}
+	benchmarkDo(b, client, func() []Parser {
+		var s string
+		return []Parser{PlainText(&s)}
+	})
+}
+
+func BenchmarkDoNone(b *testing.B) {
+	client := &cannedClient{http.StatusNoContent, "", nil}
+	benchmarkDo(b, client, func() []Parser {
+		return []Parser{None()}
+	})
+}
+
+func BenchmarkParseJSON(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {ContentTypeJSON}},
+			Body:       ioutil.NopCloser(bytes.NewReader(benchJSON)),
+		}
+		var result benchResult
+		Parse(resp, JSON(&result))
+	}
+}
+
+func BenchmarkEncodeJSONBody(b *testing.B) {
+	obj := map[string]interface{}{"name": "Fluffy", "tags": []string{"cat", "cute"}, "age": 3}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		EncodeJSONBody(&http.Request{}, obj)
+	}
+}
+
+func BenchmarkEncodeForm(b *testing.B) {
+	params := url.Values{"name": {"Fluffy"}, "tag": {"cat", "cute"}, "age": {"3"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		EncodeForm(&http.Request{}, params)
+	}
+}
@@ -0,0 +1,80 @@
+package httpsimp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// maxPooledBufferSize keeps buffers grown by unusually large bodies
+// from being retained by the pool.
+const maxPooledBufferSize = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// readAllPooled reads r to the end into a pooled buffer and passes
+// the contents to f, which must not retain the slice.
+func readAllPooled(r io.Reader, f func(b []byte)) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	_, err := buf.ReadFrom(r)
+	f(buf.Bytes())
+	return err
+}
+
+// marshalJSONPooled is json.Marshal encoding into a pooled buffer; the result
+// is an exact-size copy, so that GetBody can hold on to it.
+func marshalJSONPooled(v interface{}) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	b := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	return append([]byte(nil), b...), nil
+}
+
+// encodeFormPooled is url.Values.Encode writing into a pooled buffer.
+func encodeFormPooled(params url.Values) []byte {
+	if len(params) == 0 {
+		return []byte{}
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		ek := url.QueryEscape(k)
+		for _, v := range params[k] {
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(ek)
+			buf.WriteByte('=')
+			buf.WriteString(url.QueryEscape(v))
+		}
+	}
+	return append([]byte(nil), buf.Bytes()...)
+}
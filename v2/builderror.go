@@ -0,0 +1,117 @@
+package httpsimp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+/*
+BuildError is returned by Do when the request could not be built, e.g.
+because of a malformed URL, a body that cannot be encoded or an invalid
+header. It means that nothing has been sent to the server, so unlike
+transport and response errors, retrying won't help.
+*/
+type BuildError struct {
+	Err error
+}
+
+func (err *BuildError) Error() string {
+	return fmt.Sprintf("cannot build request: %v", err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *BuildError) Unwrap() error {
+	return err.Err
+}
+
+type buildErrorKey struct{}
+
+/*
+setBuildError records a failure to build the given request, so that Do
+can report it without sending anything. The error is stored both in the
+context and in the body (which fails with the same error, in case the request
+gets sent by other means), so that it survives http.Request.WithContext
+as well as a body set later by the builders.
+*/
+func setBuildError(r *http.Request, err error) *http.Request {
+	be, ok := err.(*BuildError)
+	if !ok {
+		be = &BuildError{err}
+	}
+	*r = *r.WithContext(context.WithValue(r.Context(), buildErrorKey{}, be))
+	r.Body = &buildErrorBody{be}
+	r.GetBody = nil
+	r.ContentLength = -1
+	return r
+}
+
+func requestBuildError(r *http.Request) *BuildError {
+	if be, ok := r.Context().Value(buildErrorKey{}).(*BuildError); ok {
+		return be
+	}
+	if b, ok := r.Body.(*buildErrorBody); ok {
+		return b.err
+	}
+	for name, values := range r.Header {
+		if !validHeaderName(name) {
+			return &BuildError{fmt.Errorf("invalid header name %q", name)}
+		}
+		for _, v := range values {
+			if !validHeaderValue(v) {
+				return &BuildError{fmt.Errorf("invalid value of header %s: %q", name, v)}
+			}
+		}
+	}
+	return nil
+}
+
+// buildErrorBody is the body of a request that could not be built.
+// SetBody and friends keep it in place.
+type buildErrorBody struct {
+	err *BuildError
+}
+
+func (b *buildErrorBody) Read(p []byte) (int, error) {
+	return 0, b.err
+}
+
+func (b *buildErrorBody) Close() error {
+	return nil
+}
+
+func hasBuildErrorBody(r *http.Request) bool {
+	_, ok := r.Body.(*buildErrorBody)
+	return ok
+}
+
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c <= ' ' || c >= 0x7F || isHeaderSeparator(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHeaderSeparator(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '@', ',', ';', ':', '\\', '"', '/', '[', ']', '?', '=', '{', '}':
+		return true
+	}
+	return false
+}
+
+func validHeaderValue(v string) bool {
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if (c < ' ' && c != '\t') || c == 0x7F {
+			return false
+		}
+	}
+	return true
+}
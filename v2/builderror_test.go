@@ -0,0 +1,34 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type failingClient struct{}
+
+func (failingClient) Do(r *http.Request) (*http.Response, error) {
+	panic("request must not be sent")
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"bad URL", MakeGet("://example.com", "foo", nil, nil)},
+		{"bad JSON", MakeJSON(http.MethodPost, "http://example.com", "foo", nil, make(chan int), nil)},
+		{"bad header", MakeGet("http://example.com", "foo", nil, http.Header{"X-Foo": []string{"a\r\nb"}})},
+		{"bad header name", MakeGet("http://example.com", "foo", nil, http.Header{"X Foo": []string{"a"}})},
+		{"bad URL, fresh context", MakeGet("://example.com", "foo", nil, nil).WithContext(context.Background())},
+		{"bad JSON, fresh context", MakeJSON(http.MethodPost, "http://example.com", "foo", nil, make(chan int), nil).WithContext(context.Background())},
+		{"bad URL and JSON, fresh context", MakeJSON(http.MethodPost, "://example.com", "foo", nil, 1, nil).WithContext(context.Background())},
+	}
+	for _, tt := range tests {
+		err := Do(tt.req, failingClient{}, None())
+		if _, ok := err.(*BuildError); !ok {
+			t.Errorf("%s: expected *BuildError, got %T: %v", tt.name, err, err)
+		}
+	}
+}
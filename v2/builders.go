@@ -2,7 +2,7 @@ package httpsimp
 
 import (
 	"bytes"
-	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -17,39 +17,202 @@ with the given params.
 
 base and path are concatenated to form a URL; at least one of them must be
 provided, but the other one can be an empty string. The resulting URL must be
-valid and parsable via net/url, otherwise panic ensues.
+valid and parsable via net/url, otherwise panic ensues (with a *BuildError).
+Use URLE when base comes from configuration or user input. The Make functions
+don't panic; instead, Do returns the *BuildError.
+
+A query string that is already part of base or path is preserved as is, with
+params added to it (replacing any parameters of the same names).
 
 url.Values and http.Header are just maps that can be provided in place,
 no need to use their fancy Set or Add methods.
 */
 func URL(base, path string, params url.Values) *url.URL {
+	u, err := buildURL(base, path, params)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+/*
+URLE is like URL, but returns a *BuildError instead of panicking when
+the resulting URL cannot be parsed.
+*/
+func URLE(base, path string, params url.Values) (*url.URL, error) {
+	return buildURL(base, path, params)
+}
+
+func buildURL(base, path string, params url.Values) (*url.URL, error) {
 	var components *url.URL
 	var err error
 
 	if base == "" {
 		components, err = url.Parse(path)
 		if err != nil {
-			panic(err)
+			return nil, &BuildError{err}
 		}
 	} else {
 		components, err = url.Parse(base)
 		if err != nil {
-			panic(err)
+			return nil, &BuildError{err}
 		}
 
 		if path != "" {
-			if !strings.HasPrefix(path, "/") {
+			var pathQuery string
+			if i := strings.IndexByte(path, '?'); i >= 0 {
+				path, pathQuery = path[:i], path[i+1:]
+			}
+			if path != "" && !strings.HasPrefix(path, "/") {
 				path = "/" + path
 			}
 			components.Path = components.Path + path
+			if pathQuery != "" {
+				if components.RawQuery != "" {
+					components.RawQuery += "&" + pathQuery
+				} else {
+					components.RawQuery = pathQuery
+				}
+			}
 		}
 	}
 
 	if params != nil {
-		components.RawQuery = strings.Replace(params.Encode(), "+", "%20", -1)
+		components.RawQuery, err = mergeQuery(components.RawQuery, params)
+		if err != nil {
+			return nil, &BuildError{err}
+		}
 	}
 
-	return components
+	return components, nil
+}
+
+// mergeQuery adds params to an existing raw query string, replacing
+// the parameters of the same names. The rest of rawQuery is kept as is.
+func mergeQuery(rawQuery string, params url.Values) (string, error) {
+	if _, err := url.ParseQuery(rawQuery); err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key := pair
+		if i := strings.IndexByte(key, '='); i >= 0 {
+			key = key[:i]
+		}
+		key, _ = url.QueryUnescape(key)
+		if _, ok := params[key]; ok {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(pair)
+	}
+	if encoded := strings.Replace(params.Encode(), "+", "%20", -1); encoded != "" {
+		if buf.Len() > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(encoded)
+	}
+	return buf.String(), nil
+}
+
+/*
+URLT is like URL, but path is a template with named parameters in braces,
+like "/users/{id}/posts/{post}", which are replaced with the corresponding
+values from pathParams. Each value is escaped as a single path segment,
+so a value like "a/b?c" cannot change the structure of the URL:
+
+    u := httpsimp.URLT(baseURL, "/users/{id}/posts/{post}", map[string]string{
+        "id":   userID,
+        "post": postSlug,
+    }, nil)
+
+A missing or unused parameter is an error. Like URL, URLT panics
+with a *BuildError on errors.
+*/
+func URLT(base, path string, pathParams map[string]string, params url.Values) *url.URL {
+	u, err := buildURLT(base, path, pathParams, params)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+/*
+URLTE is like URLT, but returns a *BuildError instead of panicking.
+*/
+func URLTE(base, path string, pathParams map[string]string, params url.Values) (*url.URL, error) {
+	return buildURLT(base, path, pathParams, params)
+}
+
+func buildURLT(base, template string, pathParams map[string]string, params url.Values) (*url.URL, error) {
+	path, err := expandPathTemplate(template, pathParams)
+	if err != nil {
+		return nil, &BuildError{err}
+	}
+	if base == "" {
+		return buildURL("", path, params)
+	}
+
+	components, err := url.Parse(base)
+	if err != nil {
+		return nil, &BuildError{err}
+	}
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	rawPath := components.EscapedPath() + path
+	components.Path, err = url.PathUnescape(rawPath)
+	if err != nil {
+		return nil, &BuildError{err}
+	}
+	components.RawPath = rawPath
+
+	if params != nil {
+		components.RawQuery, err = mergeQuery(components.RawQuery, params)
+		if err != nil {
+			return nil, &BuildError{err}
+		}
+	}
+	return components, nil
+}
+
+func expandPathTemplate(template string, pathParams map[string]string) (string, error) {
+	var b strings.Builder
+	used := make(map[string]bool)
+	for {
+		i := strings.IndexByte(template, '{')
+		if i < 0 {
+			if strings.IndexByte(template, '}') >= 0 {
+				return "", fmt.Errorf("unbalanced braces in path template")
+			}
+			b.WriteString(template)
+			break
+		}
+		j := strings.IndexByte(template[i:], '}')
+		if j < 0 || strings.IndexByte(template[:i], '}') >= 0 {
+			return "", fmt.Errorf("unbalanced braces in path template")
+		}
+		name := template[i+1 : i+j]
+		value, ok := pathParams[name]
+		if !ok {
+			return "", fmt.Errorf("missing path parameter %q", name)
+		}
+		used[name] = true
+		b.WriteString(template[:i])
+		b.WriteString(url.PathEscape(value))
+		template = template[i+j+1:]
+	}
+	for name := range pathParams {
+		if !used[name] {
+			return "", fmt.Errorf("unused path parameter %q", name)
+		}
+	}
+	return b.String(), nil
 }
 
 /*
@@ -59,11 +222,7 @@ format and sets the body and Content-Type on the given request.
 To properly handle HTTP redirects, both Body and GetBody are set.
 */
 func EncodeForm(r *http.Request, params url.Values) *http.Request {
-	if params == nil {
-		params = url.Values{}
-	}
-	body := []byte(params.Encode())
-	_ = SetBody(r, body)
+	_ = SetBody(r, encodeFormPooled(params))
 
 	if r.Header == nil {
 		r.Header = make(http.Header)
@@ -77,16 +236,26 @@ func EncodeForm(r *http.Request, params url.Values) *http.Request {
 
 /*
 EncodeJSONBody encodes the given object into JSON (application/json)
-format and sets the body and Content-Type on the given request.
+format using JSONCodec and sets the body and Content-Type on the given request.
 
-If JSON encoding fails, the method panics.
+If JSON encoding fails, Do returns a *BuildError without sending the request.
 
 To properly handle HTTP redirects, both Body and GetBody are set.
 */
 func EncodeJSONBody(r *http.Request, obj interface{}) *http.Request {
-	body, err := json.Marshal(obj)
+	return encodeJSONBodyAs(r, obj, ContentTypeJSON)
+}
+
+func encodeJSONBodyAs(r *http.Request, obj interface{}, ctype string) *http.Request {
+	var body []byte
+	var err error
+	if _, ok := JSONCodec.(stdJSONCodec); ok {
+		body, err = marshalJSONPooled(obj)
+	} else {
+		body, err = JSONCodec.Marshal(obj)
+	}
 	if err != nil {
-		panic(err)
+		return setBuildError(r, fmt.Errorf("cannot encode JSON body: %w", err))
 	}
 	_ = SetBody(r, body)
 
@@ -94,7 +263,7 @@ func EncodeJSONBody(r *http.Request, obj interface{}) *http.Request {
 		r.Header = make(http.Header)
 	}
 	if r.Header["Content-Type"] == nil {
-		r.Header["Content-Type"] = []string{ContentTypeJSON}
+		r.Header["Content-Type"] = []string{ctype}
 	}
 
 	return r
@@ -104,8 +273,12 @@ func EncodeJSONBody(r *http.Request, obj interface{}) *http.Request {
 SetBody sets the given request's body to the given data.
 
 To properly handle HTTP redirects, both Body and GetBody are set.
+A request that could not be built (see BuildError) is left as is.
 */
 func SetBody(r *http.Request, data []byte) *http.Request {
+	if hasBuildErrorBody(r) {
+		return r
+	}
 	r.Body = ioutil.NopCloser(bytes.NewReader(data))
 	r.GetBody = func() (io.ReadCloser, error) {
 		return ioutil.NopCloser(bytes.NewReader(data)), nil
@@ -113,3 +286,102 @@ func SetBody(r *http.Request, data []byte) *http.Request {
 	r.ContentLength = int64(len(data))
 	return r
 }
+
+/*
+SetBodyReader sets the given request's body to stream from the given reader,
+so that large uploads don't need to be buffered in memory.
+
+Pass the body size in bytes as contentLength, or -1 if unknown (in which case
+chunked transfer encoding is used). The size is determined automatically for
+*bytes.Reader, *bytes.Buffer and *strings.Reader.
+
+To properly handle HTTP redirects and retries, GetBody is set when the reader
+can be replayed: for in-memory readers listed above, and for io.Seeker
+implementations like *os.File (which are rewound to their initial offset).
+Seekable readers are not closed after sending, so the caller is responsible
+for closing files; other io.ReadCloser readers are closed after sending.
+A request that could not be built (see BuildError) is left as is.
+*/
+func SetBodyReader(r *http.Request, reader io.Reader, contentLength int64) *http.Request {
+	if hasBuildErrorBody(r) {
+		return r
+	}
+	r.GetBody = nil
+	switch v := reader.(type) {
+	case *bytes.Buffer:
+		data := v.Bytes()
+		return setBodyReaderFactory(r, int64(len(data)), func() io.Reader {
+			return bytes.NewReader(data)
+		})
+	case *bytes.Reader:
+		snapshot := *v
+		return setBodyReaderFactory(r, int64(v.Len()), func() io.Reader {
+			rd := snapshot
+			return &rd
+		})
+	case *strings.Reader:
+		snapshot := *v
+		return setBodyReaderFactory(r, int64(v.Len()), func() io.Reader {
+			rd := snapshot
+			return &rd
+		})
+	case io.ReadSeeker:
+		if offset, err := v.Seek(0, io.SeekCurrent); err == nil {
+			r.Body = ioutil.NopCloser(v)
+			r.GetBody = func() (io.ReadCloser, error) {
+				if _, err := v.Seek(offset, io.SeekStart); err != nil {
+					return nil, err
+				}
+				return ioutil.NopCloser(v), nil
+			}
+			r.ContentLength = contentLength
+			return r
+		}
+	}
+
+	if rc, ok := reader.(io.ReadCloser); ok {
+		r.Body = rc
+	} else {
+		r.Body = ioutil.NopCloser(reader)
+	}
+	r.ContentLength = contentLength
+	return r
+}
+
+func setBodyReaderFactory(r *http.Request, contentLength int64, f func() io.Reader) *http.Request {
+	r.Body = ioutil.NopCloser(f())
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(f()), nil
+	}
+	r.ContentLength = contentLength
+	return r
+}
+
+/*
+OnUploadProgress arranges for f to be called as the request body is being
+sent, with the number of bytes sent so far and the total body size (-1 if
+unknown). If the body is re-sent (e.g. after a redirect), the count starts
+over from zero.
+
+Call this after the body has been set.
+*/
+func OnUploadProgress(r *http.Request, f func(sent, total int64)) *http.Request {
+	if r.Body == nil || r.Body == http.NoBody || hasBuildErrorBody(r) {
+		return r
+	}
+	total := r.ContentLength
+	if total == 0 {
+		total = -1
+	}
+	r.Body = &progressReader{r.Body, 0, total, f}
+	if getBody := r.GetBody; getBody != nil {
+		r.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return &progressReader{body, 0, total, f}, nil
+		}
+	}
+	return r
+}
@@ -0,0 +1,93 @@
+package httpsimp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestURLT(t *testing.T) {
+	tests := []struct {
+		base, path string
+		pathParams map[string]string
+		params     url.Values
+		expected   string
+	}{
+		{"https://example.com/api", "/users/{id}/posts/{post}", map[string]string{"id": "42", "post": "hello world"}, nil, "https://example.com/api/users/42/posts/hello%20world"},
+		{"https://example.com/api", "users/{id}", map[string]string{"id": "a/b?c#d"}, url.Values{"q": {"x y"}}, "https://example.com/api/users/a%2Fb%3Fc%23d?q=x%20y"},
+		{"https://example.com/a%2Fb", "/{id}", map[string]string{"id": "../x"}, nil, "https://example.com/a%2Fb/..%2Fx"},
+		{"", "https://example.com/files/{name}", map[string]string{"name": "ü.txt"}, nil, "https://example.com/files/%C3%BC.txt"},
+		{"https://example.com", "/{a}/{a}", map[string]string{"a": "x"}, nil, "https://example.com/x/x"},
+	}
+	for _, tt := range tests {
+		u := URLT(tt.base, tt.path, tt.pathParams, tt.params)
+		if actual := u.String(); actual != tt.expected {
+			t.Errorf("URLT(%q, %q) = %q, wanted %q", tt.base, tt.path, actual, tt.expected)
+		}
+	}
+}
+
+func TestURLTErrors(t *testing.T) {
+	tests := []struct {
+		path       string
+		pathParams map[string]string
+		expected   string
+	}{
+		{"/users/{id}", nil, `missing path parameter "id"`},
+		{"/users/{id}", map[string]string{"id": "1", "x": "2"}, `unused path parameter "x"`},
+		{"/users/{id", map[string]string{"id": "1"}, "unbalanced braces"},
+		{"/users/id}", nil, "unbalanced braces"},
+	}
+	for _, tt := range tests {
+		_, err := buildURLT("https://example.com", tt.path, tt.pathParams, nil)
+		if _, ok := err.(*BuildError); !ok || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%q: unexpected error %v", tt.path, err)
+		}
+	}
+}
+
+func TestURLMergesQuery(t *testing.T) {
+	tests := []struct {
+		base, path string
+		params     url.Values
+		expected   string
+	}{
+		{"https://x/api?key=abc", "/items", url.Values{"page": {"2"}}, "https://x/api/items?key=abc&page=2"},
+		{"https://x/api?key=abc", "/items", nil, "https://x/api/items?key=abc"},
+		{"https://x/api?key=abc&page=1", "/items", url.Values{"page": {"2"}}, "https://x/api/items?key=abc&page=2"},
+		{"https://x/api", "/items?sort=name", url.Values{"q": {"a b"}}, "https://x/api/items?sort=name&q=a%20b"},
+		{"https://x/api?key=abc", "/items?sort=name", nil, "https://x/api/items?key=abc&sort=name"},
+		{"", "https://x/items?sort=name", url.Values{"q": {"1"}}, "https://x/items?sort=name&q=1"},
+		{"https://x/api?key=abc", "", url.Values{"q": {"1"}}, "https://x/api?key=abc&q=1"},
+		{"https://x/api?b=1&a=x+y&e=%2F", "", url.Values{"c": {"1"}}, "https://x/api?b=1&a=x+y&e=%2F&c=1"},
+		{"https://x/api?b=1&a=x+y&e=%2F&flag", "", url.Values{"b": {"2"}, "flag": {}}, "https://x/api?a=x+y&e=%2F&b=2"},
+	}
+	for _, tt := range tests {
+		if actual := URL(tt.base, tt.path, tt.params).String(); actual != tt.expected {
+			t.Errorf("URL(%q, %q, %v) = %q, wanted %q", tt.base, tt.path, tt.params, actual, tt.expected)
+		}
+	}
+}
+
+func TestPooledEncodersMatchStdlib(t *testing.T) {
+	objs := []interface{}{nil, 42, "<a&b>", map[string]interface{}{"z": []int{1, 2}, "a": "\u2028"}}
+	for _, obj := range objs {
+		expected, _ := json.Marshal(obj)
+		r := EncodeJSONBody(&http.Request{}, obj)
+		actual, _ := ioutil.ReadAll(r.Body)
+		if string(actual) != string(expected) || r.ContentLength != int64(len(expected)) {
+			t.Errorf("EncodeJSONBody(%v) = %q, wanted %q", obj, actual, expected)
+		}
+	}
+
+	for _, params := range []url.Values{nil, {}, {"b": {"1", "x y"}, "a&": {"é"}, "c": {}}} {
+		r := EncodeForm(&http.Request{}, params)
+		actual, _ := ioutil.ReadAll(r.Body)
+		if expected := params.Encode(); string(actual) != expected {
+			t.Errorf("EncodeForm(%v) = %q, wanted %q", params, actual, expected)
+		}
+	}
+}
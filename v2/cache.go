@@ -0,0 +1,354 @@
+package httpsimp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+CacheStore is a storage backend for the HTTP cache created by Cache.
+It stores opaque serialized responses keyed by URL. Implementations must be
+safe for concurrent use.
+
+Use NewMemoryCacheStore for an in-memory store, or implement this interface
+on top of disk, Redis, memcached or anything else.
+*/
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, data []byte)
+	Delete(key string)
+}
+
+/*
+NewMemoryCacheStore returns a CacheStore that keeps everything in memory
+and never evicts anything.
+*/
+func NewMemoryCacheStore() CacheStore {
+	return &memoryCacheStore{items: make(map[string][]byte)}
+}
+
+type memoryCacheStore struct {
+	mu    sync.RWMutex
+	items map[string][]byte
+}
+
+func (s *memoryCacheStore) Get(key string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.items[key]
+	return data, ok
+}
+
+func (s *memoryCacheStore) Set(key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = data
+}
+
+func (s *memoryCacheStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+}
+
+/*
+Cache returns an HTTPClient implementing a private HTTP cache per RFC 7234
+on top of the given client, storing responses in the given store.
+
+GET responses are cached according to Cache-Control (max-age, no-store,
+no-cache, must-revalidate), Expires and Vary headers; when there's no
+explicit freshness information, responses with Last-Modified get
+a heuristic lifetime of 10% of their age (but no more than a day).
+Stale responses with ETag or Last-Modified validators are revalidated
+with a conditional request, and a 304 response refreshes the stored one.
+Successful unsafe requests (POST, PUT, DELETE, etc) invalidate the cached
+response for their URL.
+
+Cache-Control: no-store and no-cache are honored on requests too.
+Requests that already carry conditional or Range headers bypass the cache.
+
+Cached responses are buffered in memory, so don't cache huge downloads.
+*/
+func Cache(client HTTPClient, store CacheStore) HTTPClient {
+	return &cachingClient{client, store}
+}
+
+type cachingClient struct {
+	client HTTPClient
+	store  CacheStore
+}
+
+type cacheEntry struct {
+	StatusCode   int
+	Status       string
+	Header       http.Header
+	Body         []byte
+	Vary         map[string]string
+	RequestTime  time.Time
+	ResponseTime time.Time
+}
+
+var cacheableStatuses = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+func (c *cachingClient) Do(r *http.Request) (*http.Response, error) {
+	key := r.URL.String()
+
+	if r.Method != "" && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		resp, err := c.client.Do(r)
+		if err == nil && resp.StatusCode < 400 {
+			c.store.Delete(key)
+		}
+		return resp, err
+	}
+
+	reqCC := parseCacheControl(r.Header)
+	if _, ok := reqCC["no-store"]; ok || r.Method == http.MethodHead || hasAnyHeader(r.Header, "Range", "If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range") {
+		return c.client.Do(r)
+	}
+
+	entry := c.lookup(key, r)
+	if entry == nil {
+		return c.fetch(key, r)
+	}
+
+	_, noCache := reqCC["no-cache"]
+	if maxAge, ok := reqCC["max-age"]; ok && maxAge == "0" {
+		noCache = true
+	}
+	if !noCache && entry.isFresh(time.Now()) {
+		return entry.response(r), nil
+	}
+
+	etag := entry.Header.Get("ETag")
+	lastModified := entry.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return c.fetch(key, r)
+	}
+
+	cr := r.Clone(r.Context())
+	if cr.Header == nil {
+		cr.Header = make(http.Header)
+	}
+	if etag != "" {
+		cr.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		cr.Header.Set("If-Modified-Since", lastModified)
+	}
+	reqTime := time.Now()
+	resp, err := c.client.Do(cr)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		return c.storeResponse(key, r, resp, reqTime)
+	}
+	drainAndClose(resp.Body)
+
+	for k, v := range resp.Header {
+		entry.Header[k] = v
+	}
+	entry.RequestTime = reqTime
+	entry.ResponseTime = time.Now()
+	c.save(key, entry)
+	return entry.response(r), nil
+}
+
+func (c *cachingClient) fetch(key string, r *http.Request) (*http.Response, error) {
+	reqTime := time.Now()
+	resp, err := c.client.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	return c.storeResponse(key, r, resp, reqTime)
+}
+
+func (c *cachingClient) storeResponse(key string, r *http.Request, resp *http.Response, reqTime time.Time) (*http.Response, error) {
+	if !cacheableStatuses[resp.StatusCode] {
+		return resp, nil
+	}
+	respCC := parseCacheControl(resp.Header)
+	if _, ok := respCC["no-store"]; ok {
+		c.store.Delete(key)
+		return resp, nil
+	}
+
+	vary := make(map[string]string)
+	for _, v := range resp.Header["Vary"] {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return resp, nil
+			} else if name != "" {
+				vary[name] = r.Header.Get(name)
+			}
+		}
+	}
+
+	entry := &cacheEntry{
+		StatusCode:   resp.StatusCode,
+		Status:       resp.Status,
+		Header:       resp.Header.Clone(),
+		Vary:         vary,
+		RequestTime:  reqTime,
+		ResponseTime: time.Now(),
+	}
+	if entry.freshnessLifetime() <= 0 && entry.Header.Get("ETag") == "" && entry.Header.Get("Last-Modified") == "" {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	entry.Body = body
+
+	c.save(key, entry)
+	return resp, nil
+}
+
+func (c *cachingClient) lookup(key string, r *http.Request) *cacheEntry {
+	data, ok := c.store.Get(key)
+	if !ok {
+		return nil
+	}
+	entry := new(cacheEntry)
+	if err := json.Unmarshal(data, entry); err != nil {
+		c.store.Delete(key)
+		return nil
+	}
+	for name, value := range entry.Vary {
+		if r.Header.Get(name) != value {
+			return nil
+		}
+	}
+	return entry
+}
+
+func (c *cachingClient) save(key string, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	c.store.Set(key, data)
+}
+
+func (entry *cacheEntry) freshnessLifetime() time.Duration {
+	cc := parseCacheControl(entry.Header)
+	if _, ok := cc["no-cache"]; ok {
+		return 0
+	}
+	if v, ok := cc["max-age"]; ok {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		return 0
+	}
+
+	date := entry.date()
+	if v := entry.Header.Get("Expires"); v != "" {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0
+		}
+		return expires.Sub(date)
+	}
+
+	if lm, err := http.ParseTime(entry.Header.Get("Last-Modified")); err == nil && entry.StatusCode == http.StatusOK {
+		lifetime := date.Sub(lm) / 10
+		if lifetime > 24*time.Hour {
+			lifetime = 24 * time.Hour
+		}
+		return lifetime
+	}
+	return 0
+}
+
+func (entry *cacheEntry) date() time.Time {
+	if date, err := http.ParseTime(entry.Header.Get("Date")); err == nil {
+		return date
+	}
+	return entry.ResponseTime
+}
+
+func (entry *cacheEntry) age(now time.Time) time.Duration {
+	apparentAge := entry.ResponseTime.Sub(entry.date())
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+	correctedAge := entry.ResponseTime.Sub(entry.RequestTime)
+	if secs, err := strconv.ParseInt(entry.Header.Get("Age"), 10, 64); err == nil {
+		correctedAge += time.Duration(secs) * time.Second
+	}
+	initialAge := apparentAge
+	if correctedAge > initialAge {
+		initialAge = correctedAge
+	}
+	return initialAge + now.Sub(entry.ResponseTime)
+}
+
+func (entry *cacheEntry) isFresh(now time.Time) bool {
+	return entry.freshnessLifetime() > entry.age(now)
+}
+
+func (entry *cacheEntry) response(r *http.Request) *http.Response {
+	header := entry.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(entry.age(time.Now())/time.Second), 10))
+	return &http.Response{
+		Status:        entry.Status,
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       r,
+	}
+}
+
+func parseCacheControl(h http.Header) map[string]string {
+	cc := make(map[string]string)
+	for _, v := range h["Cache-Control"] {
+		for _, directive := range strings.Split(v, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			if i := strings.IndexByte(directive, '='); i >= 0 {
+				cc[strings.ToLower(directive[:i])] = strings.Trim(directive[i+1:], `"`)
+			} else {
+				cc[strings.ToLower(directive)] = ""
+			}
+		}
+	}
+	return cc
+}
+
+func hasAnyHeader(h http.Header, names ...string) bool {
+	for _, name := range names {
+		if _, ok := h[name]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCacheFresh(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	client := Cache(http.DefaultClient, NewMemoryCacheStore())
+	for i := 0; i < 3; i++ {
+		var text string
+		err := Do(MakeGet(srv.URL, "", nil, nil), client, PlainText(&text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != "hello" {
+			t.Fatalf("invalid value of text: %q", text)
+		}
+	}
+	if hits != 1 {
+		t.Fatalf("hits = %d, wanted 1", hits)
+	}
+
+	err := Do(MakeForm(http.MethodPost, srv.URL, "", nil, nil), client, None())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Do(MakeGet(srv.URL, "", nil, nil), client, None())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits != 3 {
+		t.Fatalf("hits = %d, wanted 3 after invalidation", hits)
+	}
+}
+
+func TestCacheRevalidate(t *testing.T) {
+	hits, notModified := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	client := Cache(http.DefaultClient, NewMemoryCacheStore())
+	for i := 0; i < 3; i++ {
+		var text string
+		err := Do(MakeGet(srv.URL, "", nil, nil), client, PlainText(&text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != "hello" {
+			t.Fatalf("invalid value of text: %q", text)
+		}
+	}
+	if hits != 3 || notModified != 2 {
+		t.Fatalf("hits = %d, notModified = %d, wanted 3 and 2", hits, notModified)
+	}
+}
+
+func TestCacheVary(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer srv.Close()
+
+	client := Cache(http.DefaultClient, NewMemoryCacheStore())
+	for _, lang := range []string{"en", "en", "fr"} {
+		var text string
+		err := Do(MakeGet(srv.URL, "", nil, http.Header{"Accept-Language": []string{lang}}), client, PlainText(&text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != lang {
+			t.Fatalf("text = %q, wanted %q", text, lang)
+		}
+	}
+	if hits != 2 {
+		t.Fatalf("hits = %d, wanted 2", hits)
+	}
+}
@@ -0,0 +1,106 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+OptionsResult describes the capabilities of a resource as reported
+in response to an OPTIONS request.
+*/
+type OptionsResult struct {
+	StatusCode int
+
+	// Allow lists the methods from the Allow header, e.g. ["GET", "HEAD"].
+	Allow []string
+
+	CORS CORSInfo
+
+	Header http.Header
+}
+
+/*
+CORSInfo holds the Access-Control-* response headers of a CORS
+preflight response.
+*/
+type CORSInfo struct {
+	AllowOrigin      string   // Access-Control-Allow-Origin
+	AllowMethods     []string // Access-Control-Allow-Methods
+	AllowHeaders     []string // Access-Control-Allow-Headers
+	ExposeHeaders    []string // Access-Control-Expose-Headers
+	AllowCredentials bool     // Access-Control-Allow-Credentials
+	MaxAge           time.Duration
+}
+
+/*
+Allows returns true if the given method is listed in the Allow header.
+*/
+func (r *OptionsResult) Allows(method string) bool {
+	for _, m := range r.Allow {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Options sends an OPTIONS request and returns the allowed methods and CORS
+headers of the response. To debug a CORS preflight, pass the headers
+a browser would send:
+
+    info, err := httpsimp.Options(apiURL, "/items", nil, http.Header{
+        "Origin":                         {"https://app.example.com"},
+        "Access-Control-Request-Method":  {"PUT"},
+        "Access-Control-Request-Headers": {"Content-Type"},
+    }, client)
+
+Any 2xx status is accepted; others result in an error, as usual.
+*/
+func Options(base, path string, params url.Values, headers http.Header, client HTTPClient) (*OptionsResult, error) {
+	var meta ResponseMeta
+	err := Do(MakeOptions(base, path, params, headers), client, None(), Meta(&meta))
+	if err != nil {
+		return nil, err
+	}
+	h := meta.Header
+	result := &OptionsResult{
+		StatusCode: meta.StatusCode,
+		Allow:      headerList(h, "Allow", true),
+		CORS: CORSInfo{
+			AllowOrigin:      h.Get("Access-Control-Allow-Origin"),
+			AllowMethods:     headerList(h, "Access-Control-Allow-Methods", true),
+			AllowHeaders:     headerList(h, "Access-Control-Allow-Headers", false),
+			ExposeHeaders:    headerList(h, "Access-Control-Expose-Headers", false),
+			AllowCredentials: strings.EqualFold(strings.TrimSpace(h.Get("Access-Control-Allow-Credentials")), "true"),
+		},
+		Header: h,
+	}
+	if secs, err := strconv.Atoi(strings.TrimSpace(h.Get("Access-Control-Max-Age"))); err == nil {
+		result.CORS.MaxAge = time.Duration(secs) * time.Second
+	}
+	return result, nil
+}
+
+// headerList splits comma-separated values of all occurrences of the given
+// header, optionally converting them to upper case (for method names).
+func headerList(h http.Header, name string, upper bool) []string {
+	var result []string
+	for _, v := range h.Values(name) {
+		for _, item := range strings.Split(v, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			if upper {
+				item = strings.ToUpper(item)
+			}
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions {
+			t.Errorf("method = %s", r.Method)
+		}
+		w.Header().Set("Allow", "GET, head,PUT")
+		if r.Header.Get("Origin") != "" {
+			w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+			w.Header().Set("Access-Control-Allow-Methods", "GET, PUT")
+			w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Add("Access-Control-Allow-Headers", "X-Custom, Authorization")
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Set("Access-Control-Max-Age", "600")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	info, err := Options(srv.URL, "/items", nil, http.Header{
+		"Origin":                        {"https://app.example.com"},
+		"Access-Control-Request-Method": {"PUT"},
+	}, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, e := info.Allow, []string{"GET", "HEAD", "PUT"}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Allow = %q, wanted %q", a, e)
+	}
+	if !info.Allows(http.MethodHead) || info.Allows(http.MethodDelete) {
+		t.Errorf("Allows is wrong for %q", info.Allow)
+	}
+	e := CORSInfo{
+		AllowOrigin:      "https://app.example.com",
+		AllowMethods:     []string{"GET", "PUT"},
+		AllowHeaders:     []string{"Content-Type", "X-Custom", "Authorization"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	if !reflect.DeepEqual(info.CORS, e) {
+		t.Errorf("CORS = %+v, wanted %+v", info.CORS, e)
+	}
+}
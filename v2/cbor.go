@@ -0,0 +1,50 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/url"
+)
+
+/*
+CBORCodec is the Codec used by EncodeCBORBody and CBOR. It is nil by default
+to keep this package free of dependencies; set it once during initialization,
+e.g. using github.com/fxamacker/cbor:
+
+    httpsimp.CBORCodec = httpsimp.CodecFuncs{cbor.Marshal, cbor.Unmarshal}
+*/
+var CBORCodec Codec
+
+/*
+EncodeCBORBody encodes the given object into CBOR (application/cbor) format
+using CBORCodec and sets the body and Content-Type on the given request.
+
+If encoding fails, Do returns a *BuildError without sending the request.
+
+To properly handle HTTP redirects, both Body and GetBody are set.
+*/
+func EncodeCBORBody(r *http.Request, obj interface{}) *http.Request {
+	return EncodeBody(r, CBORCodec, ContentTypeCBOR, obj)
+}
+
+/*
+CBOR is a Parser function that verifies the response status code and content
+type (which must be ContentTypeCBOR) and unmarshals the body into the result
+variable using CBORCodec.
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func CBOR(result interface{}, mopt ...ParseOption) Parser {
+	return MakeParser(ContentTypeCBOR, mopt, codecBodyParser(result, func() Codec { return CBORCodec }))
+}
+
+/*
+MakeCBOR builds a POST/PUT/etc request with the given URL, headers and body
+(which contains the given object encoded in CBOR format using CBORCodec).
+
+See MakeGet for the meaning of base, path, params and headers.
+
+If CBOR encoding fails, Do returns a *BuildError.
+*/
+func MakeCBOR(method string, base, path string, params url.Values, obj interface{}, headers http.Header) *http.Request {
+	return EncodeCBORBody(makeRequest(method, base, path, params, headers), obj)
+}
@@ -0,0 +1,38 @@
+package httpsimp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCBOR(t *testing.T) {
+	old := CBORCodec
+	CBORCodec = gobCodec
+	defer func() { CBORCodec = old }()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); r.Method == http.MethodPut && ct != ContentTypeCBOR {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", ContentTypeCBOR)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	var p msgpackPoint
+	err := Do(MakeCBOR(http.MethodPut, srv.URL, "/echo", nil, msgpackPoint{3, 4}, nil), http.DefaultClient, CBOR(&p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != (msgpackPoint{3, 4}) {
+		t.Errorf("got %+v", p)
+	}
+
+	err = Do(MakeGet(srv.URL, "/echo", nil, nil), http.DefaultClient, CBOR(&p, StatusSpec(http.StatusTeapot)))
+	if StatusCode(err) != http.StatusOK {
+		t.Errorf("expected an unmatched response error, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package httpsimp
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+/*
+CharsetDecoder wraps a reader of text in some character set into a reader
+of the same text in UTF-8.
+*/
+type CharsetDecoder func(r io.Reader) io.Reader
+
+var charsetDecoders = struct {
+	sync.RWMutex
+	m map[string]CharsetDecoder
+}{m: map[string]CharsetDecoder{
+	"iso-8859-1":   latin1Decoder,
+	"iso8859-1":    latin1Decoder,
+	"latin1":       latin1Decoder,
+	"l1":           latin1Decoder,
+	"windows-1252": windows1252Decoder,
+	"cp1252":       windows1252Decoder,
+}}
+
+/*
+RegisterCharset makes ConvertCharset (used by default by PlainText and HTML)
+support the given charset, as named in the charset parameter of Content-Type.
+UTF-8, US-ASCII, ISO-8859-1 and Windows-1252 are supported out of the box.
+To keep this package free of dependencies, others must be registered during
+initialization, e.g. using golang.org/x/text:
+
+    httpsimp.RegisterCharset("shift_jis", func(r io.Reader) io.Reader {
+        return japanese.ShiftJIS.NewDecoder().Reader(r)
+    })
+*/
+func RegisterCharset(name string, d CharsetDecoder) {
+	charsetDecoders.Lock()
+	defer charsetDecoders.Unlock()
+	charsetDecoders.m[strings.ToLower(name)] = d
+}
+
+/*
+ConvertCharset causes the parser to convert the body into UTF-8 according to
+the charset parameter of Content-Type (see RegisterCharset). Bodies without
+a charset are assumed to be UTF-8 already. A body in an unsupported charset
+results in a decoding error.
+
+PlainText and HTML convert bodies by default; use this option with other
+parsers like Bytes.
+*/
+func ConvertCharset() ParseOption {
+	return convertCharset
+}
+
+var convertCharset ParseOption = matchOptionFunc(func(m *Parser) {
+	m.convertCharset = true
+})
+
+// charsetDecoder returns the decoder for the given charset, or nil if
+// no conversion is necessary.
+func charsetDecoder(charset string) (CharsetDecoder, bool) {
+	charset = strings.ToLower(strings.TrimSpace(charset))
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return nil, true
+	}
+	charsetDecoders.RLock()
+	defer charsetDecoders.RUnlock()
+	d, ok := charsetDecoders.m[charset]
+	return d, ok
+}
+
+func latin1Decoder(r io.Reader) io.Reader {
+	return &singleByteReader{r: r}
+}
+
+func windows1252Decoder(r io.Reader) io.Reader {
+	return &singleByteReader{r: r, high: &windows1252High}
+}
+
+// windows1252High maps bytes 0x80-0x9F; others match ISO-8859-1.
+// Undefined bytes map to the same code points, as browsers do.
+var windows1252High = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+// singleByteReader converts ISO-8859-1 (or Windows-1252 when high is set)
+// into UTF-8.
+type singleByteReader struct {
+	r       io.Reader
+	high    *[32]rune
+	buf     []byte
+	pending []byte
+	err     error
+}
+
+func (s *singleByteReader) Read(p []byte) (int, error) {
+	if len(s.pending) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if s.buf == nil {
+			s.buf = make([]byte, 4096)
+		}
+		var n int
+		n, s.err = s.r.Read(s.buf)
+		out := s.pending[:0]
+		for _, b := range s.buf[:n] {
+			switch {
+			case b < utf8.RuneSelf:
+				out = append(out, b)
+			case s.high != nil && b < 0xA0:
+				out = appendRune(out, s.high[b-0x80])
+			default:
+				out = appendRune(out, rune(b))
+			}
+		}
+		s.pending = out
+		if len(s.pending) == 0 {
+			return 0, s.err
+		}
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func appendRune(b []byte, r rune) []byte {
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	return append(b, buf[:n]...)
+}
@@ -0,0 +1,64 @@
+package httpsimp
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPlainTextCharset(t *testing.T) {
+	tests := []struct {
+		ctype    string
+		body     []byte
+		expected string
+	}{
+		{"text/plain", []byte("caf\xc3\xa9"), "café"},
+		{"text/plain; charset=ISO-8859-1", []byte("caf\xe9"), "café"},
+		{"text/plain; charset=windows-1252", []byte("\x93caf\xe9\x94 \x80"), "“café” €"},
+	}
+	for _, test := range tests {
+		var s string
+		err := get(http.StatusOK, test.ctype, test.body, PlainText(&s))
+		if err != nil {
+			t.Errorf("%s: %v", test.ctype, err)
+		} else if s != test.expected {
+			t.Errorf("%s: got %q, wanted %q", test.ctype, s, test.expected)
+		}
+	}
+
+	var s string
+	err := get(http.StatusOK, "text/plain; charset=x-unknown", []byte("abc"), PlainText(&s))
+	if err == nil || !strings.Contains(err.Error(), `unsupported charset "x-unknown"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConvertCharsetRegistered(t *testing.T) {
+	RegisterCharset("x-upper", func(r io.Reader) io.Reader {
+		b, _ := ioutil.ReadAll(r)
+		return bytes.NewReader(bytes.ToUpper(b))
+	})
+
+	var b []byte
+	err := get(http.StatusOK, "application/octet-stream; charset=X-Upper", []byte("abc"), Bytes(&b, ConvertCharset()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "ABC" {
+		t.Errorf("got %q", b)
+	}
+}
+
+func TestSingleByteReaderLarge(t *testing.T) {
+	src := bytes.Repeat([]byte{'a', 0xe9}, 5000)
+	b, err := ioutil.ReadAll(latin1Decoder(bytes.NewReader(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != strings.Repeat("aé", 5000) {
+		t.Errorf("conversion mismatch")
+	}
+}
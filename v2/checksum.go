@@ -0,0 +1,145 @@
+package httpsimp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+/*
+ChecksumMismatchError is reported (as the DecodingError of a *ResponseError)
+when the body doesn't match the checksum passed to VerifySHA256 and friends.
+*/
+type ChecksumMismatchError struct {
+	Algorithm string // e.g. "sha256"
+	Expected  string // hex
+	Actual    string // hex
+}
+
+func (err *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch: expected %s, got %s", err.Algorithm, err.Expected, err.Actual)
+}
+
+/*
+VerifySHA256 causes the parser to compute the SHA-256 hash of the body
+while it is being consumed, and to fail with a *ChecksumMismatchError if it
+doesn't match the expected hex-encoded value. Unlike checking the result of
+Bytes, this works with File and streaming parsers without buffering
+the body:
+
+    err := httpsimp.Do(r, client, httpsimp.File(path, httpsimp.VerifySHA256(artifact.SHA256)))
+
+The parts of the body not read by the parser are read and hashed when
+the body is closed. Verification is skipped by Raw, which hands the body
+to the caller.
+*/
+func VerifySHA256(expected string) ParseOption {
+	return VerifyChecksum("sha256", sha256.New, expected)
+}
+
+/*
+VerifySHA1 is like VerifySHA256, but uses SHA-1.
+*/
+func VerifySHA1(expected string) ParseOption {
+	return VerifyChecksum("sha1", sha1.New, expected)
+}
+
+/*
+VerifyMD5 is like VerifySHA256, but uses MD5, which still appears in
+legacy artifact repositories (and Content-MD5 headers). Don't rely on it
+against tampering.
+*/
+func VerifyMD5(expected string) ParseOption {
+	return VerifyChecksum("md5", md5.New, expected)
+}
+
+/*
+VerifyChecksum is like VerifySHA256, but uses the given hash function,
+reporting the given algorithm name in errors.
+*/
+func VerifyChecksum(algorithm string, newHash func() hash.Hash, expected string) ParseOption {
+	c := &checksumSpec{algorithm, newHash, strings.ToLower(strings.TrimSpace(expected))}
+	return matchOptionFunc(func(m *Parser) {
+		m.checksums = append(m.checksums[:len(m.checksums):len(m.checksums)], c)
+	})
+}
+
+type checksumSpec struct {
+	algorithm string
+	newHash   func() hash.Hash
+	expected  string
+}
+
+// checksumBody hashes everything read from the body, and fails the final
+// read on a mismatch, so that the parser sees the error while consuming
+// the body. Closing it reads (and hashes) the rest, so that the entire body
+// is covered even if the parser stops reading early.
+type checksumBody struct {
+	io.ReadCloser
+	specs  []*checksumSpec
+	w      io.Writer
+	hashes []hash.Hash
+	eof    bool
+	err    error
+}
+
+func newChecksumBody(body io.ReadCloser, specs []*checksumSpec) *checksumBody {
+	b := &checksumBody{ReadCloser: body, specs: specs}
+	writers := make([]io.Writer, len(specs))
+	for i, s := range specs {
+		h := s.newHash()
+		b.hashes = append(b.hashes, h)
+		writers[i] = h
+	}
+	b.w = io.MultiWriter(writers...)
+	return b
+}
+
+func (b *checksumBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.w.Write(p[:n])
+	if err == io.EOF && !b.eof {
+		b.eof = true
+		if merr := b.compare(); merr != nil {
+			err = merr
+		}
+	}
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *checksumBody) Close() error {
+	if !b.eof && b.err == nil {
+		if _, err := io.Copy(ioutil.Discard, b); err != nil && b.err == nil {
+			b.err = err
+		}
+	}
+	return b.ReadCloser.Close()
+}
+
+// verify returns the mismatch or read error, if any; it returns nil
+// if the body has not been read entirely (e.g. handed out by Raw).
+func (b *checksumBody) verify() error {
+	return b.err
+}
+
+func (b *checksumBody) compare() error {
+	for i, s := range b.specs {
+		actual := hex.EncodeToString(b.hashes[i].Sum(nil))
+		if actual != s.expected {
+			return &ChecksumMismatchError{s.algorithm, s.expected, actual}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package httpsimp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	body := []byte(`{"foo": 42}`)
+	sha256sum := sha256.Sum256(body)
+	sha1sum := sha1.Sum(body)
+	md5sum := md5.Sum(body)
+
+	var b []byte
+	err := get(http.StatusOK, ContentTypeJSON, body, Bytes(&b,
+		VerifySHA256(hex.EncodeToString(sha256sum[:])),
+		VerifySHA1(hex.EncodeToString(sha1sum[:])),
+		VerifyMD5(hex.EncodeToString(md5sum[:]))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// JSON stops reading at the end of the value, the rest is hashed on close
+	var v struct{ Foo int }
+	err = get(http.StatusOK, ContentTypeJSON, append(body, "\n\n"...), JSON(&v, VerifySHA256(hex.EncodeToString(sha256sum[:]))))
+	var cme *ChecksumMismatchError
+	if !errors.As(err, &cme) || cme.Algorithm != "sha256" || cme.Expected != hex.EncodeToString(sha256sum[:]) {
+		t.Fatalf("err = %v", err)
+	}
+
+	err = get(http.StatusOK, ContentTypeJSON, body, JSON(&v, VerifyMD5("0123456789abcdef0123456789abcdef")))
+	if !errors.As(err, &cme) || cme.Actual != hex.EncodeToString(md5sum[:]) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,437 @@
+package httpsimp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Client is an HTTPClient that sends requests via an internally managed
+*http.Client configured with ClientOptions. Pass it to Do like any other
+HTTPClient:
+
+    client := httpsimp.NewClient(
+        httpsimp.Timeout(10*time.Second),
+        httpsimp.HostTLSConfig("*.internal.example.com", internalTLS))
+
+    err := httpsimp.Do(httpsimp.MakeGet(baseURL, path, nil, nil), client, httpsimp.JSON(&resp))
+
+A Client is safe for concurrent use, and can be reconfigured on the fly
+using Update.
+*/
+type Client struct {
+	mu    sync.Mutex   // serializes Update calls
+	state atomic.Value // *clientState
+}
+
+type clientState struct {
+	config     *clientConfig
+	httpClient *http.Client
+}
+
+type clientConfig struct {
+	timeout   time.Duration
+	tlsConfig *tls.Config
+	hostTLS   []hostTLSConfig
+
+	baseURL        string
+	bearerToken    string
+	acceptEncoding string
+	defaultParams  url.Values
+	requestID      func() string
+	injectTrace    func(ctx context.Context, h http.Header)
+
+	jar         http.CookieJar
+	proxy       *url.URL
+	unixSockets map[string]string
+
+	csrfHeader, csrfToken string
+
+	errorBodyLimit int64
+
+	validate bool
+
+	maxIdleConnsPerHost int
+
+	retry *retryConfig
+
+	// transportChanged is set by options that require the transport
+	// to be rebuilt on Update.
+	transportChanged bool
+
+	minTLSVersion  uint16
+	cipherPolicy   string
+	tlsWarningHook func(host string, state tls.ConnectionState, reason string)
+
+	endpoints []*endpoint
+}
+
+type hostTLSConfig struct {
+	pattern   string
+	tlsConfig *tls.Config
+}
+
+/*
+ClientOption is passed into NewClient to configure a Client.
+
+You cannot define custom client options.
+*/
+type ClientOption interface {
+	applyToClient(c *clientConfig)
+}
+
+type clientOptionFunc func(c *clientConfig)
+
+func (o clientOptionFunc) applyToClient(c *clientConfig) {
+	o(c)
+}
+
+/*
+ConfigError is returned by Client for every request when the client has been
+configured with invalid options, like an unknown CipherPolicy. Nothing is
+sent; unlike BuildError, it is not caused by the request itself, and goes
+away once the client is fixed via Update.
+*/
+type ConfigError struct {
+	Err error
+}
+
+func (err *ConfigError) Error() string {
+	return fmt.Sprintf("invalid client configuration: %v", err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *ConfigError) Unwrap() error {
+	return err.Err
+}
+
+/*
+NewClient returns a new Client configured with the given options.
+*/
+func NewClient(opts ...ClientOption) *Client {
+	config := new(clientConfig)
+	for _, o := range opts {
+		o.applyToClient(config)
+	}
+	config.transportChanged = false
+	c := new(Client)
+	c.state.Store(&clientState{
+		config:     config,
+		httpClient: config.buildHTTPClient(),
+	})
+	return c
+}
+
+/*
+Update atomically applies the given options on top of the current
+configuration of the client. Requests started after Update returns use
+the new configuration, while in-flight requests complete with the old one.
+This makes Update suitable for hot configuration reloads, e.g. rotating
+credentials:
+
+    client.Update(httpsimp.BearerToken(newToken))
+
+Connection pools are kept unless TLS-related options are changed.
+HostTLSConfig and Endpoint replace previously registered entries with the same
+pattern or name.
+*/
+func (c *Client) Update(opts ...ClientOption) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old := c.load()
+	config := old.config.clone()
+	for _, o := range opts {
+		o.applyToClient(config)
+	}
+
+	if config.transportChanged {
+		config.transportChanged = false
+		c.state.Store(&clientState{config, config.buildHTTPClient()})
+		closeIdleConnections(old.httpClient.Transport)
+	} else {
+		c.state.Store(&clientState{config, &http.Client{
+			Transport: old.httpClient.Transport,
+			Timeout:   config.timeout,
+			Jar:       config.jar,
+		}})
+	}
+}
+
+func (c *Client) load() *clientState {
+	return c.state.Load().(*clientState)
+}
+
+func (c *clientConfig) clone() *clientConfig {
+	clone := *c
+	clone.hostTLS = append([]hostTLSConfig(nil), c.hostTLS...)
+	clone.endpoints = append([]*endpoint(nil), c.endpoints...)
+	return &clone
+}
+
+/*
+Do sends the given request, implementing HTTPClient.
+*/
+func (c *Client) Do(r *http.Request) (*http.Response, error) {
+	s := c.load()
+	if err := s.config.tlsPolicyError(); err != nil {
+		return nil, &ConfigError{err}
+	}
+	if s.config.validate {
+		if err := Validate(r); err != nil {
+			return nil, err
+		}
+	}
+	r, err := s.config.prepare(r)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.errorBodyLimit != 0 {
+		r = withErrorBodyLimit(r, s.config.errorBodyLimit)
+	}
+	resp, err := s.doWithRetries(r, s.config.policyFor(r))
+	if err != nil && s.config.requestID != nil {
+		err = &requestIDError{r.Header.Get(RequestIDHeader), err}
+	}
+	return resp, err
+}
+
+func (c *clientConfig) prepare(r *http.Request) (*http.Request, error) {
+	needsBase := c.needsBaseURL(r)
+	needsAuth := c.bearerToken != "" && r.Header.Get("Authorization") == ""
+	needsEncoding := c.acceptEncoding != "" && r.Header.Get("Accept-Encoding") == ""
+	needsParams := len(c.defaultParams) > 0
+	needsID := c.requestID != nil && r.Header.Get(RequestIDHeader) == ""
+	needsTrace := c.injectTrace != nil && r.Header.Get(TraceParentHeader) == ""
+	needsHeader := c.jar != nil && r.Header == nil // http.Client adds cookies to it
+	needsCSRF := c.needsCSRF(r)
+	if !needsBase && !needsAuth && !needsEncoding && !needsParams && !needsID && !needsTrace && !needsHeader && !needsCSRF {
+		return r, nil
+	}
+
+	r = r.Clone(r.Context())
+	if needsBase {
+		u, err := c.resolveURL(r.URL)
+		if err != nil {
+			return nil, err
+		}
+		r.URL = u
+		r.Host = ""
+	}
+	if needsParams {
+		q := r.URL.Query()
+		for k, v := range c.defaultParams {
+			if _, ok := q[k]; !ok {
+				q[k] = v
+			}
+		}
+		r.URL.RawQuery = strings.Replace(q.Encode(), "+", "%20", -1)
+	}
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	if needsAuth {
+		r.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+	if needsEncoding {
+		r.Header.Set("Accept-Encoding", c.acceptEncoding)
+	}
+	if needsID {
+		r.Header.Set(RequestIDHeader, c.newRequestID(r))
+	}
+	if needsTrace {
+		c.injectTrace(r.Context(), r.Header)
+	}
+	if needsCSRF {
+		r.Header.Set(c.csrfHeader, c.csrfToken)
+	}
+	return r, nil
+}
+
+func (c *clientConfig) needsBaseURL(r *http.Request) bool {
+	return c.baseURL != "" && r.URL.Scheme == "" && r.URL.Host == ""
+}
+
+func (c *clientConfig) resolveURL(u *url.URL) (*url.URL, error) {
+	resolved, err := url.Parse(c.baseURL + u.String())
+	if err != nil {
+		return nil, &BuildError{err}
+	}
+	return resolved, nil
+}
+
+/*
+Timeout sets the overall time limit for requests made by the client,
+including reading the response body. Zero means no timeout (not recommended).
+*/
+func Timeout(d time.Duration) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.timeout = d
+	})
+}
+
+/*
+TLSConfig sets the TLS configuration used for hosts not matched by
+any HostTLSConfig option.
+*/
+func TLSConfig(cfg *tls.Config) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.tlsConfig = cfg
+		c.transportChanged = true
+	})
+}
+
+/*
+HostTLSConfig sets the TLS configuration (e.g. client certificates
+or a custom CA pool) used for hosts matching the given pattern.
+
+The pattern is either an exact host name like "api.example.com",
+or a wildcard like "*.example.com" matching all subdomains of example.com
+(but not example.com itself). If the pattern includes a port, like
+"internal.example.com:8443", the port must match too.
+When several patterns match, the first one wins. Specifying the same pattern
+again replaces its configuration.
+
+Each distinct configuration gets its own connection pool.
+*/
+func HostTLSConfig(pattern string, cfg *tls.Config) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		pattern := strings.ToLower(pattern)
+		c.transportChanged = true
+		for i, h := range c.hostTLS {
+			if h.pattern == pattern {
+				c.hostTLS[i].tlsConfig = cfg
+				return
+			}
+		}
+		c.hostTLS = append(c.hostTLS, hostTLSConfig{pattern, cfg})
+	})
+}
+
+/*
+BaseURL sets the URL prepended to the URLs of requests that have no scheme
+and host, so that requests can be built with an empty base:
+
+    client := httpsimp.NewClient(httpsimp.BaseURL("https://api.example.com/v1"))
+    err := httpsimp.Do(httpsimp.MakeGet("", "/users", nil, nil), client, httpsimp.JSON(&users))
+*/
+func BaseURL(base string) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.baseURL = strings.TrimSuffix(base, "/")
+	})
+}
+
+/*
+BearerToken sets the token sent in the Authorization header of requests
+that don't specify one explicitly. An empty token disables the header.
+*/
+func BearerToken(token string) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.bearerToken = token
+	})
+}
+
+/*
+DefaultParams sets query string parameters added to every request sent
+by the client, unless the request already has a parameter of the same name.
+This suits APIs that authenticate via a query key on every call:
+
+    client := httpsimp.NewClient(httpsimp.DefaultParams(url.Values{"api_key": {key}}))
+
+A nil or empty map removes the default parameters. Errors returned by Do
+don't include these parameters.
+*/
+func DefaultParams(params url.Values) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.defaultParams = params
+	})
+}
+
+func (c *clientConfig) buildHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: c.buildTransport(),
+		Timeout:   c.timeout,
+		Jar:       c.jar,
+	}
+}
+
+func (c *clientConfig) buildTransport() http.RoundTripper {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = c.applyTLSPolicy(c.tlsConfig)
+	base.Proxy = c.proxyFunc()
+	c.applyUnixSockets(base)
+	if c.maxIdleConnsPerHost != 0 {
+		base.MaxIdleConnsPerHost = c.maxIdleConnsPerHost
+	}
+	if len(c.hostTLS) == 0 {
+		return base
+	}
+
+	rt := &hostRoutingTransport{fallback: base}
+	for _, h := range c.hostTLS {
+		t := base.Clone()
+		t.TLSClientConfig = c.applyTLSPolicy(h.tlsConfig)
+		rt.routes = append(rt.routes, hostRoute{h.pattern, t})
+	}
+	return rt
+}
+
+type hostRoute struct {
+	pattern   string
+	transport http.RoundTripper
+}
+
+type hostRoutingTransport struct {
+	routes   []hostRoute
+	fallback http.RoundTripper
+}
+
+func (t *hostRoutingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	for _, route := range t.routes {
+		if matchHostPattern(route.pattern, r.URL.Host) {
+			return route.transport.RoundTrip(r)
+		}
+	}
+	return t.fallback.RoundTrip(r)
+}
+
+func matchHostPattern(pattern, hostport string) bool {
+	hostport = strings.ToLower(hostport)
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+
+	patternHost := pattern
+	if h, _, err := net.SplitHostPort(pattern); err == nil {
+		patternHost = h
+		host = hostport
+	}
+
+	if strings.HasPrefix(patternHost, "*.") {
+		suffix := pattern[1:]
+		return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+	}
+	return host == pattern
+}
+
+func (t *hostRoutingTransport) CloseIdleConnections() {
+	for _, route := range t.routes {
+		closeIdleConnections(route.transport)
+	}
+	closeIdleConnections(t.fallback)
+}
+
+func closeIdleConnections(rt http.RoundTripper) {
+	if c, ok := rt.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
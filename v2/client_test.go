@@ -0,0 +1,195 @@
+package httpsimp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestHostTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte("secure"))
+	}))
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	var text string
+	client := NewClient(HostTLSConfig("127.0.0.1", &tls.Config{RootCAs: pool}))
+	err := Do(MakeGet(srv.URL, "", nil, nil), client, PlainText(&text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "secure" {
+		t.Fatalf("invalid value of text: %q", text)
+	}
+
+	client = NewClient(HostTLSConfig("*.example.com", &tls.Config{RootCAs: pool}))
+	err = Do(MakeGet(srv.URL, "", nil, nil), client, PlainText(&text))
+	if err == nil {
+		t.Fatal("err is nil for a host not matching the pattern")
+	}
+}
+
+func TestMatchHostPattern(t *testing.T) {
+	tests := []struct {
+		pattern, host string
+		expected      bool
+	}{
+		{"api.example.com", "api.example.com", true},
+		{"api.example.com", "API.example.com:443", true},
+		{"api.example.com", "www.example.com", false},
+		{"*.example.com", "api.example.com", true},
+		{"*.example.com", "a.b.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"internal:8443", "internal:8443", true},
+		{"internal:8443", "internal:443", false},
+		{"*.example.com:8443", "api.example.com:8443", true},
+	}
+	for _, tt := range tests {
+		if actual := matchHostPattern(tt.pattern, tt.host); actual != tt.expected {
+			t.Errorf("matchHostPattern(%q, %q) = %v, wanted %v", tt.pattern, tt.host, actual, tt.expected)
+		}
+	}
+}
+
+func TestTLSWarningHook(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	var warnings []string
+	client := NewClient(
+		TLSConfig(&tls.Config{RootCAs: pool}),
+		CipherPolicy(CipherPolicyIntermediate),
+		TLSWarningHook(func(host string, state tls.ConnectionState, reason string) {
+			warnings = append(warnings, reason)
+		}))
+	err := Do(MakeGet(srv.URL, "", nil, nil), client, None())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || warnings[0] != "negotiated the minimum allowed TLS version TLS 1.2" {
+		t.Fatalf("invalid warnings: %q", warnings)
+	}
+
+	client = NewClient(TLSConfig(&tls.Config{RootCAs: pool}), CipherPolicy(CipherPolicyModern))
+	err = Do(MakeGet(srv.URL, "", nil, nil), client, None())
+	if err == nil {
+		t.Fatal("err is nil for a TLS 1.2 server with the modern policy")
+	}
+
+	client = NewClient(TLSConfig(&tls.Config{RootCAs: pool}), CipherPolicy("mdoern"))
+	err = Do(MakeGet(srv.URL, "", nil, nil), client, None())
+	var ce *ConfigError
+	var be *BuildError
+	if !errors.As(err, &ce) || errors.As(err, &be) {
+		t.Fatalf("err = %v, wanted a config error for an unknown cipher policy", err)
+	}
+	client.Update(CipherPolicy(CipherPolicyIntermediate))
+	if err := Do(MakeGet(srv.URL, "", nil, nil), client, None()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientUpdate(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("slow") != "" {
+			close(started)
+			<-release
+		}
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	client := NewClient(BaseURL(srv.URL+"/v1/"), BearerToken("old"))
+	transport := client.load().httpClient.Transport
+
+	slow := make(chan string)
+	go func() {
+		var text string
+		err := Do(MakeGet("", "/slow", url.Values{"slow": {"1"}}, nil), client, PlainText(&text))
+		if err != nil {
+			text = err.Error()
+		}
+		slow <- text
+	}()
+	<-started
+
+	client.Update(BaseURL(srv.URL+"/v2"), BearerToken("new"))
+	if client.load().httpClient.Transport != transport {
+		t.Errorf("transport rebuilt without TLS changes")
+	}
+
+	var text string
+	err := Do(MakeGet("", "/users", nil, nil), client, PlainText(&text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "/v2/users Bearer new" {
+		t.Fatalf("invalid value of text: %q", text)
+	}
+
+	close(release)
+	if text := <-slow; text != "/v1/slow Bearer old" {
+		t.Fatalf("invalid value of in-flight text: %q", text)
+	}
+
+	client.Update(MinTLSVersion(tls.VersionTLS12))
+	if client.load().httpClient.Transport == transport {
+		t.Errorf("transport not rebuilt after TLS changes")
+	}
+	err = Do(MakeGet(srv.URL, "/abs", nil, http.Header{"Authorization": {"Basic x"}}), client, PlainText(&text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "/abs Basic x" {
+		t.Fatalf("invalid value of text: %q", text)
+	}
+
+	client = NewClient(MinTLSVersion(tls.VersionTLS12))
+	transport = client.load().httpClient.Transport
+	client.Update(BearerToken("new"))
+	if client.load().httpClient.Transport != transport {
+		t.Errorf("transport rebuilt by the first Update of a client with TLS options")
+	}
+}
+
+func TestClientDefaultParams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte(r.URL.RawQuery))
+	}))
+	defer srv.Close()
+
+	client := NewClient(BaseURL(srv.URL), DefaultParams(url.Values{"api_key": {"k"}, "format": {"json"}}))
+
+	var text string
+	err := Do(MakeGet("", "/x", url.Values{"format": {"xml"}, "q": {"a b"}}, nil), client, PlainText(&text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "api_key=k&format=xml&q=a%20b"; text != expected {
+		t.Errorf("query = %q, wanted %q", text, expected)
+	}
+
+	client.Update(DefaultParams(nil))
+	err = Do(MakeGet("", "/x", nil, nil), client, PlainText(&text))
+	if err != nil || text != "" {
+		t.Errorf("query = %q, %v, wanted none", text, err)
+	}
+}
@@ -0,0 +1,648 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type spec struct {
+	Info struct {
+		Title string `json:"title"`
+	} `json:"info"`
+	Paths      map[string]*pathItem `json:"paths"`
+	Components struct {
+		Schemas       map[string]*schema      `json:"schemas"`
+		Parameters    map[string]*parameter   `json:"parameters"`
+		RequestBodies map[string]*requestBody `json:"requestBodies"`
+		Responses     map[string]*response    `json:"responses"`
+	} `json:"components"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `json:"parameters"`
+	Get        *operation   `json:"get"`
+	Put        *operation   `json:"put"`
+	Post       *operation   `json:"post"`
+	Delete     *operation   `json:"delete"`
+	Options    *operation   `json:"options"`
+	Head       *operation   `json:"head"`
+	Patch      *operation   `json:"patch"`
+}
+
+func (p *pathItem) operations() []struct {
+	method string
+	op     *operation
+} {
+	all := []struct {
+		method string
+		op     *operation
+	}{
+		{"GET", p.Get}, {"PUT", p.Put}, {"POST", p.Post}, {"DELETE", p.Delete},
+		{"OPTIONS", p.Options}, {"HEAD", p.Head}, {"PATCH", p.Patch},
+	}
+	result := all[:0]
+	for _, o := range all {
+		if o.op != nil {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+type operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Deprecated  bool                 `json:"deprecated"`
+	Parameters  []*parameter         `json:"parameters"`
+	RequestBody *requestBody         `json:"requestBody"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Ref      string                `json:"$ref"`
+	Required bool                  `json:"required"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Ref     string                `json:"$ref"`
+	Content map[string]*mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 interface{}        `json:"type"`
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	Enum                 []interface{}      `json:"enum"`
+	AllOf                []*schema          `json:"allOf"`
+}
+
+// typeName returns the schema type, ignoring "null" in OpenAPI 3.1 type lists.
+func (s *schema) typeName() string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if name, ok := v.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	if len(s.Properties) > 0 {
+		return "object"
+	}
+	return ""
+}
+
+type generator struct {
+	spec    *spec
+	pkg     string
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+// httpsimpPath is imported as httpsimp, since the package name doesn't match
+// the last element of the import path.
+const httpsimpPath = "github.com/andreyvit/httpsimplified/v2"
+
+func generate(data []byte, pkg string) ([]byte, error) {
+	var sp spec
+	if err := json.Unmarshal(data, &sp); err != nil {
+		return nil, fmt.Errorf("cannot parse spec (only JSON is supported): %w", err)
+	}
+	g := &generator{spec: &sp, pkg: pkg, imports: make(map[string]bool)}
+
+	if err := g.genSchemas(); err != nil {
+		return nil, err
+	}
+	g.genClient()
+	if err := g.genOperations(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by httpsimp-gen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	if len(g.imports) > 0 {
+		var std, ext []string
+		for imp := range g.imports {
+			if strings.Contains(imp, ".") {
+				ext = append(ext, imp)
+			} else {
+				std = append(std, imp)
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(ext)
+		out.WriteString("import (\n")
+		for _, imp := range std {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+		if len(ext) > 0 {
+			out.WriteString("\n")
+			for _, imp := range ext {
+				if imp == httpsimpPath {
+					fmt.Fprintf(&out, "\thttpsimp %q\n", imp)
+				} else {
+					fmt.Fprintf(&out, "\t%q\n", imp)
+				}
+			}
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(g.buf.Bytes())
+
+	code, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid code: %w", err)
+	}
+	return code, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) genSchemas() error {
+	for _, name := range sortedKeys(g.spec.Components.Schemas) {
+		s := g.spec.Components.Schemas[name]
+		typeName := goName(name)
+		g.comment(s.Description)
+		if s.typeName() == "object" && len(s.Properties) > 0 {
+			g.printf("type %s %s\n\n", typeName, g.structType(s))
+			continue
+		}
+		g.printf("type %s %s\n\n", typeName, g.goType(s))
+
+		if s.typeName() == "string" && len(s.Enum) > 0 {
+			g.printf("const (\n")
+			for _, v := range s.Enum {
+				if str, ok := v.(string); ok {
+					g.printf("\t%s%s %s = %q\n", typeName, goName(str), typeName, str)
+				}
+			}
+			g.printf(")\n\n")
+		}
+	}
+	return nil
+}
+
+func (g *generator) structType(s *schema) string {
+	required := make(map[string]bool)
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for _, prop := range sortedKeys(s.Properties) {
+		ps := s.Properties[prop]
+		if ps.Description != "" {
+			for _, line := range strings.Split(strings.TrimSpace(ps.Description), "\n") {
+				fmt.Fprintf(&b, "// %s\n", line)
+			}
+		}
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "%s %s `json:%q`\n", goName(prop), g.goType(ps), tag)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func (g *generator) goType(s *schema) string {
+	if s == nil {
+		return "interface{}"
+	}
+	if s.Ref != "" {
+		return goName(refName(s.Ref))
+	}
+	if len(s.AllOf) == 1 {
+		return g.goType(s.AllOf[0])
+	}
+	switch s.typeName() {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = true
+			return "time.Time"
+		case "byte":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		if s.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if s.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.goType(s.Items)
+	case "object":
+		if len(s.Properties) > 0 {
+			return g.structType(s)
+		}
+		var additional schema
+		if len(s.AdditionalProperties) > 0 && json.Unmarshal(s.AdditionalProperties, &additional) == nil {
+			return "map[string]" + g.goType(&additional)
+		}
+		return "map[string]interface{}"
+	default:
+		return "interface{}"
+	}
+}
+
+func (g *generator) comment(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		g.printf("// %s\n", strings.TrimRight(line, " "))
+	}
+}
+
+func (g *generator) genClient() {
+	g.imports[httpsimpPath] = true
+	title := g.spec.Info.Title
+	if title == "" {
+		title = "the"
+	}
+	g.printf(`// Client calls %s API. BaseURL (like "https://api.example.com/v1") is
+// prepended to operation paths; HTTPClient is used to send requests
+// (e.g. an *http.Client with a timeout, or an *httpsimp.Client).
+type Client struct {
+	BaseURL    string
+	HTTPClient httpsimp.HTTPClient
+}
+
+`, title)
+}
+
+func (g *generator) genOperations() error {
+	seen := make(map[string]string)
+	for _, path := range sortedKeys(g.spec.Paths) {
+		item := g.spec.Paths[path]
+		for _, o := range item.operations() {
+			name := goName(o.op.OperationID)
+			if o.op.OperationID == "" {
+				name = goName(strings.ToLower(o.method) + " " + path)
+			}
+			if prev, ok := seen[name]; ok {
+				return fmt.Errorf("operations %s and %s %s both map to method %s", prev, o.method, path, name)
+			}
+			seen[name] = o.method + " " + path
+
+			params, err := g.resolveParams(append(append([]*parameter(nil), item.Parameters...), o.op.Parameters...))
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", o.method, path, err)
+			}
+			if err := g.genOperation(name, o.method, path, o.op, params); err != nil {
+				return fmt.Errorf("%s %s: %w", o.method, path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *generator) resolveParams(params []*parameter) ([]*parameter, error) {
+	var result []*parameter
+	index := make(map[string]int)
+	for _, p := range params {
+		if p.Ref != "" {
+			resolved, ok := g.spec.Components.Parameters[refName(p.Ref)]
+			if !ok {
+				return nil, fmt.Errorf("unresolved reference %s", p.Ref)
+			}
+			p = resolved
+		}
+		if p.In == "cookie" {
+			continue
+		}
+		// operation-level parameters override path-level ones
+		key := p.In + ":" + p.Name
+		if i, ok := index[key]; ok {
+			result[i] = p
+		} else {
+			index[key] = len(result)
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (g *generator) genOperation(name, method, path string, op *operation, params []*parameter) error {
+	body := op.RequestBody
+	if body != nil && body.Ref != "" {
+		resolved, ok := g.spec.Components.RequestBodies[refName(body.Ref)]
+		if !ok {
+			return fmt.Errorf("unresolved reference %s", body.Ref)
+		}
+		body = resolved
+	}
+	var bodySchema *schema
+	if body != nil {
+		if mt := jsonMediaType(body.Content); mt != nil {
+			bodySchema = mt.Schema
+		}
+	}
+
+	resultSchema, err := g.successSchema(op)
+	if err != nil {
+		return err
+	}
+
+	hasRequest := len(params) > 0 || bodySchema != nil
+	reqType := name + "Request"
+	if hasRequest {
+		g.printf("// %s holds the parameters of %s.\n", reqType, name)
+		g.printf("type %s struct {\n", reqType)
+		for _, p := range params {
+			g.comment(p.Description)
+			typ := g.goType(p.Schema)
+			if !p.Required && p.In != "path" && !strings.HasPrefix(typ, "[]") {
+				typ = "*" + typ
+			}
+			g.printf("%s %s\n", goName(p.Name), typ)
+		}
+		if bodySchema != nil {
+			g.printf("Body %s\n", g.goType(bodySchema))
+		}
+		g.printf("}\n\n")
+	}
+
+	resultType := ""
+	if resultSchema != nil {
+		resultType = g.goType(resultSchema)
+	}
+	byValue := strings.HasPrefix(resultType, "[]") || strings.HasPrefix(resultType, "map[")
+
+	g.printf("// %s calls %s %s", name, method, path)
+	if op.Summary != "" {
+		g.printf(": %s", strings.TrimSuffix(strings.TrimSpace(op.Summary), "."))
+	}
+	g.printf(".\n")
+	if op.Deprecated {
+		g.printf("//\n// Deprecated: the operation is deprecated by the API.\n")
+	}
+	g.imports["context"] = true
+	args := "ctx context.Context"
+	if hasRequest {
+		args += ", req *" + reqType
+	}
+	switch {
+	case resultType == "":
+		g.printf("func (c *Client) %s(%s) error {\n", name, args)
+	case byValue:
+		g.printf("func (c *Client) %s(%s) (%s, error) {\n", name, args, resultType)
+	default:
+		g.printf("func (c *Client) %s(%s) (*%s, error) {\n", name, args, resultType)
+	}
+
+	g.printf("path := %s\n", g.pathExpr(path, params))
+
+	query, header := "nil", "nil"
+	for _, p := range params {
+		switch p.In {
+		case "query":
+			if query == "nil" {
+				g.imports["net/url"] = true
+				g.printf("query := url.Values{}\n")
+				query = "query"
+			}
+			g.setParam("query", p)
+		case "header":
+			if header == "nil" {
+				g.imports["net/http"] = true
+				g.printf("header := http.Header{}\n")
+				header = "header"
+			}
+			g.setParam("header", p)
+		}
+	}
+
+	if bodySchema != nil {
+		g.printf("r := httpsimp.MakeJSON(%q, c.BaseURL, path, %s, req.Body, %s)\n", method, query, header)
+	} else {
+		g.printf("r := httpsimp.Make(%q, c.BaseURL, path, %s, nil, %s)\n", method, query, header)
+	}
+
+	switch {
+	case resultType == "":
+		g.printf("return httpsimp.Do(httpsimp.Apply(r, httpsimp.WithContext(ctx)), c.HTTPClient, httpsimp.None())\n")
+	case byValue:
+		g.printf("var result %s\n", resultType)
+		g.printf("err := httpsimp.Do(httpsimp.Apply(r, httpsimp.WithContext(ctx)), c.HTTPClient, httpsimp.JSON(&result))\n")
+		g.printf("return result, err\n")
+	default:
+		g.printf("var result %s\n", resultType)
+		g.printf("if err := httpsimp.Do(httpsimp.Apply(r, httpsimp.WithContext(ctx)), c.HTTPClient, httpsimp.JSON(&result)); err != nil {\nreturn nil, err\n}\n")
+		g.printf("return &result, nil\n")
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// successSchema returns the JSON schema of the first 2xx response.
+func (g *generator) successSchema(op *operation) (*schema, error) {
+	var codes []string
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		resp := op.Responses[code]
+		if resp.Ref != "" {
+			resolved, ok := g.spec.Components.Responses[refName(resp.Ref)]
+			if !ok {
+				return nil, fmt.Errorf("unresolved reference %s", resp.Ref)
+			}
+			resp = resolved
+		}
+		if mt := jsonMediaType(resp.Content); mt != nil && mt.Schema != nil {
+			return mt.Schema, nil
+		}
+	}
+	return nil, nil
+}
+
+func (g *generator) pathExpr(path string, params []*parameter) string {
+	byName := make(map[string]*parameter)
+	for _, p := range params {
+		if p.In == "path" {
+			byName[p.Name] = p
+		}
+	}
+
+	var parts []string
+	for path != "" {
+		i := strings.Index(path, "{")
+		j := strings.Index(path, "}")
+		if i < 0 || j < i {
+			parts = append(parts, strconv.Quote(path))
+			break
+		}
+		if i > 0 {
+			parts = append(parts, strconv.Quote(path[:i]))
+		}
+		name := path[i+1 : j]
+		if _, ok := byName[name]; ok {
+			g.imports["net/url"] = true
+			parts = append(parts, "url.PathEscape("+g.stringExpr("req."+goName(name), byName[name].Schema)+")")
+		} else {
+			parts = append(parts, strconv.Quote(path[i:j+1]))
+		}
+		path = path[j+1:]
+	}
+	if len(parts) == 0 {
+		return `""`
+	}
+	return strings.Join(parts, " + ")
+}
+
+func (g *generator) setParam(target string, p *parameter) {
+	field := "req." + goName(p.Name)
+	typ := g.goType(p.Schema)
+	switch {
+	case strings.HasPrefix(typ, "[]"):
+		var items *schema
+		if p.Schema != nil {
+			items = p.Schema.Items
+		}
+		g.printf("for _, v := range %s {\n%s.Add(%q, %s)\n}\n", field, target, p.Name, g.stringExpr("v", items))
+	case p.Required:
+		g.printf("%s.Set(%q, %s)\n", target, p.Name, g.stringExpr(field, p.Schema))
+	default:
+		g.printf("if %s != nil {\n%s.Set(%q, %s)\n}\n", field, target, p.Name, g.stringExpr("*"+field, p.Schema))
+	}
+}
+
+func (g *generator) stringExpr(expr string, s *schema) string {
+	switch g.goType(s) {
+	case "string":
+		return expr
+	case "time.Time":
+		if strings.HasPrefix(expr, "*") {
+			expr = "(" + expr + ")"
+		}
+		return expr + ".Format(time.RFC3339)"
+	default:
+		g.imports["fmt"] = true
+		return "fmt.Sprint(" + expr + ")"
+	}
+}
+
+func jsonMediaType(content map[string]*mediaType) *mediaType {
+	if mt, ok := content["application/json"]; ok {
+		return mt
+	}
+	for _, ctype := range sortedKeys(content) {
+		if strings.HasSuffix(ctype, "+json") {
+			return content[ctype]
+		}
+	}
+	return nil
+}
+
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+var initialisms = map[string]string{
+	"Api": "API", "Http": "HTTP", "Https": "HTTPS", "Id": "ID", "Ids": "IDs",
+	"Json": "JSON", "Url": "URL", "Uri": "URI", "Uuid": "UUID", "Xml": "XML",
+}
+
+// goName converts a name like "pet_id", "petId" or "get /pets/{id}"
+// into an exported Go identifier like PetID.
+func goName(s string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(word) > 0 && (unicode.IsLower(word[len(word)-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		w = strings.ToUpper(w[:1]) + strings.ToLower(w[1:])
+		if in, ok := initialisms[w]; ok {
+			w = in
+		}
+		b.WriteString(w)
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]*schema:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]*pathItem:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]*mediaType:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
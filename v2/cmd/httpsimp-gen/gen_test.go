@@ -0,0 +1,96 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/petstore.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := generate(data, "petstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(code)
+
+	for _, expected := range []string{
+		"package petstore\n",
+		`httpsimp "github.com/andreyvit/httpsimplified/v2"`,
+		"type Pet struct {",
+		"\tID     int64             `json:\"id\"`",
+		"\tBornAt time.Time         `json:\"born_at,omitempty\"`",
+		"\tStatus Status `json:\"status,omitempty\"`",
+		`StatusAvailable Status = "available"`,
+		"func (c *Client) ListPets(ctx context.Context, req *ListPetsRequest) ([]Pet, error) {",
+		"\tLimit *int32\n",
+		"\t\tquery.Add(\"tag\", v)\n",
+		"\t\tquery.Set(\"since\", (*req.Since).Format(time.RFC3339))\n",
+		"return httpsimp.Do(httpsimp.Apply(r, httpsimp.WithContext(ctx)), c.HTTPClient, httpsimp.None())",
+		"\t\theader.Set(\"X-Request-Id\", *req.XRequestID)\n",
+		"func (c *Client) CreatePet(ctx context.Context, req *CreatePetRequest) (*Pet, error) {",
+		`r := httpsimp.MakeJSON("POST", c.BaseURL, path, nil, req.Body, nil)`,
+		`path := "/pets/" + url.PathEscape(fmt.Sprint(req.PetID))`,
+		"func (c *Client) ShowPetByID(ctx context.Context, req *ShowPetByIDRequest) (*Pet, error) {",
+		"// Deprecated: the operation is deprecated by the API.\nfunc (c *Client) DeletePetsPetID(ctx context.Context, req *DeletePetsPetIDRequest) error {",
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("generated code does not contain %q", expected)
+		}
+	}
+	if t.Failed() {
+		t.Log(s)
+	}
+}
+
+func TestGenerateTypeChecks(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/petstore.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := generate(data, "petstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "petstore.go", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("petstore", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("generated code does not type-check: %v\n%s", err, code)
+	}
+}
+
+func TestGenerateInvalidSpec(t *testing.T) {
+	if _, err := generate([]byte("openapi: 3.0.0"), "api"); err == nil {
+		t.Errorf("expected an error for a YAML spec")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"petId":          "PetID",
+		"pet_id":         "PetID",
+		"X-Request-Id":   "XRequestID",
+		"HTTPServer":     "HTTPServer",
+		"get /pets/{id}": "GetPetsID",
+		"2fa":            "X2fa",
+		"listAPIKeys":    "ListAPIKeys",
+	}
+	for input, expected := range tests {
+		if actual := goName(input); actual != expected {
+			t.Errorf("goName(%q) = %q, wanted %q", input, actual, expected)
+		}
+	}
+}
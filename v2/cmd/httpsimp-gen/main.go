@@ -0,0 +1,63 @@
+/*
+Command httpsimp-gen generates a typed API client on top of httpsimp
+from an OpenAPI 3 specification (in JSON format; convert YAML specs first,
+e.g. with yq -o json).
+
+Usage:
+
+    httpsimp-gen -spec openapi.json -pkg petstore -o petstore/client.go
+
+The generated file contains:
+
+- a Go struct for every object schema in components/schemas;
+
+- a Client type with BaseURL and HTTPClient fields;
+
+- for every operation, a request struct holding its path, query and header
+parameters and the request body, and a Client method that builds the request
+with httpsimp, sends it via httpsimp.Do and decodes the JSON response of
+the first 2xx status code listed in the spec.
+
+Operations are named after their operationId (or method and path if missing).
+Error responses are reported via the usual httpsimp errors, so use
+httpsimp.StatusCode and errors.As on the returned error.
+*/
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+func main() {
+	log.SetFlags(0)
+	specPath := flag.String("spec", "", "path to OpenAPI 3 spec in JSON format (required)")
+	pkg := flag.String("pkg", "api", "package name of the generated code")
+	out := flag.String("o", "", "output file (default stdout)")
+	flag.Parse()
+
+	if *specPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := ioutil.ReadFile(*specPath)
+	if err != nil {
+		log.Fatalf("httpsimp-gen: %v", err)
+	}
+	code, err := generate(data, *pkg)
+	if err != nil {
+		log.Fatalf("httpsimp-gen: %s: %v", *specPath, err)
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(code)
+	} else {
+		err = ioutil.WriteFile(*out, code, 0644)
+	}
+	if err != nil {
+		log.Fatalf("httpsimp-gen: %v", err)
+	}
+}
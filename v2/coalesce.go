@@ -0,0 +1,102 @@
+package httpsimp
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+/*
+Coalesce returns an HTTPClient that deduplicates concurrent identical GET
+requests: while a request is in flight, other GET requests with the same URL,
+Host and headers wait for it and receive a copy of its response instead of
+hitting the server again. Each caller gets its own response body,
+so they can all pass the response to Parse independently.
+
+This is handy for configuration or metadata endpoints hammered by many
+goroutines at once. The response body is buffered in memory, so don't
+coalesce requests returning huge bodies.
+
+Requests with other methods or with a body are passed through as is.
+Note that if the first request fails (e.g. because its context has been
+canceled), all coalesced requests fail with the same error.
+*/
+func Coalesce(client HTTPClient) HTTPClient {
+	return &coalescingClient{
+		client: client,
+		calls:  make(map[string]*coalescedCall),
+	}
+}
+
+type coalescingClient struct {
+	client HTTPClient
+	mu     sync.Mutex
+	calls  map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done chan struct{}
+	resp *http.Response
+	body []byte
+	err  error
+}
+
+func (c *coalescingClient) Do(r *http.Request) (*http.Response, error) {
+	if (r.Method != "" && r.Method != http.MethodGet) || (r.Body != nil && r.Body != http.NoBody) {
+		return c.client.Do(r)
+	}
+	key := coalescingKey(r)
+
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.response(r)
+		case <-r.Context().Done():
+			return nil, r.Context().Err()
+		}
+	}
+	call := &coalescedCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	resp, err := c.client.Do(r)
+	if err == nil {
+		call.resp = resp
+		call.body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	call.err = err
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.response(r)
+}
+
+func (call *coalescedCall) response(r *http.Request) (*http.Response, error) {
+	if call.err != nil {
+		return nil, call.err
+	}
+	resp := new(http.Response)
+	*resp = *call.resp
+	resp.Header = call.resp.Header.Clone()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(call.body))
+	resp.Request = r
+	return resp, nil
+}
+
+func coalescingKey(r *http.Request) string {
+	var buf strings.Builder
+	buf.WriteString(r.Host)
+	buf.WriteString("\n")
+	buf.WriteString(r.URL.String())
+	buf.WriteString("\n")
+	r.Header.Write(&buf)
+	return buf.String()
+}
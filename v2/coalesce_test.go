@@ -0,0 +1,95 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCoalesce(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Write([]byte(`{"foo": 42}`))
+	}))
+	defer srv.Close()
+
+	upstream := &countingClient{client: http.DefaultClient}
+	client := Coalesce(upstream)
+
+	const n = 10
+	var wg, joined sync.WaitGroup
+	results := make([]int, n)
+	errs := make([]error, n)
+	get := func(i int, ctx context.Context) {
+		defer wg.Done()
+		var resp struct {
+			Foo int `json:"foo"`
+		}
+		errs[i] = Do(MakeGet(srv.URL, "", nil, nil).WithContext(ctx), client, JSON(&resp))
+		results[i] = resp.Foo
+	}
+
+	wg.Add(1)
+	go get(0, context.Background())
+	<-started
+
+	// the other requests wait on their contexts once they've joined the first one
+	for i := 1; i < n; i++ {
+		wg.Add(1)
+		joined.Add(1)
+		go get(i, &joinContext{Context: context.Background(), joined: joined.Done})
+	}
+	joined.Wait()
+	close(release)
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if results[i] != 42 {
+			t.Fatalf("invalid value of Foo in result %d: %v", i, results[i])
+		}
+	}
+	if calls := atomic.LoadInt32(&upstream.calls); calls != 1 {
+		t.Fatalf("requests were not coalesced: %d upstream calls", calls)
+	}
+}
+
+type countingClient struct {
+	client HTTPClient
+	calls  int32
+}
+
+func (c *countingClient) Do(r *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return c.client.Do(r)
+}
+
+// joinContext calls joined the first time Done is called.
+type joinContext struct {
+	context.Context
+	once   sync.Once
+	joined func()
+}
+
+func (ctx *joinContext) Done() <-chan struct{} {
+	ctx.once.Do(ctx.joined)
+	return ctx.Context.Done()
+}
+
+func TestCoalescingKeyHost(t *testing.T) {
+	a := MakeGet("http://127.0.0.1/", "/", nil, nil)
+	b := MakeGet("http://127.0.0.1/", "/", nil, nil)
+	b.Host = "example.com"
+	if coalescingKey(a) == coalescingKey(b) {
+		t.Errorf("requests with different Host coalesced")
+	}
+}
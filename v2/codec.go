@@ -0,0 +1,122 @@
+package httpsimp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+)
+
+/*
+Codec encodes and decodes request and response bodies in some format.
+It is used to plug in third-party implementations of formats like MessagePack
+and CBOR without making this package depend on them.
+
+Most such libraries have suitable Marshal and Unmarshal functions that can be
+adapted via CodecFuncs:
+
+    httpsimp.MsgpackCodec = httpsimp.CodecFuncs{msgpack.Marshal, msgpack.Unmarshal}
+*/
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+/*
+JSONCodec is the Codec used by EncodeJSONBody (and thus MakeJSON etc) and
+the JSON parser. It defaults to encoding/json; replace it during initialization
+to use a faster implementation, e.g. jsoniter:
+
+    json := jsoniter.ConfigCompatibleWithStandardLibrary
+    httpsimp.JSONCodec = httpsimp.CodecFuncs{json.Marshal, json.Unmarshal}
+*/
+var JSONCodec Codec = stdJSONCodec{}
+
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+/*
+CodecFuncs adapts a pair of functions into a Codec.
+*/
+type CodecFuncs struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(data []byte, v interface{}) error
+}
+
+// Marshal implements Codec.
+func (c CodecFuncs) Marshal(v interface{}) ([]byte, error) {
+	return c.MarshalFunc(v)
+}
+
+// Unmarshal implements Codec.
+func (c CodecFuncs) Unmarshal(data []byte, v interface{}) error {
+	return c.UnmarshalFunc(data, v)
+}
+
+/*
+EncodeBody encodes the given object using the given codec and sets the body
+and Content-Type (to ctype) on the given request.
+
+If encoding fails, Do returns a *BuildError without sending the request.
+
+To properly handle HTTP redirects, both Body and GetBody are set.
+*/
+func EncodeBody(r *http.Request, codec Codec, ctype string, obj interface{}) *http.Request {
+	if codec == nil {
+		return setBuildError(r, fmt.Errorf("cannot encode %s body: no codec configured", ctype))
+	}
+	body, err := codec.Marshal(obj)
+	if err != nil {
+		return setBuildError(r, fmt.Errorf("cannot encode %s body: %w", ctype, err))
+	}
+	_ = SetBody(r, body)
+
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	if r.Header["Content-Type"] == nil {
+		r.Header["Content-Type"] = []string{ctype}
+	}
+	return r
+}
+
+/*
+Decode is a Parser function that verifies the response status code and content
+type (which must be ctype) and unmarshals the body into the result variable
+using the given codec.
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func Decode(result interface{}, codec Codec, ctype string, mopt ...ParseOption) Parser {
+	return MakeParser(ctype, mopt, codecBodyParser(result, func() Codec { return codec }))
+}
+
+func codecBodyParser(result interface{}, codec func() Codec) func(resp *http.Response) (interface{}, error) {
+	if result == nil {
+		var body interface{}
+		result = &body
+	}
+	return func(resp *http.Response) (interface{}, error) {
+		defer resp.Body.Close()
+		c := codec()
+		if c == nil {
+			return nil, errors.New("no codec configured")
+		}
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading body: %w", err)
+		}
+		err = c.Unmarshal(b, result)
+		body := reflect.ValueOf(result).Elem().Interface()
+		return body, err
+	}
+}
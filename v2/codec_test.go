@@ -0,0 +1,46 @@
+package httpsimp
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEncodeBodyError(t *testing.T) {
+	failing := CodecFuncs{
+		MarshalFunc: func(v interface{}) ([]byte, error) { return nil, errors.New("boom") },
+	}
+	r := EncodeBody(&http.Request{}, failing, "application/test", 1)
+	if err := requestBuildError(r); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected a build error, got %v", err)
+	}
+}
+
+func TestJSONCodec(t *testing.T) {
+	old := JSONCodec
+	defer func() { JSONCodec = old }()
+	var marshaled, unmarshaled int
+	JSONCodec = CodecFuncs{
+		MarshalFunc: func(v interface{}) ([]byte, error) {
+			marshaled++
+			return old.Marshal(v)
+		},
+		UnmarshalFunc: func(data []byte, v interface{}) error {
+			unmarshaled++
+			return old.Unmarshal(data, v)
+		},
+	}
+
+	r := EncodeJSONBody(&http.Request{}, map[string]int{"a": 1})
+	body, _ := ioutil.ReadAll(r.Body)
+	var v map[string]int
+	err := get(http.StatusOK, ContentTypeJSON, body, JSON(&v))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v["a"] != 1 || marshaled != 1 || unmarshaled != 1 {
+		t.Errorf("got %v, marshaled %d, unmarshaled %d", v, marshaled, unmarshaled)
+	}
+}
@@ -0,0 +1,112 @@
+package httpsimp
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+/*
+FirstOf returns a parser that handles the response using the first of
+the given parsers that matches it, just like Do and Parse do with their
+arguments. It is useful to pass a group of parsers around as a single one,
+e.g. in Policy.Parsers or Also.
+
+Pseudo-parsers like Expect and Meta are ignored inside FirstOf.
+*/
+func FirstOf(parsers ...Parser) Parser {
+	return Parser{
+		children: parsers,
+		combine: func(resp *http.Response, mt *mediaType, wantErr bool) (bool, error) {
+			var errs []error
+			for _, p := range parsers {
+				if p.isPseudo() {
+					continue
+				}
+				matched, err := parse(resp, mt, p, wantErr)
+				if matched {
+					return true, err
+				}
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return false, joinErrors(errs)
+		},
+	}
+}
+
+/*
+Also returns a parser that runs every one of the given parsers that matches
+the response, each on its own copy of the body, which is buffered in memory.
+For example, to decode JSON and keep the raw bytes for auditing:
+
+    var raw []byte
+    err := httpsimp.Do(r, client, httpsimp.Also(httpsimp.JSON(&resp), httpsimp.Bytes(&raw)))
+
+The combined parser matches if any of the given parsers matches, and returns
+the first error encountered (ReturnError on any of them causes an error too).
+
+Pseudo-parsers like Expect and Meta are ignored inside Also.
+*/
+func Also(parsers ...Parser) Parser {
+	return Parser{
+		children: parsers,
+		combine: func(resp *http.Response, mt *mediaType, wantErr bool) (bool, error) {
+			body, err := bufferBody(resp)
+			if err != nil {
+				return true, &ResponseError{
+					StatusCode:    resp.StatusCode,
+					ContentType:   resp.Header.Get("Content-Type"),
+					ContentTypeOK: true,
+					DecodingError: err,
+				}
+			}
+
+			anyMatched := false
+			var firstErr error
+			var mismatchErrs []error
+			for _, p := range parsers {
+				if p.isPseudo() {
+					continue
+				}
+				resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+				matched, err := parse(resp, mt, p, wantErr)
+				if matched {
+					anyMatched = true
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else if err != nil {
+					mismatchErrs = append(mismatchErrs, err)
+				}
+			}
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			if !anyMatched {
+				return false, joinErrors(mismatchErrs)
+			}
+			return true, firstErr
+		},
+	}
+}
+
+func flattenParsers(parsers []Parser) []Parser {
+	nested := false
+	for _, p := range parsers {
+		nested = nested || p.children != nil
+	}
+	if !nested {
+		return parsers
+	}
+
+	var result []Parser
+	for _, p := range parsers {
+		if p.children != nil {
+			result = append(result, flattenParsers(p.children)...)
+		} else {
+			result = append(result, p)
+		}
+	}
+	return result
+}
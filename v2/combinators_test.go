@@ -0,0 +1,57 @@
+package httpsimp
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAlso(t *testing.T) {
+	var resp struct {
+		Foo int `json:"foo"`
+	}
+	var raw []byte
+	var text string
+	body := []byte(`{"foo": 42}`)
+	err := get(http.StatusOK, ContentTypeJSON, body, Also(JSON(&resp), Bytes(&raw), PlainText(&text, ContentType(ContentTypeTextPlain))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Foo != 42 || string(raw) != string(body) || text != "" {
+		t.Fatalf("resp = %v, raw = %q, text = %q", resp, raw, text)
+	}
+
+	err = get(http.StatusOK, ContentTypeTextPlain, body, Also(JSON(&resp), JSON(nil, Status4xx)), Bytes(&raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = get(http.StatusOK, ContentTypeJSON, []byte(`{"foo": "bar"}`), Also(Bytes(&raw), JSON(&resp)))
+	if e := getResponseError(err); e == nil || e.DecodingError == nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFirstOf(t *testing.T) {
+	var m map[string]interface{}
+	var text string
+	group := FirstOf(JSON(&m), PlainText(&text, ContentType(ContentTypeTextPlain)))
+
+	err := get(http.StatusOK, ContentTypeTextPlain, []byte("hello"), group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "hello" {
+		t.Fatalf("invalid value of text: %q", text)
+	}
+
+	err = get(http.StatusOK, "text/html", []byte("hello"), group)
+	if StatusCode(err) != http.StatusOK {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := new(acceptRecorder)
+	Do(MakeGet("http://example.com", "", nil, nil), client, group)
+	if client.accept != "application/json, text/plain" {
+		t.Fatalf("Accept = %q", client.accept)
+	}
+}
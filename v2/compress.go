@@ -0,0 +1,176 @@
+package httpsimp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+/*
+GzipBody arranges for the request body to be compressed with gzip right
+before the request is sent, setting Content-Encoding: gzip. It is a shortcut
+for TransformRequestBody(r, GzipRequestBody), so it can be called before or
+after the body has been set:
+
+    r := httpsimp.GzipBody(httpsimp.MakeJSON(http.MethodPost, baseURL, path, nil, events, nil))
+
+The compressed body is buffered in memory, so redirects and retries re-send it.
+*/
+func GzipBody(r *http.Request) *http.Request {
+	return TransformRequestBody(r, GzipRequestBody)
+}
+
+/*
+GzipRequestBody is a RequestBodyTransform that compresses the body with gzip
+and sets Content-Encoding: gzip. Empty bodies and bodies that already have
+a Content-Encoding are left alone.
+*/
+func GzipRequestBody(body []byte, header http.Header) ([]byte, error) {
+	if len(body) == 0 || header.Get("Content-Encoding") != "" {
+		return body, nil
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	header.Set("Content-Encoding", "gzip")
+	return buf.Bytes(), nil
+}
+
+/*
+ContentDecoder wraps a reader of an encoded (compressed) response body into
+a reader of the decoded body.
+*/
+type ContentDecoder func(r io.Reader) (io.ReadCloser, error)
+
+var contentDecoders = struct {
+	sync.RWMutex
+	m map[string]ContentDecoder
+}{m: map[string]ContentDecoder{
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"deflate": func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	},
+}}
+
+/*
+RegisterContentDecoder makes Parse (and thus Do) transparently decode response
+bodies with the given Content-Encoding before parsers run. gzip and deflate
+are supported out of the box; to keep this package free of dependencies,
+decoders for other encodings must be registered during initialization,
+e.g. using github.com/andybalholm/brotli and github.com/klauspost/compress:
+
+    httpsimp.RegisterContentDecoder("br", func(r io.Reader) (io.ReadCloser, error) {
+        return ioutil.NopCloser(brotli.NewReader(r)), nil
+    })
+    httpsimp.RegisterContentDecoder("zstd", func(r io.Reader) (io.ReadCloser, error) {
+        d, err := zstd.NewReader(r)
+        if err != nil {
+            return nil, err
+        }
+        return d.IOReadCloser(), nil
+    })
+
+Responses with an unregistered encoding are passed to parsers as is.
+Use AcceptEncoding option to advertise the encodings to servers.
+*/
+func RegisterContentDecoder(encoding string, d ContentDecoder) {
+	contentDecoders.Lock()
+	defer contentDecoders.Unlock()
+	contentDecoders.m[strings.ToLower(encoding)] = d
+}
+
+/*
+AcceptEncoding makes the client send an Accept-Encoding header listing
+the given encodings (in order of preference) with requests that don't have
+one. Register decoders for the encodings other than gzip and deflate
+via RegisterContentDecoder.
+
+Note that setting Accept-Encoding disables the transparent gzip decompression
+of http.Transport, so gzip should normally be included in the list;
+the responses are then decoded by Parse instead.
+*/
+func AcceptEncoding(encodings ...string) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.acceptEncoding = strings.Join(encodings, ", ")
+	})
+}
+
+// decodeContentEncoding replaces the body of a response that has
+// a Content-Encoding with a decoded one, if all encodings are registered.
+func decodeContentEncoding(resp *http.Response) {
+	header := resp.Header.Get("Content-Encoding")
+	if header == "" || resp.Body == nil {
+		return
+	}
+
+	var decoders []ContentDecoder
+	contentDecoders.RLock()
+	for _, enc := range strings.Split(header, ",") {
+		enc = strings.ToLower(strings.TrimSpace(enc))
+		if enc == "" || enc == "identity" {
+			continue
+		}
+		d := contentDecoders.m[enc]
+		if d == nil {
+			contentDecoders.RUnlock()
+			return
+		}
+		decoders = append(decoders, d)
+	}
+	contentDecoders.RUnlock()
+
+	resp.Body = &decodedBody{src: resp.Body, decoders: decoders}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// decodedBody creates decoders lazily, so that empty bodies (e.g. of HEAD
+// responses) don't fail.
+type decodedBody struct {
+	src      io.ReadCloser
+	decoders []ContentDecoder
+	r        io.Reader
+	closers  []io.Closer
+	err      error
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.r == nil && b.err == nil {
+		var r io.Reader = b.src
+		// encodings are listed in the order they were applied
+		for i := len(b.decoders) - 1; i >= 0; i-- {
+			rc, err := b.decoders[i](r)
+			if err != nil {
+				b.err = err
+				break
+			}
+			b.closers = append(b.closers, rc)
+			r = rc
+		}
+		b.r = r
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.r.Read(p)
+}
+
+func (b *decodedBody) Close() error {
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		b.closers[i].Close()
+	}
+	return b.src.Close()
+}
@@ -0,0 +1,100 @@
+package httpsimp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGzipBody(t *testing.T) {
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusTemporaryRedirect)
+			return
+		}
+		if ce := r.Header.Get("Content-Encoding"); ce != "gzip" {
+			t.Errorf("Content-Encoding = %q", ce)
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(zr)
+		received = append(received, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	r := GzipBody(MakeJSON(http.MethodPost, srv.URL, "/old", nil, map[string]int{"a": 1}, nil))
+	err := Do(r, http.DefaultClient, None())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || received[0] != `{"a":1}` {
+		t.Errorf("received %q", received)
+	}
+}
+
+func TestGzipRequestBodySkipsEncoded(t *testing.T) {
+	header := http.Header{"Content-Encoding": {"br"}}
+	body, err := GzipRequestBody([]byte("abc"), header)
+	if err != nil || string(body) != "abc" || header.Get("Content-Encoding") != "br" {
+		t.Errorf("got %q, %v, %v", body, err, header)
+	}
+}
+
+func TestContentDecoding(t *testing.T) {
+	// a toy "reverse" encoding standing in for brotli or zstd
+	RegisterContentDecoder("x-reverse", func(r io.Reader) (io.ReadCloser, error) {
+		b, err := ioutil.ReadAll(r)
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+		return ioutil.NopCloser(bytes.NewReader(b)), err
+	})
+
+	var acceptEncoding string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Header().Set("Content-Encoding", "gzip, x-reverse")
+		if r.Method == http.MethodHead {
+			return
+		}
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(`{"ok":true}`))
+		zw.Close()
+		b := buf.Bytes()
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	client := NewClient(AcceptEncoding("x-reverse", "gzip"))
+	var resp struct {
+		OK bool `json:"ok"`
+	}
+	err := Do(MakeGet(srv.URL, "", nil, nil), client, JSON(&resp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.OK {
+		t.Errorf("body not decoded")
+	}
+	if acceptEncoding != "x-reverse, gzip" {
+		t.Errorf("Accept-Encoding = %q", acceptEncoding)
+	}
+
+	err = Do(MakeHead(srv.URL, "", nil, nil), client, None())
+	if err != nil {
+		t.Errorf("HEAD: %v", err)
+	}
+}
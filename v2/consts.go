@@ -9,4 +9,35 @@ const (
 
 	// ContentTypeFormURLEncoded is "application/x-www-form-urlencoded"
 	ContentTypeFormURLEncoded = "application/x-www-form-urlencoded"
+
+	// ContentTypeHTML is "text/html"
+	ContentTypeHTML = "text/html"
+
+	// ContentTypeCSV is "text/csv"
+	ContentTypeCSV = "text/csv"
+
+	// ContentTypeAnyJSON is a pattern matching application/json and any
+	// application/...+json type; pass it to ContentType option.
+	ContentTypeAnyJSON = "application/*+json"
+
+	// ContentTypeJSONPatch is "application/json-patch+json" (RFC 6902)
+	ContentTypeJSONPatch = "application/json-patch+json"
+
+	// ContentTypeMergePatch is "application/merge-patch+json" (RFC 7396)
+	ContentTypeMergePatch = "application/merge-patch+json"
+
+	// ContentTypeProtobuf is "application/x-protobuf"
+	ContentTypeProtobuf = "application/x-protobuf"
+
+	// ContentTypeMsgpack is "application/msgpack"
+	ContentTypeMsgpack = "application/msgpack"
+
+	// ContentTypeCBOR is "application/cbor" (RFC 8949)
+	ContentTypeCBOR = "application/cbor"
+
+	// ContentTypeJSONAPI is "application/vnd.api+json" (see https://jsonapi.org)
+	ContentTypeJSONAPI = "application/vnd.api+json"
+
+	// ContentTypeMultipartMixed is "multipart/mixed" (RFC 2046)
+	ContentTypeMultipartMixed = "multipart/mixed"
 )
@@ -0,0 +1,190 @@
+package httpsimp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Jar makes the client store cookies received in responses and send them
+with subsequent requests, like a browser does. Pass a *cookiejar.Jar,
+or a PersistentJar to keep cookies between runs.
+*/
+func Jar(jar http.CookieJar) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.jar = jar
+	})
+}
+
+/*
+PersistentJar is an http.CookieJar (based on net/http/cookiejar) that can
+save its cookies to a JSON file and load them back on the next run, which is
+handy for automating sites that use session cookies:
+
+    jar, err := httpsimp.NewPersistentJar("cookies.json")
+    if err != nil { ... }
+    client := httpsimp.NewClient(httpsimp.Jar(jar))
+    ...
+    err = jar.Save()
+
+Session cookies (those without an expiration time) are saved too. The file
+contains credentials, so it is written with 0600 permissions.
+*/
+type PersistentJar struct {
+	path string
+
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	entries []jarEntry
+}
+
+type jarEntry struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+/*
+NewPersistentJar returns a PersistentJar saving to the given file,
+loading the cookies it already contains (a missing file is fine).
+An empty path makes an in-memory jar, for which Save does nothing.
+*/
+func NewPersistentJar(path string) (*PersistentJar, error) {
+	jar, _ := cookiejar.New(nil)
+	j := &PersistentJar{path: path, jar: jar}
+	if path == "" {
+		return j, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var entries []jarEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, &os.PathError{Op: "load cookies", Path: path, Err: err}
+	}
+	for _, e := range entries {
+		if u, err := url.Parse(e.URL); err == nil && e.Cookie != nil {
+			j.SetCookies(u, []*http.Cookie{e.Cookie})
+		}
+	}
+	return j, nil
+}
+
+// SetCookies implements http.CookieJar.
+func (j *PersistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	origin := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+	for _, c := range cookies {
+		c := *c
+		if c.MaxAge > 0 {
+			// replaying MaxAge later would extend the lifetime
+			c.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+			c.MaxAge = 0
+		}
+		c.Raw, c.Unparsed = "", nil
+		j.entries = j.removeEntry(u, &c)
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && !c.Expires.After(now)) {
+			continue // deletion
+		}
+		j.entries = append(j.entries, jarEntry{origin, &c})
+	}
+}
+
+// removeEntry drops the entry the given cookie replaces, if any.
+func (j *PersistentJar) removeEntry(u *url.URL, c *http.Cookie) []jarEntry {
+	key := jarKey(u, c)
+	result := j.entries[:0]
+	for _, e := range j.entries {
+		eu, _ := url.Parse(e.URL)
+		if eu == nil || jarKey(eu, e.Cookie) != key {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func jarKey(u *url.URL, c *http.Cookie) string {
+	domain := strings.TrimPrefix(strings.ToLower(c.Domain), ".")
+	if domain == "" {
+		domain = u.Hostname()
+	}
+	path := c.Path
+	if path == "" || path[0] != '/' {
+		path = defaultCookiePath(u.Path)
+	}
+	return domain + ";" + path + ";" + c.Name
+}
+
+// defaultCookiePath implements the default-path algorithm of RFC 6265.
+func defaultCookiePath(p string) string {
+	i := strings.LastIndexByte(p, '/')
+	if p == "" || p[0] != '/' || i == 0 {
+		return "/"
+	}
+	return p[:i]
+}
+
+// Cookies implements http.CookieJar.
+func (j *PersistentJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+/*
+Save writes the unexpired cookies to the file given to NewPersistentJar,
+atomically replacing it.
+*/
+func (j *PersistentJar) Save() error {
+	if j.path == "" {
+		return nil
+	}
+
+	j.mu.Lock()
+	now := time.Now()
+	entries := make([]jarEntry, 0, len(j.entries))
+	for _, e := range j.entries {
+		if e.Cookie.Expires.IsZero() || e.Cookie.Expires.After(now) {
+			entries = append(entries, e)
+		}
+	}
+	j.mu.Unlock()
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(j.path), filepath.Base(j.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(0600)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), j.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPersistentJar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			http.SetCookie(w, &http.Cookie{Name: "remember", Value: "1", Path: "/", MaxAge: 3600})
+			http.SetCookie(w, &http.Cookie{Name: "temp", Value: "x", Path: "/"})
+		case "/logout-temp":
+			http.SetCookie(w, &http.Cookie{Name: "temp", Path: "/", MaxAge: -1})
+		}
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte(r.Header.Get("Cookie")))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	jar, err := NewPersistentJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(Jar(jar))
+	for _, p := range []string{"/login", "/logout-temp"} {
+		if err := Do(MakeGet(srv.URL, p, nil, nil), client, None()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := jar.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("unexpected file mode: %v, %v", fi, err)
+	}
+
+	jar2, err := NewPersistentJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text string
+	if err := Do(MakeGet(srv.URL, "/", nil, nil), NewClient(Jar(jar2)), PlainText(&text)); err != nil {
+		t.Fatal(err)
+	}
+	if text != "session=abc; remember=1" && text != "remember=1; session=abc" {
+		t.Errorf("cookies after reload = %q", text)
+	}
+}
+
+func TestPersistentJarMissingFile(t *testing.T) {
+	jar, err := NewPersistentJar(filepath.Join(t.TempDir(), "none.json"))
+	if err != nil || jar == nil {
+		t.Fatalf("NewPersistentJar = %v, %v", jar, err)
+	}
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	os.WriteFile(bad, []byte("nope"), 0600)
+	if _, err := NewPersistentJar(bad); err == nil {
+		t.Error("expected an error for a malformed file")
+	}
+}
@@ -0,0 +1,48 @@
+package httpsimp
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	// CookieHeader is the "Cookie" HTTP header
+	CookieHeader = "Cookie"
+)
+
+/*
+CookieValue returns a Cookie header value sending the given cookies,
+e.g. "session=abc; theme=dark". Only the names and values of the cookies
+are used; values are sanitized and quoted as necessary, and cookies with
+invalid names are skipped.
+
+Use CookieHeader constant for the header name.
+*/
+func CookieValue(cookies ...*http.Cookie) string {
+	parts := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		if s := (&http.Cookie{Name: c.Name, Value: c.Value}).String(); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+/*
+CookieMapValue is like CookieValue, but accepts a map of cookie names
+to values. The cookies are sorted by name.
+*/
+func CookieMapValue(cookies map[string]string) string {
+	names := make([]string, 0, len(cookies))
+	for name := range cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]*http.Cookie, 0, len(names))
+	for _, name := range names {
+		list = append(list, &http.Cookie{Name: name, Value: cookies[name]})
+	}
+	return CookieValue(list...)
+}
@@ -0,0 +1,18 @@
+package httpsimp
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCookieValue(t *testing.T) {
+	actual := CookieValue(&http.Cookie{Name: "session", Value: "abc", Path: "/"}, &http.Cookie{Name: "bad name", Value: "x"}, &http.Cookie{Name: "msg", Value: "hello world"})
+	if expected := `session=abc; msg="hello world"`; actual != expected {
+		t.Fatalf("CookieValue = %q, wanted %q", actual, expected)
+	}
+
+	actual = CookieMapValue(map[string]string{"theme": "dark", "session": "abc"})
+	if expected := `session=abc; theme=dark`; actual != expected {
+		t.Fatalf("CookieMapValue = %q, wanted %q", actual, expected)
+	}
+}
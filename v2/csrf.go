@@ -0,0 +1,142 @@
+package httpsimp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// DefaultCSRFHeader is the header CSRF tokens are sent in by default.
+const DefaultCSRFHeader = "X-Csrf-Token"
+
+// maxCSRFPageBytes limits how much of the page is read by FetchCSRF.
+const maxCSRFPageBytes = 4 << 20
+
+/*
+CSRFExtractor obtains a CSRF token from the response to the request made
+by Client.FetchCSRF. body is the full response body.
+*/
+type CSRFExtractor func(resp *http.Response, body []byte) (string, error)
+
+/*
+CSRFFlow describes how Client.FetchCSRF obtains a CSRF token.
+*/
+type CSRFFlow struct {
+	// Request builds the request fetching the token, typically a GET of
+	// a form page or a dedicated endpoint.
+	Request func() *http.Request
+
+	// Extract finds the token in the response: use CSRFFromHeader,
+	// CSRFFromCookie, CSRFFromJSON or a custom function (e.g. parsing
+	// a hidden form field out of HTML).
+	Extract CSRFExtractor
+
+	// Header is the request header to send the token in
+	// (DefaultCSRFHeader if empty).
+	Header string
+}
+
+/*
+FetchCSRF obtains a CSRF token as described by the given flow and
+configures the client to send it with all subsequent mutating requests
+(that is, except GET, HEAD, OPTIONS and TRACE), see CSRFToken:
+
+    err := client.FetchCSRF(httpsimp.CSRFFlow{
+        Request: func() *http.Request { return httpsimp.MakeGet(baseURL, "/admin", nil, nil) },
+        Extract: httpsimp.CSRFFromCookie("csrftoken"),
+        Header:  "X-CSRFToken",
+    })
+
+Frameworks usually tie the token to a session cookie, so the client needs
+a cookie jar (see Jar and Client.Login). Call FetchCSRF again when the server
+rejects the token.
+*/
+func (c *Client) FetchCSRF(flow CSRFFlow) error {
+	var resp *http.Response
+	if err := Do(flow.Request(), c, Raw(&resp)); err != nil {
+		return err
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCSRFPageBytes))
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("CSRF: error reading body: %w", err)
+	}
+
+	token, err := flow.Extract(resp, body)
+	if err != nil {
+		return fmt.Errorf("CSRF: %w", err)
+	}
+	if token == "" {
+		return fmt.Errorf("CSRF: no token in the response")
+	}
+	c.Update(CSRFToken(flow.Header, token))
+	return nil
+}
+
+/*
+CSRFToken makes the client send the given CSRF token in the given header
+(DefaultCSRFHeader if empty) with mutating requests that don't have it.
+An empty token disables the header.
+*/
+func CSRFToken(header, token string) ClientOption {
+	if header == "" {
+		header = DefaultCSRFHeader
+	}
+	return clientOptionFunc(func(c *clientConfig) {
+		c.csrfHeader, c.csrfToken = header, token
+	})
+}
+
+func (c *clientConfig) needsCSRF(r *http.Request) bool {
+	if c.csrfToken == "" || r.Header.Get(c.csrfHeader) != "" {
+		return false
+	}
+	switch r.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	return true
+}
+
+// CSRFFromHeader extracts a CSRF token from the given response header.
+func CSRFFromHeader(name string) CSRFExtractor {
+	return func(resp *http.Response, body []byte) (string, error) {
+		return resp.Header.Get(name), nil
+	}
+}
+
+// CSRFFromCookie extracts a CSRF token from the given cookie set by the response.
+func CSRFFromCookie(name string) CSRFExtractor {
+	return func(resp *http.Response, body []byte) (string, error) {
+		for _, c := range resp.Cookies() {
+			if c.Name == name {
+				return c.Value, nil
+			}
+		}
+		return "", fmt.Errorf("no cookie %q in the response", name)
+	}
+}
+
+/*
+CSRFFromJSON extracts a CSRF token from the string at the given dot-separated
+path (see JSONPath) of a JSON response.
+*/
+func CSRFFromJSON(path string) CSRFExtractor {
+	return func(resp *http.Response, body []byte) (string, error) {
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "", err
+		}
+		v, ok := lookupJSONPath(doc, path)
+		if !ok {
+			return "", fmt.Errorf("JSON field %q is missing", path)
+		}
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("JSON field %q is not a string", path)
+		}
+		return s, nil
+	}
+}
@@ -0,0 +1,67 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchCSRF(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/form":
+			http.SetCookie(w, &http.Cookie{Name: "csrftoken", Value: "c1"})
+			w.Header().Set("X-Page-Token", "h1")
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			w.Write([]byte(`{"meta":{"csrf":"j1"}}`))
+		default:
+			w.Header().Set("Content-Type", ContentTypeTextPlain)
+			w.Write([]byte(r.Header.Get(DefaultCSRFHeader) + "|" + r.Header.Get("X-CSRFToken")))
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		extract  CSRFExtractor
+		header   string
+		expected string
+	}{
+		{CSRFFromCookie("csrftoken"), "X-CSRFToken", "|c1"},
+		{CSRFFromHeader("X-Page-Token"), "", "h1|"},
+		{CSRFFromJSON("meta.csrf"), "", "j1|"},
+	}
+	for _, tt := range tests {
+		client := NewClient()
+		err := client.FetchCSRF(CSRFFlow{
+			Request: func() *http.Request { return MakeGet(srv.URL, "/form", nil, nil) },
+			Extract: tt.extract,
+			Header:  tt.header,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var text string
+		if err := Do(MakeForm(http.MethodPost, srv.URL, "/save", nil, nil), client, PlainText(&text)); err != nil {
+			t.Fatal(err)
+		}
+		if text != tt.expected {
+			t.Errorf("POST sent %q, wanted %q", text, tt.expected)
+		}
+		if err := Do(MakeGet(srv.URL, "/list", nil, nil), client, PlainText(&text)); err != nil {
+			t.Fatal(err)
+		}
+		if text != "|" {
+			t.Errorf("GET sent %q, wanted no token", text)
+		}
+	}
+
+	client := NewClient()
+	err := client.FetchCSRF(CSRFFlow{
+		Request: func() *http.Request { return MakeGet(srv.URL, "/form", nil, nil) },
+		Extract: CSRFFromCookie("missing"),
+	})
+	if err == nil {
+		t.Error("expected an error for a missing cookie")
+	}
+}
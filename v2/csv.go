@@ -0,0 +1,165 @@
+package httpsimp
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+CSV is a Parser function that verifies the response status code and content
+type (which must be ContentTypeCSV) and decodes the body into rows, which must
+be either a *[][]string (receiving all records, including the header) or
+a pointer to a slice of structs (or struct pointers).
+
+When decoding into structs, the first record is treated as a header and
+columns are mapped to fields by their csv tag (or, without a tag, by a
+case-insensitive match of the field name). Fields tagged csv:"-" and columns
+without a matching field are ignored. Supported field types are strings,
+bools, integers, floats and encoding.TextUnmarshaler implementations:
+
+    var rows []struct {
+        Date   string  `csv:"date"`
+        Clicks int     `csv:"clicks"`
+        Spend  float64 `csv:"spend_usd"`
+    }
+    err := httpsimp.Do(req, client, httpsimp.CSV(&rows))
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func CSV(rows interface{}, mopt ...ParseOption) Parser {
+	p := MakeParser(ContentTypeCSV, mopt, func(resp *http.Response) (interface{}, error) {
+		defer drainAndClose(resp.Body)
+		r := csv.NewReader(resp.Body)
+		r.FieldsPerRecord = -1
+
+		if records, ok := rows.(*[][]string); ok {
+			all, err := r.ReadAll()
+			*records = all
+			return all, err
+		}
+
+		v := reflect.ValueOf(rows)
+		if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+			return nil, fmt.Errorf("CSV: rows must be *[][]string or a pointer to a slice of structs, got %T", rows)
+		}
+		return decodeCSVStructs(r, v.Elem())
+	})
+	p.stripBOM = true
+	return p
+}
+
+func decodeCSVStructs(r *csv.Reader, slice reflect.Value) (interface{}, error) {
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("CSV: unsupported row type %v", elemType)
+	}
+
+	header, err := r.Read()
+	if err == io.EOF {
+		slice.Set(reflect.MakeSlice(slice.Type(), 0, 0))
+		return slice.Interface(), nil
+	} else if err != nil {
+		return nil, err
+	}
+	fields := csvFieldIndexes(structType, header)
+
+	result := reflect.MakeSlice(slice.Type(), 0, 0)
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		row := reflect.New(structType)
+		for col, value := range record {
+			if col >= len(fields) || fields[col] == nil {
+				continue
+			}
+			if err := setCSVField(row.Elem().FieldByIndex(fields[col]), value); err != nil {
+				return nil, fmt.Errorf("CSV line %d, column %q: %w", line, header[col], err)
+			}
+		}
+		if elemType.Kind() == reflect.Ptr {
+			result = reflect.Append(result, row)
+		} else {
+			result = reflect.Append(result, row.Elem())
+		}
+	}
+	slice.Set(result)
+	return result.Interface(), nil
+}
+
+func csvFieldIndexes(structType reflect.Type, header []string) [][]int {
+	fields := make([][]int, len(header))
+	for i := 0; i < structType.NumField(); i++ {
+		f := structType.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		name := f.Tag.Get("csv")
+		if name == "-" {
+			continue
+		}
+		for col, h := range header {
+			if fields[col] != nil {
+				continue
+			}
+			if (name != "" && h == name) || (name == "" && strings.EqualFold(h, f.Name)) {
+				fields[col] = f.Index
+			}
+		}
+	}
+	return fields
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func setCSVField(field reflect.Value, value string) error {
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+		return nil
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		field.SetBool(b)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		field.SetInt(n)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		field.SetUint(n)
+		return err
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		field.SetFloat(n)
+		return err
+	default:
+		return errors.New("unsupported field type " + field.Type().String())
+	}
+}
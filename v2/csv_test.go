@@ -0,0 +1,63 @@
+package httpsimp
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const csvReport = "date,Clicks,spend_usd,ignored\n2024-01-01,10,1.5,x\n2024-01-02,,2,y\n"
+
+func TestCSVRecords(t *testing.T) {
+	var rows [][]string
+	err := get(http.StatusOK, "text/csv; charset=utf-8", []byte(csvReport), CSV(&rows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[1][2] != "1.5" {
+		t.Errorf("got %q", rows)
+	}
+}
+
+func TestCSVStructs(t *testing.T) {
+	type row struct {
+		Date   string `csv:"date"`
+		Clicks int
+		Spend  float64 `csv:"spend_usd"`
+		Note   string  `csv:"-"`
+	}
+	var rows []*row
+	err := get(http.StatusOK, ContentTypeCSV, []byte(csvReport), CSV(&rows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*row{{"2024-01-01", 10, 1.5, ""}, {"2024-01-02", 0, 2, ""}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("got %+v %+v", rows[0], rows[1])
+	}
+}
+
+func TestCSVTextUnmarshaler(t *testing.T) {
+	var rows []struct {
+		At time.Time `csv:"at"`
+	}
+	err := get(http.StatusOK, ContentTypeCSV, []byte("at\n2024-01-01T10:00:00Z\n"), CSV(&rows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || !rows[0].At.Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %+v", rows)
+	}
+}
+
+func TestCSVInvalidValue(t *testing.T) {
+	var rows []struct {
+		Clicks int
+	}
+	err := get(http.StatusOK, ContentTypeCSV, []byte("clicks\nmany\n"), CSV(&rows))
+	if err == nil || !strings.Contains(err.Error(), `line 2, column "clicks"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
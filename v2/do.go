@@ -1,7 +1,9 @@
 package httpsimp
 
 import (
+	"errors"
 	"net/http"
+	"time"
 )
 
 /*
@@ -23,16 +25,96 @@ forever, causing goroutine leaks. A custom client is strongly recommended.
 
 For the parsers, use JSON, Bytes, PlainText, Raw or None from this package,
 or define your own custom one using MakeParser.
+
+Errors returned by Do mention the method and the URL of the request (with
+query parameter values redacted), the time spent, the request ID (see
+RequestID) and, if the request has been retried by the Client, the attempt
+number.
+
+If the request could not be built (see BuildError), Do returns
+a *BuildError without sending anything. Request body transforms added
+via TransformRequestBody are applied right before sending.
+
+If client is a *Client and the request matches one of its endpoints,
+the parsers of the endpoint's policy are tried after the given ones.
+
+Unless the request already has an Accept header, Do sets one listing
+the content types of the parsers (e.g. application/json for JSON), so that
+servers don't respond with HTML pages. If some of the parsers accept any
+content type, a wildcard is included with a lower preference.
+
+Use WithTimeout to limit the time spent on a single request.
 */
 func Do(r *http.Request, client HTTPClient, parsers ...Parser) error {
+	if err := requestBuildError(r); err != nil {
+		return err
+	}
+	r, err := applyRequestTransforms(r)
+	if err != nil {
+		return err
+	}
+
+	if pc, ok := client.(policyClient); ok {
+		if policy := pc.policyFor(r); policy != nil && len(policy.Parsers) > 0 {
+			parsers = append(parsers[:len(parsers):len(parsers)], policy.Parsers...)
+		}
+	}
+	if r.Header.Get("Accept") == "" {
+		if accept := acceptHeader(parsers); accept != "" {
+			// a shallow copy with its own header is enough here
+			rc := new(http.Request)
+			*rc = *r
+			rc.Header = make(http.Header, len(r.Header)+1)
+			for k, v := range r.Header {
+				rc.Header[k] = v
+			}
+			rc.Header["Accept"] = []string{accept}
+			r = rc
+		}
+	}
+
+	r, cancel := withRequestTimeout(r)
+
+	start := time.Now()
 	resp, err := client.Do(r)
 	if err != nil {
-		return &wrapperError{r.Method, r.URL.Path, err}
+		if cancel != nil {
+			cancel()
+		}
+		attempt, requestID := 1, r.Header.Get(RequestIDHeader)
+		var ie *requestIDError
+		if errors.As(err, &ie) {
+			requestID, err = ie.id, ie.err
+		}
+		var ae *attemptError
+		if errors.As(err, &ae) {
+			attempt, err = ae.attempt, ae.err
+		}
+		return newWrapperError(r, time.Since(start), attempt, requestID, err)
+	}
+	if cancel != nil {
+		// the body might outlive Do when handed out by Raw
+		resp.Body = &cancelOnClose{resp.Body, cancel}
+	}
+	var ci *callInfo
+	for _, p := range parsers {
+		if p.wantsCallInfo {
+			ci = &callInfo{start: start, latency: time.Since(start)}
+			attachCallInfo(resp, r, ci)
+			break
+		}
 	}
 
 	err = Parse(resp, parsers...)
+	if ci != nil {
+		ci.finish()
+	}
 	if err != nil {
-		return &wrapperError{r.Method, r.URL.Path, err}
+		sent := r
+		if resp.Request != nil {
+			sent = resp.Request
+		}
+		return newWrapperError(r, time.Since(start), responseAttempt(resp), sent.Header.Get(RequestIDHeader), err)
 	}
 
 	return nil
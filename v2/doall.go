@@ -0,0 +1,70 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+/*
+RequestWithParsers is a request to be executed by DoAll together with
+the parsers to handle its response.
+*/
+type RequestWithParsers struct {
+	Request *http.Request
+	Parsers []Parser
+}
+
+/*
+DoAll executes the given requests concurrently via Do, running at most limit
+of them at a time (all at once if limit <= 0), and returns their errors
+in the same order as reqs; nil elements correspond to successful requests:
+
+    var users [3]User
+    errs := httpsimp.DoAll(ctx, client, []httpsimp.RequestWithParsers{
+        {httpsimp.MakeGet(baseURL, "/users/1", nil, nil), []httpsimp.Parser{httpsimp.JSON(&users[0])}},
+        {httpsimp.MakeGet(baseURL, "/users/2", nil, nil), []httpsimp.Parser{httpsimp.JSON(&users[1])}},
+        {httpsimp.MakeGet(baseURL, "/users/3", nil, nil), []httpsimp.Parser{httpsimp.JSON(&users[2])}},
+    }, 2)
+    if err := errors.Join(errs...); err != nil {
+        return err
+    }
+
+Every request is executed with ctx, applied via WithContext, so options
+like GzipBody and WithTimeout keep working. Once ctx is done, in-flight
+requests are canceled, no more requests are started, and the remaining
+ones fail with ctx.Err().
+
+Each request must use its own parsers, since they run concurrently.
+*/
+func DoAll(ctx context.Context, client HTTPClient, reqs []RequestWithParsers, limit int) []error {
+	errs := make([]error, len(reqs))
+	if limit <= 0 || limit > len(reqs) {
+		limit = len(reqs)
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, rp := range reqs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+
+		r := WithContext(ctx)(rp.Request)
+		wg.Add(1)
+		go func(i int, r *http.Request, parsers []Parser) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = Do(r, client, parsers...)
+		}(i, r, rp.Parsers)
+	}
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,98 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDoAll(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		if r.URL.Path == "/3" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	results := make([]string, 6)
+	reqs := make([]RequestWithParsers, len(results))
+	for i := range reqs {
+		reqs[i] = RequestWithParsers{MakeGet(srv.URL, "/"+strconv.Itoa(i), nil, nil), []Parser{PlainText(&results[i])}}
+	}
+
+	errs := DoAll(context.Background(), http.DefaultClient, reqs, 2)
+	for i, err := range errs {
+		if i == 3 {
+			if StatusCode(err) != http.StatusNotFound {
+				t.Errorf("errs[3] = %v", err)
+			}
+		} else if err != nil {
+			t.Errorf("errs[%d] = %v", i, err)
+		} else if results[i] != "/"+strconv.Itoa(i) {
+			t.Errorf("results[%d] = %q", i, results[i])
+		}
+	}
+	if maxInFlight > 2 {
+		t.Errorf("maxInFlight = %d, wanted at most 2", maxInFlight)
+	}
+}
+
+func TestDoAllCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errs := DoAll(ctx, http.DefaultClient, []RequestWithParsers{
+		{MakeGet(srv.URL, "", nil, nil), []Parser{None()}},
+		{MakeGet(srv.URL, "", nil, nil), []Parser{None()}},
+	}, 1)
+	for i, err := range errs {
+		if err != context.Canceled {
+			t.Errorf("errs[%d] = %v", i, err)
+		}
+	}
+}
+
+func TestDoAllCancelsRequestsWithOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	errs := DoAll(ctx, http.DefaultClient, []RequestWithParsers{
+		{GzipBody(MakeWith(http.MethodPost, srv.URL, "", WithBody([]byte("data")))), []Parser{None()}},
+	}, 0)
+	if !IsTimeout(errs[0]) {
+		t.Errorf("err = %v, wanted a timeout", errs[0])
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("took %v, ctx not applied", elapsed)
+	}
+}
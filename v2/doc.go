@@ -11,7 +11,7 @@ to send a request and parse the response:
     err := httpsimp.Do(httpsimp.MakeGet(baseURL, path, params, headers), client, httpsimp.JSON(&resp))
 
 where httpsimp.JSON is a body parser function (we also provide PlainText,
-Bytes, Raw and None parsers, and you can define your own).
+Bytes, File, Raw and None parsers, and you can define your own).
 See the example for more details.
 
 You need to pass an instance of *http.Client. You can use http.DefaultClient,
@@ -22,6 +22,13 @@ forever, causing goroutine leaks. A custom client is strongly recommended:
         Timeout: time.Second * 10,
     }
 
+Alternatively, use NewClient to build a Client with centrally managed
+configuration, like per-host TLS settings:
+
+    client := httpsimp.NewClient(
+        httpsimp.Timeout(10*time.Second),
+        httpsimp.HostTLSConfig("*.internal.example.com", internalTLSConfig))
+
 You can adjust body parser parameters by passing additional options to body
 parser functions, like this:
 
@@ -39,6 +46,40 @@ the given content type.
 - httpsimp.ContentType("") will match any content type (can be used to cancel
 default application/json filter used by JSON).
 
+- httpsimp.ContentLanguage("fr") will match only responses with the given
+language in Content-Language header (including more specific ones like fr-CA).
+
+- httpsimp.Progress(func(read, total int64) {...}) reports progress of reading
+the body.
+
+- httpsimp.MaxBytes(n) fails with httpsimp.ErrBodyTooLarge instead of reading
+more than n bytes of the body.
+
+- httpsimp.VerifySHA256(expected) fails with a *httpsimp.ChecksumMismatchError
+if the hash of the body doesn't match (see also VerifySHA1 and VerifyMD5).
+
+- httpsimp.Transform(httpsimp.StripXSSIPrefix) passes the body through the given
+transforms before parsing (see also UnwrapJSONP and DecodeBase64).
+
+- httpsimp.ConvertCharset() converts the body into UTF-8 according to the
+charset parameter of Content-Type (PlainText and HTML do this by default).
+
+- httpsimp.TolerateBOM() removes a leading UTF-8 byte order mark (JSON,
+PlainText and CSV do this by default).
+
+- httpsimp.UseNumber() and httpsimp.DisallowUnknownFields() configure JSON
+decoding like the corresponding json.Decoder methods.
+
+- httpsimp.ValidateJSONSchema(schema) validates the JSON body against
+a JSON Schema, reporting violations as a *httpsimp.SchemaValidationError.
+
+- httpsimp.Vendor(httpsimp.VendorType{...}) will match only responses with
+the given vendor-specific media type, reporting a different version as
+a *httpsimp.VersionMismatchError.
+
+- httpsimp.MatchFunc(func(resp *http.Response) bool {...}) will match only
+responses satisfying the given predicate (see PeekBody for sniffing the body).
+
 - httpsimp.ReturnError() results in a non-nil error returned.
 
 Pass multiple parsers to handle alternative response types or non-2xx status codes:
@@ -51,10 +92,18 @@ Pass multiple parsers to handle alternative response types or non-2xx status cod
         httpsimp.Bytes(&bytes, httpsimp.ContentType("image/png")),
         httpsimp.JSON(&e, httpsimp.Status4xx5xx))
 
-If you need a cancelable request, use http.Request.WithContext:
+To verify a response in smoke tests or production canaries, pass Expect
+alongside the parsers:
+
+    err := httpsimp.Do(req, client, httpsimp.None(),
+        httpsimp.Expect(httpsimp.ExpectStatus(httpsimp.StatusOK), httpsimp.ExpectJSONField("status", "ok")))
+
+If you need a cancelable request, use WithContext (rather than
+http.Request.WithContext, which drops the settings this package stores in
+the request context, like TransformRequestBody, WithTimeout and ForEndpoint):
 
     var resp responseType
-    req := httpsimp.MakeGet(baseURL, path, params, headers).WithContext(myCtx)
+    req := httpsimp.Apply(httpsimp.MakeGet(baseURL, path, params, headers), httpsimp.WithContext(myCtx))
     err := httpsimp.Perform(req, client, httpsimp.JSON(&resp))
 
 You can build the entire http.Request yourself and just call Perform:
@@ -69,10 +118,15 @@ You can build the entire http.Request yourself and just call Perform:
 
 When building custom requests, use our helpers:
 
-URL concatenates a URL and adds query params.
+URL concatenates a URL and adds query params. URLT does the same for a path
+template like "/users/{id}", escaping each parameter.
 
 EncodeForm, EncodeJSONBody and SetBody add a body to a request.
 
+MakeWith builds a request from options like WithQuery, WithHeader,
+WithContext and WithJSONBody instead of positional params and headers.
+NewRequest offers the same as a chainable RequestBuilder.
+
 Finally, you're free to obtain an http.Response through other means
 and then call Parse to handle the response:
 
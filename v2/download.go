@@ -0,0 +1,372 @@
+package httpsimp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/*
+DownloadResumable downloads the body of the given GET request into the file
+at the given path, resuming interrupted downloads.
+
+The data is written to path + ".part", and the validator (ETag or
+Last-Modified) of the response is remembered in path + ".part.validator".
+When the download is interrupted by a network error, it is retried up to
+the given number of attempts in total; each retry (as well as a subsequent
+call after a crash) sends a Range request with If-Range, so the server
+either continues where the previous attempt left off (206 Partial Content),
+or sends the entire body again if the resource has changed (200 OK).
+A body that ends before the size declared by Content-Length (or the
+Content-Range total) is treated as interrupted, too. Local errors (like
+a failure to write the file) are returned without retrying.
+Once the download is complete, the file is renamed to its final path.
+
+The given parse options (like Progress) apply to every attempt.
+Responses other than 200, 206 and 416 are handled by the fallback parsers,
+i.e. result in an error without retrying.
+*/
+func DownloadResumable(r *http.Request, client HTTPClient, path string, attempts int, mopt ...ParseOption) error {
+	partPath := path + ".part"
+	validatorPath := partPath + ".validator"
+
+	var err error
+	for attempt := 1; attempt <= attempts || attempt == 1; attempt++ {
+		err = downloadAttempt(r, client, partPath, validatorPath, mopt)
+		if err == nil {
+			os.Remove(validatorPath)
+			return os.Rename(partPath, path)
+		}
+		var interrupted *downloadInterruptedError
+		if getResponseError(err) != nil && !errors.As(err, &interrupted) {
+			return err
+		}
+		if r.Context().Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// downloadInterruptedError reports a body that ended early or failed to
+// read, as opposed to a local disk error; only these (and transport errors)
+// are retried by DownloadResumable.
+type downloadInterruptedError struct {
+	err error
+}
+
+func (e *downloadInterruptedError) Error() string { return e.err.Error() }
+func (e *downloadInterruptedError) Unwrap() error { return e.err }
+
+func downloadAttempt(r *http.Request, client HTTPClient, partPath, validatorPath string, mopt []ParseOption) error {
+	var offset int64
+	validator, _ := ioutil.ReadFile(validatorPath)
+	if fi, err := os.Stat(partPath); err == nil && len(validator) > 0 {
+		offset = fi.Size()
+	}
+
+	req := r.Clone(r.Context())
+	if offset > 0 {
+		if req.Header == nil {
+			req.Header = make(http.Header)
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", string(validator))
+	}
+
+	full := MakeParser("", append([]ParseOption{StatusOK}, mopt...), func(resp *http.Response) (interface{}, error) {
+		defer resp.Body.Close()
+		validator := resp.Header.Get("ETag")
+		if validator == "" || strings.HasPrefix(validator, "W/") {
+			validator = resp.Header.Get("Last-Modified")
+		}
+		if err := ioutil.WriteFile(validatorPath, []byte(validator), 0666); err != nil {
+			return nil, err
+		}
+		if err := writeFileFrom(partPath, resp.Body, false); err != nil {
+			return nil, err
+		}
+		return nil, checkPartSize(partPath, resp.ContentLength)
+	})
+
+	partial := MakeParser("", append([]ParseOption{StatusPartialContent}, mopt...), func(resp *http.Response) (interface{}, error) {
+		defer resp.Body.Close()
+		start, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			os.Remove(partPath)
+			return nil, fmt.Errorf("unexpected Content-Range %q, wanted bytes starting at %d", resp.Header.Get("Content-Range"), offset)
+		}
+		if err := writeFileFrom(partPath, resp.Body, true); err != nil {
+			return nil, err
+		}
+		return nil, checkPartSize(partPath, size)
+	})
+
+	complete := MakeParser("", []ParseOption{StatusSpec(http.StatusRequestedRangeNotSatisfiable)}, func(resp *http.Response) (interface{}, error) {
+		drainAndClose(resp.Body)
+		_, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || size != offset {
+			os.Remove(partPath)
+			return nil, fmt.Errorf("range not satisfiable, Content-Range %q", resp.Header.Get("Content-Range"))
+		}
+		return nil, nil
+	})
+
+	return Do(req, client, full, partial, complete)
+}
+
+func writeFileFrom(path string, r io.Reader, append bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if append {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0666)
+	if err != nil {
+		return err
+	}
+	br := &bodyReader{r: r}
+	_, err = io.Copy(f, br)
+	if err != nil && br.err != nil {
+		err = &downloadInterruptedError{err}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// bodyReader remembers a read error, telling it apart from write errors
+// returned by io.Copy.
+type bodyReader struct {
+	r   io.Reader
+	err error
+}
+
+func (br *bodyReader) Read(p []byte) (int, error) {
+	n, err := br.r.Read(p)
+	if err != nil && err != io.EOF {
+		br.err = err
+	}
+	return n, err
+}
+
+// checkPartSize verifies that the file at path has the given expected size
+// (unless it is unknown, i.e. negative). A file that is too short means
+// the body has ended early, and can be resumed.
+func checkPartSize(path string, expected int64) error {
+	if expected < 0 {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if size := fi.Size(); size < expected {
+		return &downloadInterruptedError{fmt.Errorf("body ended after %d of %d bytes", size, expected)}
+	} else if size > expected {
+		os.Remove(path)
+		return fmt.Errorf("downloaded %d bytes, but the response declared %d", size, expected)
+	}
+	return nil
+}
+
+// writeFileAtomically writes r into a temporary file next to path,
+// and renames it to path on success.
+func writeFileAtomically(path string, r io.Reader) error {
+	f, err := createTempFile(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// createTempFile creates a temporary file next to path to be renamed over it
+// later. Unlike ioutil.TempFile, which always uses 0600, the file gets
+// the mode of the existing file at path, or 0666 minus umask for a new one.
+func createTempFile(path string) (*os.File, error) {
+	dir, base := filepath.Split(path)
+	for i := 0; ; i++ {
+		name := filepath.Join(dir, "."+base+"."+strconv.FormatUint(uint64(rand.Uint32()), 36)+".tmp")
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && i < 1000 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if fi, err := os.Stat(path); err == nil {
+			if err := f.Chmod(fi.Mode().Perm()); err != nil {
+				f.Close()
+				os.Remove(name)
+				return nil, err
+			}
+		}
+		return f, nil
+	}
+}
+
+// parseContentRange parses "bytes 100-199/1000" and "bytes */1000" values,
+// returning the start offset (-1 for the latter form) and the total size
+// (-1 if unknown).
+func parseContentRange(s string) (start, size int64, err error) {
+	if !strings.HasPrefix(s, "bytes ") {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", s)
+	}
+	s = s[len("bytes "):]
+	i := strings.IndexByte(s, '/')
+	if i < 0 {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", s)
+	}
+	rng, total := s[:i], s[i+1:]
+
+	size = -1
+	if total != "*" {
+		if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q", s)
+		}
+	}
+
+	start = -1
+	if rng != "*" {
+		j := strings.IndexByte(rng, '-')
+		if j < 0 {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q", s)
+		}
+		if start, err = strconv.ParseInt(rng[:j], 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q", s)
+		}
+	}
+	return start, size, nil
+}
+
+/*
+DownloadSegmented downloads the body of the given GET request into the file
+at the given path using up to the given number of concurrent Range requests,
+each fetching a segment of the file, which speeds up large downloads from
+high-latency origins.
+
+A HEAD request is sent first to find out the size of the file. If the
+server rejects it (e.g. with 405 Method Not Allowed), doesn't advertise
+Accept-Ranges: bytes or the size is unknown, the file is downloaded with
+a single request, as with File.
+The segments are written into a temporary file in the same directory,
+which is renamed to path once all of them have been received; if any
+segment fails, the others are canceled and the temporary file is removed.
+Segment requests carry If-Range with the validator (ETag or Last-Modified)
+of the HEAD response, so a resource modified mid-download is detected.
+
+The given parse options apply to every segment request.
+*/
+func DownloadSegmented(r *http.Request, client HTTPClient, path string, segments int, mopt ...ParseOption) error {
+	var meta ResponseMeta
+	head := r.Clone(r.Context())
+	head.Method = http.MethodHead
+	head.Body, head.GetBody, head.ContentLength = nil, nil, 0
+	if head.Header == nil {
+		head.Header = make(http.Header)
+	}
+	head.Header.Set("Accept-Encoding", "identity")
+	if err := Do(head, client, None(), Meta(&meta)); err != nil {
+		if getResponseError(err) != nil {
+			return Do(r, client, File(path, mopt...))
+		}
+		return err
+	}
+	size := meta.ContentLength
+	if segments < 2 || size < int64(segments) || !strings.EqualFold(meta.Header.Get("Accept-Ranges"), "bytes") {
+		return Do(r, client, File(path, mopt...))
+	}
+	validator := meta.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = meta.Header.Get("Last-Modified")
+	}
+
+	f, err := createTempFile(path)
+	if err != nil {
+		return err
+	}
+	err = f.Truncate(size)
+	if err == nil {
+		err = downloadSegments(r, client, f, size, segments, validator, mopt)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func downloadSegments(r *http.Request, client HTTPClient, f *os.File, size int64, segments int, validator string, mopt []ParseOption) error {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	segmentSize := (size + int64(segments) - 1) / int64(segments)
+	errs := make(chan error, segments)
+	for start := int64(0); start < size; start += segmentSize {
+		end := start + segmentSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		req := r.Clone(ctx)
+		if req.Header == nil {
+			req.Header = make(http.Header)
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+		if validator != "" {
+			req.Header.Set("If-Range", validator)
+		}
+		go func(start, end int64) {
+			err := Do(req, client, segmentParser(f, start, end, mopt))
+			if err != nil {
+				cancel()
+			}
+			errs <- err
+		}(start, end)
+	}
+
+	var firstErr error
+	for start := int64(0); start < size; start += segmentSize {
+		if err := <-errs; err != nil && (firstErr == nil || errors.Is(firstErr, context.Canceled)) {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func segmentParser(f *os.File, start, end int64, mopt []ParseOption) Parser {
+	return MakeParser("", append([]ParseOption{StatusPartialContent}, mopt...), func(resp *http.Response) (interface{}, error) {
+		defer resp.Body.Close()
+		rstart, _, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || rstart != start {
+			return nil, fmt.Errorf("unexpected Content-Range %q, wanted bytes starting at %d", resp.Header.Get("Content-Range"), start)
+		}
+		n, err := io.Copy(io.NewOffsetWriter(f, start), io.LimitReader(resp.Body, end-start+1))
+		if err == nil && n != end-start+1 {
+			err = fmt.Errorf("segment %d-%d truncated after %d bytes", start, end, n)
+		}
+		return nil, err
+	})
+}
@@ -0,0 +1,200 @@
+package httpsimp
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDownloadResumable(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if rng := r.Header.Get("Range"); rng != "" && r.Header.Get("If-Range") == `"v1"` {
+			start, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(data)-1, len(data)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(data[start:])
+			return
+		}
+		// simulate a connection dropped in the middle of the first response
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data[:len(data)/3])
+		panic(http.ErrAbortHandler)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "data.bin")
+	err := DownloadResumable(MakeGet(srv.URL, "", nil, nil), http.DefaultClient, path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, data) {
+		t.Fatalf("invalid file contents, len = %d", len(actual))
+	}
+	if requests != 2 {
+		t.Fatalf("requests = %d, wanted 2", requests)
+	}
+}
+
+func TestDownloadResumableShortPartial(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(data)-1, len(data)))
+			w.WriteHeader(http.StatusPartialContent)
+			if requests == 2 {
+				// a proxy that cleanly ends the body early
+				w.Write(data[start : start+100])
+			} else {
+				w.Write(data[start:])
+			}
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data[:len(data)/3])
+		panic(http.ErrAbortHandler)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := DownloadResumable(MakeGet(srv.URL, "", nil, nil), http.DefaultClient, path, 3); err != nil {
+		t.Fatal(err)
+	}
+	if actual, _ := ioutil.ReadFile(path); !bytes.Equal(actual, data) {
+		t.Fatalf("invalid file contents, len = %d", len(actual))
+	}
+	if requests != 3 {
+		t.Fatalf("requests = %d, wanted 3", requests)
+	}
+}
+
+func TestDownloadResumableDiskError(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.Mkdir(path+".part", 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := DownloadResumable(MakeGet(srv.URL, "", nil, nil), http.DefaultClient, path, 3); err == nil {
+		t.Fatal("err is nil")
+	}
+	if requests != 1 {
+		t.Fatalf("requests = %d, wanted 1 (disk errors must not be retried)", requests)
+	}
+}
+
+func TestDownloadSegmented(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+	var mu sync.Mutex
+	var ranges []string
+	etag := `"v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if rng := r.Header.Get("Range"); rng != "" {
+			ranges = append(ranges, rng)
+		}
+		w.Header().Set("ETag", etag)
+		mu.Unlock()
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.bin")
+	if err := DownloadSegmented(MakeGet(srv.URL, "/data.bin", nil, nil), http.DefaultClient, path, 3); err != nil {
+		t.Fatal(err)
+	}
+	if actual, _ := ioutil.ReadFile(path); !bytes.Equal(actual, data) {
+		t.Fatalf("invalid file contents, len = %d", len(actual))
+	}
+	sort.Strings(ranges)
+	if a, e := strings.Join(ranges, " "), "bytes=0-5333 bytes=10668-15999 bytes=5334-10667"; a != e {
+		t.Errorf("ranges = %q, wanted %q", a, e)
+	}
+
+	// resource modified after HEAD: If-Range makes the server send 200
+	ranges = nil
+	os.Remove(path)
+	client := &headHookClient{http.DefaultClient, func() {
+		mu.Lock()
+		etag = `"v2"`
+		mu.Unlock()
+	}}
+	if err := DownloadSegmented(MakeGet(srv.URL, "/data.bin", nil, nil), client, path, 3); StatusCode(err) != http.StatusOK {
+		t.Fatalf("err = %v, wanted a 200 status mismatch", err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("%d files left in the directory", len(files))
+	}
+}
+
+func TestDownloadSegmentedFallback(t *testing.T) {
+	data := []byte("no ranges here")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			t.Errorf("unexpected Range request")
+		}
+		if r.Method == http.MethodHead {
+			if ae := r.Header.Get("Accept-Encoding"); ae != "identity" {
+				t.Errorf("HEAD Accept-Encoding = %q, wanted identity", ae)
+			}
+			if r.URL.Path == "/nohead" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	for _, p := range []string{"/data.bin", "/nohead"} {
+		path := filepath.Join(t.TempDir(), "data.bin")
+		if err := DownloadSegmented(MakeGet(srv.URL, p, nil, nil), http.DefaultClient, path, 4); err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		if actual, _ := ioutil.ReadFile(path); !bytes.Equal(actual, data) {
+			t.Fatalf("%s: file contents = %q", p, actual)
+		}
+	}
+}
+
+// headHookClient calls hook after each HEAD request.
+type headHookClient struct {
+	client HTTPClient
+	hook   func()
+}
+
+func (c *headHookClient) Do(r *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(r)
+	if r.Method == http.MethodHead {
+		c.hook()
+	}
+	return resp, err
+}
@@ -0,0 +1,215 @@
+package httpsimp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/*
+Policy describes how requests to a particular endpoint are handled.
+Attach policies to endpoints with the Endpoint client option.
+*/
+type Policy struct {
+	// Timeout limits the time of a single request, including reading
+	// the response body. It is applied in addition to the client-wide
+	// Timeout, so the shorter one wins.
+	Timeout time.Duration
+
+	// Parsers are tried after the parsers passed to Do, so they can handle
+	// endpoint-specific error responses in a single place.
+	Parsers []Parser
+
+	// Retry, if set, replaces the client's RetryAfter settings for
+	// the endpoint (use Attempts: 1 to disable retries).
+	Retry *RetryPolicy
+
+	// RateLimit, if set, tracks the rate limits reported by the endpoint
+	// and delays requests to it when the budget runs low (see
+	// RateLimitTracker). Share a tracker between endpoints subject to
+	// the same quota.
+	RateLimit *RateLimitTracker
+}
+
+/*
+RetryPolicy configures retries of an endpoint, like RetryAfter does for
+the entire client: a request is sent at most Attempts times, and retried
+when the response has one of the Statuses (429 Too Many Requests if empty).
+*/
+type RetryPolicy struct {
+	Attempts int
+	Statuses []int
+}
+
+/*
+Endpoint registers a named endpoint with the given policy on the client.
+
+The pattern is an optional HTTP method followed by a path, like
+"GET /users/{id}/posts" or "/admin/*". A {name} component matches any single
+path segment, and a trailing * matches one or more remaining segments. Requests whose
+method and path match the pattern get the endpoint's policy applied
+automatically; when several endpoints match, the first one registered wins.
+Registering an endpoint with the same name again replaces it.
+
+Use ForEndpoint to select an endpoint explicitly, in which case the pattern
+can be empty.
+*/
+func Endpoint(name, pattern string, policy Policy) ClientOption {
+	method, path := "", pattern
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		method, path = pattern[:i], strings.TrimSpace(pattern[i+1:])
+	}
+	ep := &endpoint{name, method, splitPath(path), policy}
+	return clientOptionFunc(func(c *clientConfig) {
+		for i, existing := range c.endpoints {
+			if existing.name == name {
+				c.endpoints[i] = ep
+				return
+			}
+		}
+		c.endpoints = append(c.endpoints, ep)
+	})
+}
+
+/*
+ForEndpoint returns a copy of the given request that will be handled
+according to the policy of the named endpoint registered on the Client,
+regardless of its method and path. The name is stored in the request context,
+so http.Request.WithContext drops it; use WithContext instead.
+*/
+func ForEndpoint(r *http.Request, name string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), endpointKey{}, name))
+}
+
+/*
+EndpointName returns the endpoint name set by ForEndpoint, if any.
+*/
+func EndpointName(r *http.Request) string {
+	name, _ := r.Context().Value(endpointKey{}).(string)
+	return name
+}
+
+type endpointKey struct{}
+
+type endpoint struct {
+	name     string
+	method   string
+	segments []string
+	policy   Policy
+}
+
+func (c *clientConfig) endpointFor(r *http.Request) *endpoint {
+	if name, ok := r.Context().Value(endpointKey{}).(string); ok {
+		for _, ep := range c.endpoints {
+			if ep.name == name {
+				return ep
+			}
+		}
+		return nil
+	}
+	for _, ep := range c.endpoints {
+		if ep.matches(r) {
+			return ep
+		}
+	}
+	return nil
+}
+
+func (ep *endpoint) matches(r *http.Request) bool {
+	if len(ep.segments) == 0 {
+		return false
+	}
+	if ep.method != "" && !strings.EqualFold(ep.method, methodOrGet(r.Method)) {
+		return false
+	}
+
+	actual := splitPath(r.URL.Path)
+	for i, seg := range ep.segments {
+		if i >= len(actual) {
+			return false
+		}
+		if seg == "*" && i == len(ep.segments)-1 {
+			return true
+		}
+		if !(strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")) && seg != actual[i] {
+			return false
+		}
+	}
+	return len(actual) == len(ep.segments)
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func methodOrGet(method string) string {
+	if method == "" {
+		return http.MethodGet
+	}
+	return method
+}
+
+// policyClient is implemented by Client to let Do apply endpoint parsers.
+type policyClient interface {
+	policyFor(r *http.Request) *Policy
+}
+
+func (c *Client) policyFor(r *http.Request) *Policy {
+	config := c.load().config
+	if len(config.endpoints) == 0 {
+		return nil
+	}
+	if config.needsBaseURL(r) {
+		u, err := config.resolveURL(r.URL)
+		if err != nil {
+			return nil
+		}
+		rc := *r
+		rc.URL = u
+		r = &rc
+	}
+	return config.policyFor(r)
+}
+
+func (c *clientConfig) policyFor(r *http.Request) *Policy {
+	if ep := c.endpointFor(r); ep != nil {
+		return &ep.policy
+	}
+	return nil
+}
+
+func (c *clientState) do(r *http.Request, policy *Policy) (*http.Response, error) {
+	var client HTTPClient = c.httpClient
+	if policy != nil && policy.RateLimit != nil {
+		client = policy.RateLimit.Client(client)
+	}
+	if policy == nil || policy.Timeout <= 0 {
+		return client.Do(r)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), policy.Timeout)
+	resp, err := client.Do(r.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
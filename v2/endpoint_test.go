@@ -0,0 +1,124 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEndpointPolicy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"code": "conflict"}`))
+	}))
+	defer srv.Close()
+
+	var apiErr struct {
+		Code string `json:"code"`
+	}
+	client := NewClient(
+		Endpoint("users", "POST /users/{id}", Policy{
+			Parsers: []Parser{JSON(&apiErr, Status4xx5xx, ReturnError())},
+		}),
+		Endpoint("slow", "/slow", Policy{Timeout: 50 * time.Millisecond}))
+
+	err := Do(MakeJSON(http.MethodPost, srv.URL, "/users/42", nil, nil, nil), client, None())
+	if err == nil {
+		t.Fatal("err is nil")
+	}
+	if apiErr.Code != "conflict" {
+		t.Fatalf("endpoint parser was not applied: %v", apiErr)
+	}
+
+	err = Do(MakeGet(srv.URL, "/slow", nil, nil), client, None())
+	if err == nil || StatusCode(err) != 0 {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+}
+
+func TestEndpointRetryPolicy(t *testing.T) {
+	calls := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		if calls[r.URL.Path] < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(
+		RetryAfter(3, http.StatusServiceUnavailable),
+		Endpoint("flaky", "/flaky", Policy{Retry: &RetryPolicy{Attempts: 3, Statuses: []int{http.StatusServiceUnavailable}}}),
+		Endpoint("once", "/once", Policy{Retry: &RetryPolicy{Attempts: 1}}))
+
+	if err := Do(MakeGet(srv.URL, "/flaky", nil, nil), client, None()); err != nil {
+		t.Fatal(err)
+	}
+	if err := Do(MakeGet(srv.URL, "/once", nil, nil), client, None()); StatusCode(err) != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v", err)
+	}
+	if calls["/flaky"] != 3 || calls["/once"] != 1 {
+		t.Errorf("calls = %v", calls)
+	}
+}
+
+func TestEndpointRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "10")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "30")
+	}))
+	defer srv.Close()
+
+	limits := NewRateLimitTracker(0)
+	var waited time.Duration
+	limits.wait = func(ctx context.Context, d time.Duration) error {
+		waited += d
+		return context.Canceled
+	}
+	client := NewClient(Endpoint("search", "/search", Policy{RateLimit: limits}))
+
+	if err := Do(MakeGet(srv.URL, "/other", nil, nil), client, None()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := limits.Budget(srv.Listener.Addr().String()); ok {
+		t.Fatal("rate limit tracked for a request outside of the endpoint")
+	}
+	if err := Do(MakeGet(srv.URL, "/search", nil, nil), client, None()); err != nil {
+		t.Fatal(err)
+	}
+	if err := Do(MakeGet(srv.URL, "/search", nil, nil), client, None()); err == nil || waited <= 0 {
+		t.Fatalf("err = %v, waited %v; wanted to wait for the limit reset", err, waited)
+	}
+}
+
+func TestEndpointMatching(t *testing.T) {
+	tests := []struct {
+		pattern, method, path string
+		expected              bool
+	}{
+		{"GET /users/{id}", "GET", "/users/42", true},
+		{"GET /users/{id}", "", "/users/42/", true},
+		{"GET /users/{id}", "POST", "/users/42", false},
+		{"/users/{id}", "DELETE", "/users/42", true},
+		{"/users/{id}", "GET", "/users/42/posts", false},
+		{"/admin/*", "GET", "/admin/a/b", true},
+		{"/admin/*", "GET", "/admin", false},
+	}
+	for _, tt := range tests {
+		c := new(clientConfig)
+		Endpoint("test", tt.pattern, Policy{}).applyToClient(c)
+		r := MakeGet("http://example.com", tt.path, nil, nil)
+		r.Method = tt.method
+		if actual := c.endpointFor(r) != nil; actual != tt.expected {
+			t.Errorf("%q matching %s %s = %v, wanted %v", tt.pattern, tt.method, tt.path, actual, tt.expected)
+		}
+	}
+}
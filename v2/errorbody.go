@@ -0,0 +1,121 @@
+package httpsimp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"unicode/utf8"
+)
+
+// defaultErrorBodyLimit is the maximum size of an error response body
+// read by the fallback parsers, unless overridden via ErrorBodyLimit.
+const defaultErrorBodyLimit = 64 << 10
+
+type errorBodyLimitKey struct{}
+
+/*
+ErrorBodyLimit sets the maximum number of bytes of an unhandled error response
+that Do reads into the returned error (64 KB by default). Longer bodies are
+truncated, which is reported by the error. A negative value removes
+the limit, and zero restores the default.
+
+This affects only the fallback parsers used for responses not handled by
+the parsers passed to Do.
+*/
+func ErrorBodyLimit(n int64) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.errorBodyLimit = n
+	})
+}
+
+func errorBodyLimit(resp *http.Response) int64 {
+	if resp.Request != nil {
+		if n, ok := resp.Request.Context().Value(errorBodyLimitKey{}).(int64); ok {
+			return n
+		}
+	}
+	return defaultErrorBodyLimit
+}
+
+func withErrorBodyLimit(r *http.Request, n int64) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), errorBodyLimitKey{}, n))
+}
+
+// truncatedBody is returned by fallback body parsers to tell parse
+// that the body has been truncated.
+type truncatedBody struct {
+	body interface{}
+}
+
+// failedBody is returned by body parsers to tell parse that the body
+// describes a failure, and must be returned in a ResponseError (as if
+// ReturnError was used) rather than as a decoding error.
+type failedBody struct {
+	body interface{}
+}
+
+func readErrorBody(resp *http.Response) ([]byte, bool, error) {
+	defer drainAndClose(resp.Body)
+
+	limit := errorBodyLimit(resp)
+	var r io.Reader = resp.Body
+	if limit >= 0 {
+		r = io.LimitReader(resp.Body, limit+1)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return b, false, fmt.Errorf("error reading body: %w", err)
+	}
+	if limit >= 0 && int64(len(b)) > limit {
+		return b[:limit], true, nil
+	}
+	return b, false, nil
+}
+
+func fallbackJSON(mopt ...ParseOption) Parser {
+	return MakeParser(ContentTypeJSON, mopt, func(resp *http.Response) (interface{}, error) {
+		b, truncated, err := readErrorBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			return truncatedBody{string(b)}, nil
+		}
+		var body interface{}
+		err = json.Unmarshal(b, &body)
+		return body, err
+	})
+}
+
+func fallbackPlainText(mopt ...ParseOption) Parser {
+	return MakeParser("", mopt, func(resp *http.Response) (interface{}, error) {
+		b, truncated, err := readErrorBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			return truncatedBody{string(trimPartialRune(b))}, nil
+		}
+		if !utf8.Valid(b) {
+			return b, errors.New("invalid utf-8 sequence encountered")
+		}
+		return string(b), nil
+	})
+}
+
+// trimPartialRune removes a multibyte character cut in half at the end of b.
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i > len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
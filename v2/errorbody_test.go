@@ -0,0 +1,61 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorBodyLimit(t *testing.T) {
+	err := get(http.StatusInternalServerError, ContentTypeTextPlain, []byte(strings.Repeat("x", 100000)))
+	e := getResponseError(err)
+	if e == nil || !e.Truncated || len(e.Body.(string)) != defaultErrorBodyLimit {
+		t.Fatalf("unexpected error: %.200v", err)
+	}
+	if !strings.HasSuffix(err.Error(), "... (truncated)") {
+		t.Fatalf("unexpected error message: %.200v", err)
+	}
+
+	err = get(http.StatusInternalServerError, ContentTypeJSON, []byte(`{"error": "oops"}`))
+	if e := getResponseError(err); e == nil || e.Truncated || e.Body.(map[string]interface{})["error"] != "oops" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "something went wrong"}`))
+	}))
+	defer srv.Close()
+
+	err = Do(MakeGet(srv.URL, "", nil, nil), NewClient(ErrorBodyLimit(10)), None())
+	if e := getResponseError(err); e == nil || !e.Truncated || e.Body != `{"error": ` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = Do(MakeGet(srv.URL, "", nil, nil), NewClient(ErrorBodyLimit(-1)), None())
+	if e := getResponseError(err); e == nil || e.Truncated {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestErrorBodyTruncatedUTF8(t *testing.T) {
+	// a stray Latin-1 byte in the middle doesn't shorten the body
+	body := "caf\xe9 " + strings.Repeat("x", 100000)
+	err := get(http.StatusInternalServerError, ContentTypeTextPlain, []byte(body))
+	if e := getResponseError(err); e == nil || !e.Truncated || e.Body != body[:defaultErrorBodyLimit] {
+		t.Fatalf("unexpected error: %.200v", err)
+	}
+
+	// a multibyte character cut in half is dropped
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("aéé"))
+	}))
+	defer srv.Close()
+	err = Do(MakeGet(srv.URL, "", nil, nil), NewClient(ErrorBodyLimit(4)), None())
+	if e := getResponseError(err); e == nil || !e.Truncated || e.Body != "aé" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
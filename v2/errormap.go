@@ -0,0 +1,70 @@
+package httpsimp
+
+import (
+	"errors"
+)
+
+/*
+ErrorMapping maps status specs to functions constructing domain errors
+out of unhandled responses. See ErrorMap.
+*/
+type ErrorMapping map[StatusSpec]func(e *ResponseError) error
+
+/*
+ErrorMap returns a pseudo-parser that translates errors caused by
+responses with the given status codes into errors returned by the mapped
+functions, which get the *ResponseError (with the decoded body, if any):
+
+    var conflict ConflictDetails
+    err := httpsimp.Do(r, client,
+        httpsimp.JSON(&result),
+        httpsimp.JSON(&conflict, httpsimp.StatusSpec(http.StatusConflict), httpsimp.ReturnError()),
+        httpsimp.ErrorMap(httpsimp.ErrorMapping{
+            httpsimp.StatusNotFound: func(*httpsimp.ResponseError) error {
+                return ErrMissing
+            },
+            http.StatusConflict: func(e *httpsimp.ResponseError) error {
+                return &ConflictError{e.Body.(ConflictDetails)}
+            },
+        }))
+
+Do wraps the resulting error like any other, so use errors.Is and errors.As
+to check for it.
+
+A specific status code takes precedence over a range like Status4xx, which
+takes precedence over Status4xx5xx, which takes precedence over StatusAny.
+If the mapped function returns nil, the original error is kept.
+*/
+func ErrorMap(mapping ErrorMapping) Parser {
+	return Parser{
+		mapErr: func(err error) error {
+			var e *ResponseError
+			if !errors.As(err, &e) {
+				return err
+			}
+			if f := mapping.lookup(e.StatusCode); f != nil {
+				if mapped := f(e); mapped != nil {
+					return mapped
+				}
+			}
+			return err
+		},
+	}
+}
+
+func (m ErrorMapping) lookup(code int) func(e *ResponseError) error {
+	if code < 100 || code > 599 {
+		return nil
+	}
+	specs := []StatusSpec{StatusSpec(code), StatusSpec(-(code / 100) * 100)}
+	if code >= 400 {
+		specs = append(specs, Status4xx5xx)
+	}
+	specs = append(specs, StatusAny)
+	for _, spec := range specs {
+		if f := m[spec]; f != nil {
+			return f
+		}
+	}
+	return nil
+}
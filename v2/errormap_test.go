@@ -0,0 +1,71 @@
+package httpsimp
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var errMissing = errors.New("missing")
+
+type conflictError struct {
+	Reason string
+}
+
+func (e *conflictError) Error() string {
+	return "conflict: " + e.Reason
+}
+
+func TestErrorMap(t *testing.T) {
+	type conflictBody struct {
+		Reason string `json:"reason"`
+	}
+	var result, conflict conflictBody
+	parsers := func() []Parser {
+		return []Parser{
+			JSON(&result),
+			JSON(&conflict, StatusSpec(http.StatusConflict), ReturnError()),
+			ErrorMap(ErrorMapping{
+				StatusNotFound: func(*ResponseError) error {
+					return errMissing
+				},
+				http.StatusConflict: func(e *ResponseError) error {
+					return &conflictError{e.Body.(conflictBody).Reason}
+				},
+				Status4xx: func(*ResponseError) error {
+					return nil
+				},
+				Status5xx: func(e *ResponseError) error {
+					return errors.New("server error")
+				},
+			}),
+		}
+	}
+
+	err := get(http.StatusNotFound, ContentTypeTextPlain, []byte("not found"), parsers()...)
+	if !errors.Is(err, errMissing) {
+		t.Errorf("404: unexpected error: %v", err)
+	}
+
+	err = get(http.StatusConflict, ContentTypeJSON, []byte(`{"reason": "exists"}`), parsers()...)
+	var ce *conflictError
+	if !errors.As(err, &ce) || ce.Reason != "exists" {
+		t.Errorf("409: unexpected error: %v", err)
+	}
+
+	err = get(http.StatusBadRequest, ContentTypeTextPlain, []byte("bad"), parsers()...)
+	if StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("400: unexpected error: %v", err)
+	}
+
+	err = get(http.StatusBadGateway, ContentTypeTextPlain, []byte("bad"), parsers()...)
+	if err == nil || !strings.HasPrefix(err.Error(), "GET http://127.0.0.1:") || !strings.HasSuffix(err.Error(), "): server error") {
+		t.Errorf("502: unexpected error: %v", err)
+	}
+
+	err = get(http.StatusOK, ContentTypeJSON, []byte(`{"reason": "ok"}`), parsers()...)
+	if err != nil || result.Reason != "ok" {
+		t.Errorf("200: unexpected error: %v", err)
+	}
+}
@@ -1,40 +1,191 @@
 package httpsimp
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
 )
 
+/*
+ErrBodyTooLarge is returned (wrapped) when a response body exceeds
+the limit set by MaxBytes.
+*/
+var ErrBodyTooLarge = errors.New("response body too large")
+
+/*
+Sentinel errors matching a *ResponseError with the corresponding status code
+via errors.Is:
+
+    if errors.Is(err, httpsimp.ErrNotFound) {
+        ...
+    }
+*/
+var (
+	ErrUnauthorized       error = statusError(http.StatusUnauthorized)
+	ErrForbidden          error = statusError(http.StatusForbidden)
+	ErrNotFound           error = statusError(http.StatusNotFound)
+	ErrConflict           error = statusError(http.StatusConflict)
+	ErrPreconditionFailed error = statusError(http.StatusPreconditionFailed)
+	ErrTooManyRequests    error = statusError(http.StatusTooManyRequests)
+)
+
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("HTTP %d %s", int(e), http.StatusText(int(e)))
+}
+
 type wrapperError struct {
-	Method string
-	Path   string
-	Cause  error
+	Method    string
+	URL       string // with query values redacted
+	Elapsed   time.Duration
+	Attempt   int
+	RequestID string
+	Cause     error
+}
+
+func newWrapperError(r *http.Request, elapsed time.Duration, attempt int, requestID string, cause error) *wrapperError {
+	return &wrapperError{r.Method, redactedURL(r.URL), elapsed, attempt, requestID, cause}
+}
+
+// Unwrap returns the underlying error.
+func (err *wrapperError) Unwrap() error {
+	return err.Cause
 }
 
 func (err *wrapperError) Error() string {
-	if err.Path != "" {
-		return fmt.Sprintf("%s %s: %v", err.Method, err.Path, err.Cause)
-	} else {
-		return fmt.Sprintf("%s: %v", err.Method, err.Cause)
+	var b strings.Builder
+	b.WriteString(err.Method)
+	if err.URL != "" {
+		b.WriteString(" ")
+		b.WriteString(err.URL)
+	}
+	var details []string
+	if err.Elapsed > 0 {
+		details = append(details, roundDuration(err.Elapsed).String())
+	}
+	if err.Attempt > 1 {
+		details = append(details, fmt.Sprintf("attempt %d", err.Attempt))
+	}
+	if err.RequestID != "" {
+		details = append(details, "request ID "+err.RequestID)
+	}
+	if details != nil {
+		b.WriteString(" (")
+		b.WriteString(strings.Join(details, ", "))
+		b.WriteString(")")
+	}
+	b.WriteString(": ")
+	b.WriteString(err.Cause.Error())
+	return b.String()
+}
+
+/*
+ElapsedOf returns the wall time spent by Do before failing with err,
+or 0 if err has not been returned by Do. Together with ResponseMeta.Duration,
+it allows to measure the latency of every call:
+
+    err := httpsimp.Do(r, client, httpsimp.JSON(&resp), httpsimp.Meta(&meta))
+    if err != nil {
+        observe(httpsimp.ElapsedOf(err))
+    } else {
+        observe(meta.Duration)
+    }
+*/
+func ElapsedOf(err error) time.Duration {
+	var we *wrapperError
+	if errors.As(err, &we) {
+		return we.Elapsed
+	}
+	return 0
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// redactedURL formats the given URL for error messages, hiding
+// query parameter values and the password, which often carry secrets.
+func redactedURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	if u.RawQuery == "" && u.User == nil {
+		return u.String()
+	}
+	c := *u
+	if _, has := c.User.Password(); has {
+		c.User = url.UserPassword(c.User.Username(), redactedMask)
+	}
+	if c.RawQuery != "" {
+		q, _ := url.ParseQuery(c.RawQuery)
+		for k, v := range q {
+			for i := range v {
+				v[i] = redactedMask
+			}
+			q[k] = v
+		}
+		c.RawQuery = q.Encode()
 	}
+	return c.String()
 }
 
-type responseError struct {
+/*
+ResponseError is returned by Do and Parse when a response has been received
+but could not be handled: no parser matched it, a parser with ReturnError
+matched it, or the body could not be decoded. Use errors.As to access it:
+
+    var respErr *httpsimp.ResponseError
+    if errors.As(err, &respErr) && respErr.StatusCode == http.StatusConflict {
+        ...
+    }
+*/
+type ResponseError struct {
+	// StatusCode is the HTTP status code of the response.
 	StatusCode int
 
-	ContentType       string
+	// ContentType is the media type of the response (without parameters).
+	ContentType string
+	// WantedContentType is the media type expected by the parser.
 	WantedContentType string
-	ContentTypeOK     bool
+	// ContentTypeOK is false if the content type didn't match.
+	ContentTypeOK bool
+
+	// Language is the Content-Language of the response, set when
+	// the language didn't match the one expected by ContentLanguage.
+	Language string
+	// WantedLanguage is the language expected by ContentLanguage.
+	WantedLanguage string
 
-	Body          interface{}
+	// Body is the decoded response body, e.g. a map for a JSON error
+	// response or a string for a text one. Error masks the values of
+	// secret fields in it (see RedactFields), but Body itself is intact.
+	Body interface{}
+	// Truncated is true if Body only holds the beginning of a long response.
+	Truncated bool
+	// DecodingError is the error encountered while reading or decoding
+	// the body, if any.
 	DecodingError error
 }
 
-func (err *responseError) Error() string {
-	if !err.ContentTypeOK {
+func (err *ResponseError) Error() string {
+	if err.WantedLanguage != "" {
+		return fmt.Sprintf("HTTP %d, unexpected response language %q, wanted %v", err.StatusCode, err.Language, err.WantedLanguage)
+	} else if !err.ContentTypeOK {
 		if err.DecodingError != nil {
 			return fmt.Sprintf("HTTP %d, unexpected response of type %v, wanted %v; error decoding response body: %v", err.StatusCode, err.ContentType, err.WantedContentType, err.DecodingError)
 		} else if err.Body != nil {
-			return fmt.Sprintf("HTTP %d, unexpected response of type %v, wanted %v: %v", err.StatusCode, err.ContentType, err.WantedContentType, err.Body)
+			return fmt.Sprintf("HTTP %d, unexpected response of type %v, wanted %v: %v", err.StatusCode, err.ContentType, err.WantedContentType, err.bodyString())
 		} else {
 			return fmt.Sprintf("HTTP %d, unexpected response type %v, wanted %v", err.StatusCode, err.ContentType, err.WantedContentType)
 		}
@@ -42,20 +193,46 @@ func (err *responseError) Error() string {
 		if err.DecodingError != nil {
 			return fmt.Sprintf("HTTP %d, error decoding %v response: %v", err.StatusCode, err.ContentType, err.DecodingError)
 		} else if err.Body != nil {
-			return fmt.Sprintf("HTTP %d, %v response: %v", err.StatusCode, err.ContentType, err.Body)
+			return fmt.Sprintf("HTTP %d, %v response: %v", err.StatusCode, err.ContentType, err.bodyString())
 		} else {
 			return fmt.Sprintf("HTTP %d, %v response", err.StatusCode, err.ContentType)
 		}
 	}
 }
 
-func getResponseError(err error) *responseError {
-	if e, ok := err.(*wrapperError); ok {
-		err = e.Cause
+func (err *ResponseError) bodyString() string {
+	body := redactBody(err.Body)
+	if err.Truncated {
+		return fmt.Sprintf("%v... (truncated)", body)
+	}
+	return fmt.Sprint(body)
+}
+
+// Unwrap returns the error encountered while decoding the body, if any,
+// or the body itself if it is an error (like JSONAPIErrors).
+func (err *ResponseError) Unwrap() error {
+	if err.DecodingError != nil {
+		return err.DecodingError
+	}
+	if e, ok := err.Body.(error); ok {
+		return e
 	}
+	return nil
+}
 
-	e, _ := err.(*responseError)
-	return e
+// Is reports whether target is one of the sentinel status errors
+// (like ErrNotFound) matching the status code.
+func (err *ResponseError) Is(target error) bool {
+	se, ok := target.(statusError)
+	return ok && int(se) == err.StatusCode
+}
+
+func getResponseError(err error) *ResponseError {
+	var e *ResponseError
+	if errors.As(err, &e) {
+		return e
+	}
+	return nil
 }
 
 /*
@@ -79,3 +256,71 @@ func Is4xx(err error) bool {
 	code := StatusCode(err)
 	return (code != 0) && (code >= 400 && code <= 499)
 }
+
+/*
+IsTimeout returns true if the given error is caused by a timeout: a client
+or dialer timeout, an exceeded context deadline or a 408 Request Timeout
+or 504 Gateway Timeout response.
+*/
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	code := StatusCode(err)
+	return code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout
+}
+
+/*
+IsNetworkError returns true if the given error is caused by a network
+failure (like a refused or reset connection, a DNS error or a timeout)
+while sending the request or reading the response, as opposed to a request
+that could not be built, a canceled context or an unexpected response.
+*/
+func IsNetworkError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var be *BuildError
+	if errors.As(err, &be) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+/*
+IsRetryable returns true if retrying the request that failed with the given
+error makes sense: the error is a timeout or a network error, or the server
+responded with 429 Too Many Requests or a 5xx status (except 501 Not
+Implemented and 505 HTTP Version Not Supported).
+
+Note that it's only safe to retry idempotent requests, or requests
+that the server deduplicates.
+*/
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	switch code := StatusCode(err); {
+	case code == http.StatusTooManyRequests, code == http.StatusRequestTimeout:
+		return true
+	case code == http.StatusNotImplemented, code == http.StatusHTTPVersionNotSupported:
+		return false
+	case code >= 500 && code <= 599:
+		return true
+	case code != 0:
+		return false
+	}
+	return IsTimeout(err) || IsNetworkError(err)
+}
@@ -0,0 +1,164 @@
+package httpsimp
+
+import (
+	"bytes"
+	"container/list"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+/*
+ETagStore remembers ETag and Last-Modified validators (along with the
+response bodies) of GET responses per URL, and turns subsequent requests
+to the same URLs into conditional requests.
+
+Use Client to wrap an HTTPClient:
+
+    etags := httpsimp.NewETagStore()
+    client := etags.Client(&http.Client{Timeout: 10 * time.Second})
+
+    var resp responseType
+    err := httpsimp.Do(httpsimp.MakeGet(baseURL, path, nil, nil), client, httpsimp.JSON(&resp))
+
+When the server responds with 304 Not Modified, the remembered response
+is handed to the parsers instead, so resp is filled in with the same data
+as before. Unlike Cache, ETagStore always asks the server, so it works well
+for resources that change unpredictably.
+
+Response bodies are kept in memory; set MaxEntries when requesting many
+distinct URLs.
+*/
+type ETagStore struct {
+	// MaxEntries limits the number of remembered responses; the least
+	// recently used ones are forgotten first. 0 means no limit.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // of *etagEntry, most recently used first
+}
+
+type etagEntry struct {
+	key        string
+	statusCode int
+	status     string
+	header     http.Header
+	body       []byte
+}
+
+/*
+NewETagStore returns an empty in-memory ETagStore.
+*/
+func NewETagStore() *ETagStore {
+	return &ETagStore{entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+/*
+Client returns an HTTPClient that sends conditional GET requests
+via the given client using the validators remembered by this store.
+*/
+func (s *ETagStore) Client(client HTTPClient) HTTPClient {
+	return &etagClient{s, client}
+}
+
+func (s *ETagStore) get(key string) *etagEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el := s.entries[key]
+	if el == nil {
+		return nil
+	}
+	s.lru.MoveToFront(el)
+	return el.Value.(*etagEntry)
+}
+
+func (s *ETagStore) set(key string, entry *etagEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el := s.entries[key]; el != nil {
+		s.lru.Remove(el)
+		delete(s.entries, key)
+	}
+	if entry == nil {
+		return
+	}
+	entry.key = key
+	s.entries[key] = s.lru.PushFront(entry)
+	for s.MaxEntries > 0 && s.lru.Len() > s.MaxEntries {
+		oldest := s.lru.Remove(s.lru.Back()).(*etagEntry)
+		delete(s.entries, oldest.key)
+	}
+}
+
+type etagClient struct {
+	store  *ETagStore
+	client HTTPClient
+}
+
+func (c *etagClient) Do(r *http.Request) (*http.Response, error) {
+	if (r.Method != "" && r.Method != http.MethodGet) || hasAnyHeader(r.Header, "If-None-Match", "If-Modified-Since", "Range") {
+		return c.client.Do(r)
+	}
+	key := r.URL.String()
+
+	entry := c.store.get(key)
+	if entry != nil {
+		r = r.Clone(r.Context())
+		if r.Header == nil {
+			r.Header = make(http.Header)
+		}
+		if etag := entry.header.Get("ETag"); etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := entry.header.Get("Last-Modified"); lastModified != "" {
+			r.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := c.client.Do(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		drainAndClose(resp.Body)
+
+		header := entry.header.Clone()
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+		return &http.Response{
+			Status:        entry.status,
+			StatusCode:    entry.statusCode,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(entry.body)),
+			ContentLength: int64(len(entry.body)),
+			Request:       resp.Request,
+		}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK || (resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") {
+		if resp.StatusCode < 400 {
+			c.store.set(key, nil)
+		}
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	c.store.set(key, &etagEntry{
+		statusCode: resp.StatusCode,
+		status:     resp.Status,
+		header:     resp.Header.Clone(),
+		body:       body,
+	})
+	return resp, nil
+}
@@ -0,0 +1,63 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETagStore(t *testing.T) {
+	hits, notModified := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Write([]byte(`{"foo": 42}`))
+	}))
+	defer srv.Close()
+
+	client := NewETagStore().Client(http.DefaultClient)
+	for i := 0; i < 3; i++ {
+		var resp struct {
+			Foo int `json:"foo"`
+		}
+		err := Do(MakeGet(srv.URL, "", nil, nil), client, JSON(&resp))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Foo != 42 {
+			t.Fatalf("invalid value of Foo: %v", resp)
+		}
+	}
+	if hits != 3 || notModified != 2 {
+		t.Fatalf("hits = %d, notModified = %d, wanted 3 and 2", hits, notModified)
+	}
+}
+
+func TestNotModifiedIsNotAnError(t *testing.T) {
+	err := get(http.StatusNotModified, ContentTypeJSON, nil, JSON(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestETagStoreMaxEntries(t *testing.T) {
+	store := NewETagStore()
+	store.MaxEntries = 2
+	store.set("a", &etagEntry{})
+	store.set("b", &etagEntry{})
+	store.get("a")
+	store.set("c", &etagEntry{})
+	if store.get("b") != nil || store.get("a") == nil || store.get("c") == nil {
+		t.Errorf("least recently used entry not evicted")
+	}
+	store.set("a", nil)
+	if store.get("a") != nil || len(store.entries) != 1 || store.lru.Len() != 1 {
+		t.Errorf("entry not deleted")
+	}
+}
@@ -0,0 +1,193 @@
+package httpsimp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+/*
+Expectation is an assertion about a response, checked by Expect.
+
+Use ExpectStatus, ExpectHeader, ExpectHeaderValue and ExpectJSONField
+to build expectations.
+*/
+type Expectation struct {
+	needsBody bool
+	check     func(resp *http.Response, body []byte) string
+}
+
+/*
+ExpectationError is returned by Do and Parse when some of the expectations
+passed to Expect are not met. It lists every failed expectation.
+*/
+type ExpectationError struct {
+	StatusCode int
+	Failures   []string
+}
+
+func (err *ExpectationError) Error() string {
+	return fmt.Sprintf("HTTP %d, failed expectations: %s", err.StatusCode, strings.Join(err.Failures, "; "))
+}
+
+/*
+Expect returns a pseudo-parser that checks the given expectations before
+any other parsers run, and makes Do or Parse return an *ExpectationError
+listing all failures if any of them is not met.
+
+Expect does not handle the body by itself, so pass it alongside the
+regular parsers:
+
+    err := httpsimp.Do(req, client,
+        httpsimp.JSON(&resp),
+        httpsimp.Expect(
+            httpsimp.ExpectStatus(httpsimp.StatusOK),
+            httpsimp.ExpectHeader("X-Request-Id"),
+            httpsimp.ExpectJSONField("data.status", "ok")))
+
+This is handy for smoke tests and production canaries. If any of the
+expectations need to look at the body, the body is buffered in memory,
+so the regular parsers still see the full body.
+*/
+func Expect(expectations ...Expectation) Parser {
+	needsBody := false
+	for _, e := range expectations {
+		if e.needsBody {
+			needsBody = true
+		}
+	}
+
+	return Parser{
+		observe: func(resp *http.Response) error {
+			var body []byte
+			if needsBody {
+				var err error
+				body, err = bufferBody(resp)
+				if err != nil {
+					return err
+				}
+			}
+
+			var failures []string
+			for _, e := range expectations {
+				if f := e.check(resp, body); f != "" {
+					failures = append(failures, f)
+				}
+			}
+			if len(failures) > 0 {
+				return &ExpectationError{resp.StatusCode, failures}
+			}
+			return nil
+		},
+	}
+}
+
+/*
+ExpectStatus checks that the response status code matches the given spec,
+which can be a specific status like StatusOK or a range like Status2xx.
+*/
+func ExpectStatus(spec StatusSpec) Expectation {
+	return Expectation{
+		check: func(resp *http.Response, body []byte) string {
+			if spec.Matches(resp.StatusCode) {
+				return ""
+			}
+			return fmt.Sprintf("status is %d, expected %v", resp.StatusCode, spec)
+		},
+	}
+}
+
+/*
+ExpectHeader checks that the response has the given header.
+*/
+func ExpectHeader(name string) Expectation {
+	return Expectation{
+		check: func(resp *http.Response, body []byte) string {
+			if len(resp.Header[http.CanonicalHeaderKey(name)]) > 0 {
+				return ""
+			}
+			return fmt.Sprintf("header %s is missing", http.CanonicalHeaderKey(name))
+		},
+	}
+}
+
+/*
+ExpectHeaderValue checks that the given response header is present and
+equals the given value.
+*/
+func ExpectHeaderValue(name, value string) Expectation {
+	return Expectation{
+		check: func(resp *http.Response, body []byte) string {
+			values := resp.Header[http.CanonicalHeaderKey(name)]
+			if len(values) == 0 {
+				return fmt.Sprintf("header %s is missing", http.CanonicalHeaderKey(name))
+			}
+			if values[0] != value {
+				if isSensitiveHeader(name) {
+					return fmt.Sprintf("header %s has unexpected value", http.CanonicalHeaderKey(name))
+				}
+				return fmt.Sprintf("header %s is %q, expected %q", http.CanonicalHeaderKey(name), values[0], value)
+			}
+			return ""
+		},
+	}
+}
+
+/*
+ExpectJSONField checks that the response body is a JSON document
+with the value at the given path equal to the given value.
+
+The path is a dot-separated list of object keys and array indices,
+like "data.items.0.id"; an empty path refers to the entire document.
+The expected value is compared after a round trip through JSON,
+so ExpectJSONField("count", 42) matches a JSON number 42.
+*/
+func ExpectJSONField(path string, value interface{}) Expectation {
+	return Expectation{
+		needsBody: true,
+		check: func(resp *http.Response, body []byte) string {
+			var doc interface{}
+			if err := json.Unmarshal(body, &doc); err != nil {
+				return fmt.Sprintf("body is not valid JSON: %v", err)
+			}
+			actual, ok := lookupJSONPath(doc, path)
+			if !ok {
+				return fmt.Sprintf("JSON field %q is missing", path)
+			}
+
+			expected, err := normalizeJSONValue(value)
+			if err != nil {
+				return fmt.Sprintf("cannot encode expected value of JSON field %q: %v", path, err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				if isSensitiveField(path[strings.LastIndexByte(path, '.')+1:]) {
+					return fmt.Sprintf("JSON field %q has unexpected value", path)
+				}
+				actual, _ = redactValue(actual)
+				return fmt.Sprintf("JSON field %q is %s, expected %s", path, jsonString(actual), jsonString(expected))
+			}
+			return ""
+		},
+	}
+}
+
+func normalizeJSONValue(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	err = json.NewDecoder(bytes.NewReader(b)).Decode(&v)
+	return v, err
+}
+
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
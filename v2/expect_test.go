@@ -0,0 +1,50 @@
+package httpsimp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExpectPasses(t *testing.T) {
+	var resp struct {
+		Status string `json:"status"`
+	}
+	err := get(http.StatusOK, ContentTypeJSON, []byte(`{"status": "ok", "items": [{"id": 42}]}`),
+		JSON(&resp),
+		Expect(
+			ExpectStatus(StatusOK),
+			ExpectHeader("Content-Type"),
+			ExpectJSONField("status", "ok"),
+			ExpectJSONField("items.0.id", 42)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "ok" {
+		t.Fatalf("invalid value of Status: %v", resp)
+	}
+}
+
+func TestExpectFails(t *testing.T) {
+	err := get(http.StatusOK, ContentTypeJSON, []byte(`{"status": "down"}`),
+		JSON(nil),
+		Expect(
+			ExpectStatus(StatusCreated),
+			ExpectHeader("X-Request-Id"),
+			ExpectJSONField("status", "ok"),
+			ExpectJSONField("items.0.id", 42)))
+	if err == nil {
+		t.Fatal("err is nil")
+	}
+	for _, s := range []string{
+		`HTTP 200, failed expectations`,
+		`status is 200, expected 201`,
+		`header X-Request-Id is missing`,
+		`JSON field "status" is "down", expected "ok"`,
+		`JSON field "items.0.id" is missing`,
+	} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("error does not contain %q: %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+)
+
+/*
+FetchAll fetches a resource for each of the given IDs concurrently, running
+at most limit requests at a time (see DoAll). makeRequest builds the request
+for an ID, and parsers returns the parsers storing the response into
+the given result:
+
+    users, errs := httpsimp.FetchAll(ctx, client, ids, 10,
+        func(id string) *http.Request {
+            return httpsimp.MakeGet(baseURL, "/users/"+url.PathEscape(id), nil, nil)
+        },
+        func(id string, user *User) []httpsimp.Parser {
+            return []httpsimp.Parser{httpsimp.JSON(user)}
+        })
+
+The results of successful requests are returned in the first map, and
+the errors of failed ones in the second, which is nil if all requests
+succeed. Duplicate IDs are fetched once.
+*/
+func FetchAll[ID comparable, T any](ctx context.Context, client HTTPClient, ids []ID, limit int, makeRequest func(id ID) *http.Request, parsers func(id ID, result *T) []Parser) (map[ID]T, map[ID]error) {
+	seen := make(map[ID]bool, len(ids))
+	uniq := make([]ID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			uniq = append(uniq, id)
+		}
+	}
+
+	values := make([]T, len(uniq))
+	reqs := make([]RequestWithParsers, len(uniq))
+	for i, id := range uniq {
+		reqs[i] = RequestWithParsers{makeRequest(id), parsers(id, &values[i])}
+	}
+
+	results := make(map[ID]T, len(uniq))
+	var errs map[ID]error
+	for i, err := range DoAll(ctx, client, reqs, limit) {
+		if err != nil {
+			if errs == nil {
+				errs = make(map[ID]error)
+			}
+			errs[uniq[i]] = err
+		} else {
+			results[uniq[i]] = values[i]
+		}
+	}
+	return results, errs
+}
@@ -0,0 +1,38 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/items/2" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Write([]byte(`{"path": "` + r.URL.Path + `"}`))
+	}))
+	defer srv.Close()
+
+	type item struct {
+		Path string `json:"path"`
+	}
+	results, errs := FetchAll(context.Background(), http.DefaultClient, []string{"1", "2", "3", "1"}, 2,
+		func(id string) *http.Request {
+			return MakeGet(srv.URL, "/items/"+id, nil, nil)
+		},
+		func(id string, result *item) []Parser {
+			return []Parser{JSON(result)}
+		})
+
+	if len(results) != 2 || results["1"].Path != "/items/1" || results["3"].Path != "/items/3" {
+		t.Errorf("results = %v", results)
+	}
+	if len(errs) != 1 || StatusCode(errs["2"]) != http.StatusNotFound {
+		t.Errorf("errs = %v", errs)
+	}
+}
@@ -0,0 +1,116 @@
+package httpsimp
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10000)
+	path := filepath.Join(t.TempDir(), "data.bin")
+
+	var lastRead, lastTotal int64
+	err := get(http.StatusOK, "application/octet-stream", data, File(path, Progress(func(read, total int64) {
+		lastRead, lastTotal = read, total
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, data) {
+		t.Fatalf("invalid file contents, len = %d", len(actual))
+	}
+	if lastRead != int64(len(data)) || (lastTotal != int64(len(data)) && lastTotal != -1) {
+		t.Fatalf("invalid progress: %d of %d", lastRead, lastTotal)
+	}
+}
+
+func TestFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.bin")
+	if err := ioutil.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a checksum mismatch fails the download after the body has been written
+	err := get(http.StatusOK, "application/octet-stream", []byte("new data"), File(path, VerifyMD5("00000000000000000000000000000000")))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if actual, _ := ioutil.ReadFile(path); string(actual) != "old" {
+		t.Errorf("file contents = %q, wanted the old ones", actual)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("%d files in the directory, wanted the temporary file removed", len(files))
+	}
+
+	err = get(http.StatusOK, "application/octet-stream", []byte("new data"), File(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, _ := ioutil.ReadFile(path); string(actual) != "new data" {
+		t.Errorf("file contents = %q", actual)
+	}
+}
+
+func TestFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix file modes on Windows")
+	}
+	dir := t.TempDir()
+
+	// a new file gets the same mode as any other file created by the process
+	ref := filepath.Join(dir, "ref")
+	if err := ioutil.WriteFile(ref, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "new.bin")
+	if err := get(http.StatusOK, "application/octet-stream", []byte("data"), File(path)); err != nil {
+		t.Fatal(err)
+	}
+	refInfo, _ := os.Stat(ref)
+	if fi, _ := os.Stat(path); fi.Mode() != refInfo.Mode() {
+		t.Errorf("new file mode = %v, wanted %v", fi.Mode(), refInfo.Mode())
+	}
+
+	// an existing file keeps its mode
+	path = filepath.Join(dir, "existing.bin")
+	if err := ioutil.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(http.StatusOK, "application/octet-stream", []byte("data"), File(path)); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0600 {
+		t.Errorf("existing file mode = %v, wanted 0600", fi.Mode())
+	}
+}
+
+func TestFileFreeSpaceCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if _, ok := freeSpace(filepath.Dir(path)); !ok {
+		t.Skip("free space cannot be determined on this platform")
+	}
+	err := get(http.StatusOK, "application/octet-stream", []byte("data"), File(path, FreeSpaceCheck(1<<62)))
+	var ise *InsufficientSpaceError
+	if !errors.As(err, &ise) || ise.Needed != 1<<62+4 {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file created: %v", err)
+	}
+
+	if err := get(http.StatusOK, "application/octet-stream", []byte("data"), File(path, FreeSpaceCheck(0))); err != nil {
+		t.Fatal(err)
+	}
+}
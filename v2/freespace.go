@@ -0,0 +1,44 @@
+package httpsimp
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+/*
+InsufficientSpaceError is reported (as the DecodingError of a *ResponseError)
+by File with FreeSpaceCheck when the disk doesn't have room for the body.
+*/
+type InsufficientSpaceError struct {
+	Path      string
+	Needed    int64
+	Available int64
+}
+
+func (err *InsufficientSpaceError) Error() string {
+	return fmt.Sprintf("not enough disk space for %s: need %d bytes, %d available", err.Path, err.Needed, err.Available)
+}
+
+/*
+FreeSpaceCheck makes File check, before writing anything, that the file
+system has room for the body (as given by Content-Length) plus the given
+number of reserve bytes, failing with an *InsufficientSpaceError otherwise.
+
+The check is skipped when Content-Length is unknown, and on platforms
+where free space cannot be determined (only Linux, macOS and FreeBSD
+are supported).
+*/
+func FreeSpaceCheck(reserve int64) ParseOption {
+	return matchOptionFunc(func(m *Parser) {
+		m.checkFreeSpace = true
+		m.freeSpaceReserve = reserve
+	})
+}
+
+func checkFreeSpace(path string, needed int64) error {
+	available, ok := freeSpace(filepath.Dir(path))
+	if ok && available < needed {
+		return &InsufficientSpaceError{path, needed, available}
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package httpsimp
+
+func freeSpace(dir string) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,16 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package httpsimp
+
+import "syscall"
+
+// freeSpace returns the number of bytes available to unprivileged users
+// on the file system containing dir.
+func freeSpace(dir string) (int64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, false
+	}
+	return int64(uint64(st.Bavail) * uint64(st.Bsize)), true
+}
@@ -0,0 +1,71 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+)
+
+/*
+Future is the result of a request started by DoAsync.
+*/
+type Future struct {
+	done chan struct{}
+	err  error
+}
+
+/*
+DoAsync starts executing the request via Do in a new goroutine and returns
+immediately, so that several calls can run concurrently and be joined later:
+
+    var user User
+    var orders []Order
+    fu := httpsimp.DoAsync(userReq, client, httpsimp.JSON(&user))
+    fo := httpsimp.DoAsync(ordersReq, client, httpsimp.JSON(&orders))
+    if err := fu.Wait(ctx); err != nil {
+        return err
+    }
+    if err := fo.Wait(ctx); err != nil {
+        return err
+    }
+
+The parsers write their results concurrently with the caller, so don't touch
+the destination variables until the future completes.
+*/
+func DoAsync(r *http.Request, client HTTPClient, parsers ...Parser) *Future {
+	f := &Future{done: make(chan struct{})}
+	go func() {
+		f.err = Do(r, client, parsers...)
+		close(f.done)
+	}()
+	return f
+}
+
+/*
+Wait waits for the request to complete and returns the error returned by Do.
+If ctx is done first, Wait returns ctx.Err() without waiting further;
+cancel the request's own context to abort the request itself.
+*/
+func (f *Future) Wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+Err waits for the request to complete and returns the error returned by Do.
+*/
+func (f *Future) Err() error {
+	<-f.done
+	return f.err
+}
+
+/*
+Done returns a channel that is closed when the request completes,
+for use in select statements.
+*/
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
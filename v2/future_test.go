@@ -0,0 +1,41 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDoAsync(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	var s string
+	f := DoAsync(MakeGet(srv.URL, "", nil, nil), http.DefaultClient, PlainText(&s))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := f.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait = %v, wanted deadline exceeded", err)
+	}
+
+	close(release)
+	if err := f.Err(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-f.Done():
+	default:
+		t.Fatal("Done not closed")
+	}
+	if err := f.Wait(context.Background()); err != nil || s != "hello" {
+		t.Errorf("err = %v, s = %q", err, s)
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/andreyvit/httpsimplified/v2
 
-go 1.13
+go 1.20
@@ -0,0 +1,26 @@
+//go:build go1.24
+// +build go1.24
+
+package httpsimp
+
+import (
+	"net/http"
+	"time"
+)
+
+/*
+NewH2CClient returns an *http.Client speaking cleartext HTTP/2 (h2c, with
+prior knowledge) for http:// URLs, as used by service meshes and gRPC-style
+internal services. https:// URLs use TLS with HTTP/2 only.
+
+The server must support h2c with prior knowledge; there is no fallback to
+HTTP/1.1 for http:// URLs. Requires Go 1.24 or later.
+*/
+func NewH2CClient(timeout time.Duration) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	protocols.SetHTTP2(true)
+	t.Protocols = &protocols
+	return &http.Client{Transport: t, Timeout: timeout}
+}
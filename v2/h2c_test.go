@@ -0,0 +1,30 @@
+//go:build go1.24
+// +build go1.24
+
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestH2CClient(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte(r.Proto))
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+
+	var proto string
+	if err := Do(MakeGet(srv.URL, "", nil, nil), NewH2CClient(5*time.Second), PlainText(&proto)); err != nil {
+		t.Fatal(err)
+	}
+	if proto != "HTTP/2.0" {
+		t.Errorf("proto = %q", proto)
+	}
+}
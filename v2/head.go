@@ -0,0 +1,54 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/url"
+)
+
+/*
+HeadResult describes a resource probed by Head.
+*/
+type HeadResult struct {
+	StatusCode int
+
+	// Exists is true for 2xx responses, and false for 404 Not Found
+	// and 410 Gone.
+	Exists bool
+
+	// ContentLength is the size of the resource, or -1 if unknown.
+	ContentLength int64
+
+	ContentType string
+	Header      http.Header
+}
+
+/*
+Head sends a HEAD request to probe the existence, size and type of
+a resource, e.g. before downloading it:
+
+    info, err := httpsimp.Head(baseURL, "/artifacts/app.tar.gz", nil, nil, client)
+    if err != nil {
+        return err
+    } else if !info.Exists {
+        return errNoArtifact
+    }
+    log.Printf("downloading %d bytes", info.ContentLength)
+
+A missing resource (404 or 410) is not an error; other non-2xx statuses
+are, as usual.
+*/
+func Head(base, path string, params url.Values, headers http.Header, client HTTPClient) (*HeadResult, error) {
+	var meta ResponseMeta
+	err := Do(MakeHead(base, path, params, headers), client,
+		None(), None(StatusNotFound), None(StatusSpec(http.StatusGone)), Meta(&meta))
+	if err != nil {
+		return nil, err
+	}
+	return &HeadResult{
+		StatusCode:    meta.StatusCode,
+		Exists:        Status2xx.Matches(meta.StatusCode),
+		ContentLength: meta.ContentLength,
+		ContentType:   meta.Header.Get("Content-Type"),
+		Header:        meta.Header,
+	}, nil
+}
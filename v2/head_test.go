@@ -0,0 +1,45 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHead(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/file":
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Header().Set("Content-Length", "12345")
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	info, err := Head(srv.URL, "/file", nil, nil, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Exists || info.StatusCode != http.StatusOK || info.ContentLength != 12345 || info.ContentType != "application/gzip" {
+		t.Errorf("info = %+v", info)
+	}
+
+	info, err = Head(srv.URL, "/missing", nil, nil, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Exists || info.StatusCode != http.StatusNotFound {
+		t.Errorf("info = %+v", info)
+	}
+
+	if _, err := Head(srv.URL, "/forbidden", nil, nil, http.DefaultClient); StatusCode(err) != http.StatusForbidden {
+		t.Errorf("err = %v", err)
+	}
+}
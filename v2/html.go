@@ -0,0 +1,41 @@
+package httpsimp
+
+import (
+	"io"
+	"net/http"
+)
+
+/*
+HTML is a Parser function that verifies the response status code and content
+type (which must be ContentTypeHTML) and passes the body to the given function,
+which can then use an HTML parser of your choice to extract the data you need.
+
+To keep this package free of dependencies, f receives an io.Reader, which
+is what both golang.org/x/net/html and github.com/PuerkitoBio/goquery accept:
+
+    var csrfToken string
+    err := httpsimp.Do(req, client, httpsimp.HTML(func(r io.Reader) error {
+        doc, err := goquery.NewDocumentFromReader(r)
+        if err != nil {
+            return err
+        }
+        csrfToken = doc.Find(`input[name="csrf_token"]`).AttrOr("value", "")
+        return nil
+    }))
+
+The body is converted into UTF-8 according to the charset parameter of
+Content-Type (see ConvertCharset); a charset declared only in a meta tag is
+left for the HTML parser to handle.
+
+An error returned by f is reported as a decoding error in *ResponseError.
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func HTML(f func(r io.Reader) error, mopt ...ParseOption) Parser {
+	p := MakeParser(ContentTypeHTML, mopt, func(resp *http.Response) (interface{}, error) {
+		defer drainAndClose(resp.Body)
+		return nil, f(resp.Body)
+	})
+	p.convertCharset = true
+	return p
+}
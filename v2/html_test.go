@@ -0,0 +1,43 @@
+package httpsimp
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	page := []byte(`<html><body><form><input name="csrf_token" value="abc123"></form></body></html>`)
+	tokenRe := regexp.MustCompile(`name="csrf_token" value="([^"]*)"`)
+
+	var token string
+	err := get(http.StatusOK, "text/html; charset=utf-8", page, HTML(func(r io.Reader) error {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		m := tokenRe.FindSubmatch(b)
+		if m == nil {
+			return errors.New("token not found")
+		}
+		token = string(m[1])
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "abc123" {
+		t.Errorf("token = %q", token)
+	}
+
+	err = get(http.StatusOK, ContentTypeHTML, page, HTML(func(r io.Reader) error {
+		return errors.New("login form changed")
+	}))
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) || respErr.DecodingError == nil {
+		t.Errorf("expected a decoding error, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package http3simp
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// fallbackHandshakeTimeout limits how long NewPreferringClient waits for
+// an HTTP/3 handshake before falling back to TCP; unreachable UDP ports
+// usually go unnoticed until the handshake times out.
+const fallbackHandshakeTimeout = time.Second
+
+/*
+NewClient returns an *http.Client sending all requests over HTTP/3,
+for HTTP/3-only endpoints. tlsConfig can be nil.
+
+Call Close on the returned transport (client.Transport.(io.Closer)) to
+release the UDP sockets when the client is no longer needed.
+*/
+func NewClient(tlsConfig *tls.Config, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &http3.Transport{TLSClientConfig: tlsConfig},
+		Timeout:   timeout,
+	}
+}
+
+/*
+NewPreferringClient returns an *http.Client that tries HTTP/3 first, and
+falls back to HTTP/1.1 or HTTP/2 over TCP (via a clone of
+http.DefaultTransport) when the HTTP/3 connection cannot be established,
+e.g. because UDP is blocked (which is detected after a 1s handshake timeout,
+paid by every request to such a host). Requests with a body that cannot be
+replayed (see httpsimp.SetBodyReader) are not retried over TCP.
+*/
+func NewPreferringClient(tlsConfig *tls.Config, timeout time.Duration) *http.Client {
+	tcp := http.DefaultTransport.(*http.Transport).Clone()
+	tcp.TLSClientConfig = tlsConfig
+	return &http.Client{
+		Transport: &fallbackTransport{
+			h3: &http3.Transport{
+				TLSClientConfig: tlsConfig,
+				QUICConfig:      &quic.Config{HandshakeIdleTimeout: fallbackHandshakeTimeout},
+			},
+			tcp: tcp,
+		},
+		Timeout: timeout,
+	}
+}
+
+type fallbackTransport struct {
+	h3  *http3.Transport
+	tcp *http.Transport
+}
+
+func (t *fallbackTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Scheme != "https" {
+		return t.tcp.RoundTrip(r)
+	}
+	resp, err := t.h3.RoundTrip(r)
+	if err == nil || r.Context().Err() != nil {
+		return resp, err
+	}
+
+	if r.Body != nil && r.Body != http.NoBody {
+		if r.GetBody == nil {
+			return nil, err
+		}
+		body, berr := r.GetBody()
+		if berr != nil {
+			return nil, err
+		}
+		r = r.Clone(r.Context())
+		r.Body = body
+	}
+	return t.tcp.RoundTrip(r)
+}
+
+// Close releases the UDP sockets of the HTTP/3 transport and closes idle
+// TCP connections.
+func (t *fallbackTransport) Close() error {
+	t.tcp.CloseIdleConnections()
+	return t.h3.Close()
+}
+
+var _ io.Closer = (*fallbackTransport)(nil)
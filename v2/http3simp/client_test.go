@@ -0,0 +1,48 @@
+package http3simp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httpsimp "github.com/andreyvit/httpsimplified/v2"
+)
+
+func TestPreferringClientFallsBackToTCP(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", httpsimp.ContentTypeTextPlain)
+		w.Write([]byte(r.Proto))
+	}))
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	client := NewPreferringClient(&tls.Config{RootCAs: pool}, 5*time.Second)
+	defer client.Transport.(io.Closer).Close()
+
+	// the test server doesn't listen on UDP, so HTTP/3 fails
+	var proto string
+	if err := httpsimp.Do(httpsimp.MakeGet(srv.URL, "/", nil, nil), client, httpsimp.PlainText(&proto)); err != nil {
+		t.Fatal(err)
+	}
+	if proto != "HTTP/1.1" {
+		t.Errorf("proto = %q, wanted a fallback to HTTP/1.1", proto)
+	}
+}
+
+func TestPreferringClientPlainHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := NewPreferringClient(nil, 5*time.Second)
+	defer client.Transport.(io.Closer).Close()
+	if err := httpsimp.Do(httpsimp.MakeGet(srv.URL, "/", nil, nil), client, httpsimp.None()); err != nil {
+		t.Fatal(err)
+	}
+}
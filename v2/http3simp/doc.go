@@ -0,0 +1,11 @@
+/*
+Package http3simp builds HTTP clients backed by quic-go's HTTP/3 transport,
+for use with httpsimp.Do and the rest of httpsimp:
+
+    client := http3simp.NewClient(nil, 10*time.Second)
+    err := httpsimp.Do(httpsimp.MakeGet(baseURL, path, nil, nil), client, httpsimp.JSON(&resp))
+
+It is a separate module, so that httpsimp itself doesn't depend on
+github.com/quic-go/quic-go.
+*/
+package http3simp
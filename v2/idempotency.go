@@ -0,0 +1,53 @@
+package httpsimp
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// IdempotencyKeyHeader is the header used by payment APIs like Stripe
+// to deduplicate retried requests.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+/*
+WithIdempotencyKey sets the Idempotency-Key header, letting the server
+recognize retries of the same logical request. Derive the key from your
+own identifiers (e.g. an order ID) to keep it stable across process
+restarts too.
+
+Retries made by the Client (see RetryAfter) send the same header.
+*/
+func WithIdempotencyKey(key string) RequestOption {
+	return WithHeaderValue(IdempotencyKeyHeader, key)
+}
+
+/*
+WithNewIdempotencyKey sets the Idempotency-Key header to a random UUID
+(see NewIdempotencyKey), unless the request already has one. The key is
+generated when the option is applied, so it stays the same for all retries
+of the request made by the Client; to retry it yourself, reuse the request
+(or its key) rather than building a new one.
+*/
+func WithNewIdempotencyKey() RequestOption {
+	return func(r *http.Request) *http.Request {
+		if r.Header.Get(IdempotencyKeyHeader) != "" {
+			return r
+		}
+		return WithIdempotencyKey(NewIdempotencyKey())(r)
+	}
+}
+
+/*
+NewIdempotencyKey returns a random (version 4) UUID like
+"9b2f0c1e-5a4d-4c3b-8e7f-0a1b2c3d4e5f".
+*/
+func NewIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,42 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, b := NewIdempotencyKey(), NewIdempotencyKey()
+	if !re.MatchString(a) || a == b {
+		t.Errorf("keys = %q, %q", a, b)
+	}
+}
+
+func TestIdempotencyKeyStableAcrossRetries(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if len(keys) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(RetryAfter(3, http.StatusServiceUnavailable))
+	r := MakeWith(http.MethodPost, srv.URL, "/charges", WithJSONBody(map[string]int{"amount": 100}), WithNewIdempotencyKey())
+	if err := Do(r, client, None()); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 || keys[0] == "" || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Errorf("keys = %q", keys)
+	}
+
+	r = MakeWith(http.MethodPost, srv.URL, "/charges", WithIdempotencyKey("order-42"), WithNewIdempotencyKey())
+	if a := r.Header.Get(IdempotencyKeyHeader); a != "order-42" {
+		t.Errorf("key = %q", a)
+	}
+}
@@ -0,0 +1,69 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"strings"
+)
+
+/*
+OnInformational returns a copy of the given request that calls the given
+function for every 1xx informational response (like 103 Early Hints)
+received before the final response.
+
+Informational responses never reach the parsers: net/http consumes them
+while waiting for the final response. (The only exception is 101 Switching
+Protocols, which is a final response and can be matched with Status1xx.)
+
+The function is called from the transport goroutine, so it must not block.
+*/
+func OnInformational(r *http.Request, f func(code int, header http.Header)) *http.Request {
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			f(code, http.Header(header))
+			return nil
+		},
+	}
+	return r.WithContext(httptrace.WithClientTrace(r.Context(), trace))
+}
+
+/*
+OnEarlyHints returns a copy of the given request that calls the given
+function with the Link header values of every 103 Early Hints response,
+e.g. `</style.css>; rel=preload; as=style`.
+*/
+func OnEarlyHints(r *http.Request, f func(links []string)) *http.Request {
+	return OnInformational(r, func(code int, header http.Header) {
+		if code == http.StatusEarlyHints {
+			f(splitLinkHeader(header["Link"]))
+		}
+	})
+}
+
+func splitLinkHeader(values []string) []string {
+	var links []string
+	for _, v := range values {
+		inURL := false
+		start := 0
+		for i, c := range v {
+			switch c {
+			case '<':
+				inURL = true
+			case '>':
+				inURL = false
+			case ',':
+				if !inURL {
+					if link := strings.TrimSpace(v[start:i]); link != "" {
+						links = append(links, link)
+					}
+					start = i + 1
+				}
+			}
+		}
+		if link := strings.TrimSpace(v[start:]); link != "" {
+			links = append(links, link)
+		}
+	}
+	return links
+}
@@ -0,0 +1,53 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEarlyHints(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Link", "</style.css>; rel=preload; as=style, </app.js>; rel=preload; as=script")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	var hints []string
+	var text string
+	req := OnEarlyHints(MakeGet(srv.URL, "", nil, nil), func(links []string) {
+		hints = append(hints, links...)
+	})
+	err := Do(req, http.DefaultClient, PlainText(&text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "hello" {
+		t.Fatalf("invalid value of text: %q", text)
+	}
+	expected := []string{"</style.css>; rel=preload; as=style", "</app.js>; rel=preload; as=script"}
+	if !reflect.DeepEqual(hints, expected) {
+		t.Fatalf("invalid hints: %q", hints)
+	}
+}
+
+func TestStatusSpecOutOfRange(t *testing.T) {
+	if StatusAny.Matches(99) || Status1xx.Matches(0) || StatusAny.Matches(600) {
+		t.Fatal("out-of-range status matched")
+	}
+}
+
+func TestParseInvalidStatus(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 600,
+		Header:     http.Header{"Content-Type": []string{ContentTypeJSON}},
+		Body:       http.NoBody,
+	}
+	if err := Parse(resp, None(StatusAny)); err == nil {
+		t.Fatal("err is nil")
+	}
+}
@@ -0,0 +1,145 @@
+package httpsimp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+/*
+JSONAPIDocument is the top-level envelope of a JSON:API response
+(https://jsonapi.org/format/#document-top-level).
+*/
+type JSONAPIDocument struct {
+	Data     json.RawMessage        `json:"data,omitempty"`
+	Included []json.RawMessage      `json:"included,omitempty"`
+	Errors   JSONAPIErrors          `json:"errors,omitempty"`
+	Meta     map[string]interface{} `json:"meta,omitempty"`
+	Links    map[string]interface{} `json:"links,omitempty"`
+}
+
+/*
+JSONAPIError is a JSON:API error object
+(https://jsonapi.org/format/#error-objects).
+*/
+type JSONAPIError struct {
+	ID     string                 `json:"id,omitempty"`
+	Status string                 `json:"status,omitempty"`
+	Code   string                 `json:"code,omitempty"`
+	Title  string                 `json:"title,omitempty"`
+	Detail string                 `json:"detail,omitempty"`
+	Source *JSONAPIErrorSource    `json:"source,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+/*
+JSONAPIErrorSource points to the part of the request that caused an error.
+*/
+type JSONAPIErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
+}
+
+func (e JSONAPIError) String() string {
+	var parts []string
+	for _, s := range []string{e.Code, e.Title, e.Detail} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	s := strings.Join(parts, ": ")
+	if e.Source != nil && e.Source.Pointer != "" {
+		s += " (at " + e.Source.Pointer + ")"
+	}
+	return s
+}
+
+/*
+JSONAPIErrors is the list of error objects of a JSON:API response.
+It becomes the Body of the ResponseError returned by JSONAPI and for unhandled
+JSON:API error responses, so it can be obtained via errors.As:
+
+    var apiErrs httpsimp.JSONAPIErrors
+    if errors.As(err, &apiErrs) {
+        for _, e := range apiErrs {
+            log.Printf("%s: %s", e.Code, e.Detail)
+        }
+    }
+*/
+type JSONAPIErrors []JSONAPIError
+
+func (errs JSONAPIErrors) Error() string {
+	strs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		strs = append(strs, e.String())
+	}
+	return strings.Join(strs, "; ")
+}
+
+/*
+JSONAPI is a Parser function that verifies the response status code and content
+type (which must be ContentTypeJSONAPI) and decodes the JSON:API document.
+
+If result is a *JSONAPIDocument, the whole document is stored into it
+(so you can decode the included resources and look at meta and links).
+Otherwise, the primary data is unmarshaled into result.
+
+If the document contains errors, a *ResponseError is returned with
+the JSONAPIErrors as its Body (and a nil DecodingError); use errors.As
+to obtain them.
+
+When sending JSON:API requests, set the Content-Type header explicitly:
+
+    r := httpsimp.MakeJSON(http.MethodPost, baseURL, "/articles", nil, doc, http.Header{
+        "Content-Type": {httpsimp.ContentTypeJSONAPI},
+        "Accept":       {httpsimp.ContentTypeJSONAPI},
+    })
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func JSONAPI(result interface{}, mopt ...ParseOption) Parser {
+	return MakeParser(ContentTypeJSONAPI, mopt, func(resp *http.Response) (interface{}, error) {
+		defer drainAndClose(resp.Body)
+
+		var doc JSONAPIDocument
+		if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+			return nil, err
+		}
+		if len(doc.Errors) > 0 {
+			return failedBody{doc.Errors}, nil
+		}
+
+		if d, ok := result.(*JSONAPIDocument); ok {
+			*d = doc
+		} else if result != nil && len(doc.Data) > 0 && !bytes.Equal(doc.Data, []byte("null")) {
+			if err := json.Unmarshal(doc.Data, result); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	})
+}
+
+func fallbackJSONAPI(mopt ...ParseOption) Parser {
+	return MakeParser(ContentTypeJSONAPI, mopt, func(resp *http.Response) (interface{}, error) {
+		b, truncated, err := readErrorBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			return truncatedBody{string(b)}, nil
+		}
+		var doc JSONAPIDocument
+		if err := json.Unmarshal(b, &doc); err != nil {
+			return nil, err
+		}
+		if len(doc.Errors) > 0 {
+			return doc.Errors, nil
+		}
+		var body interface{}
+		err = json.Unmarshal(b, &body)
+		return body, err
+	})
+}
@@ -0,0 +1,69 @@
+package httpsimp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestJSONAPI(t *testing.T) {
+	var article struct {
+		Type       string `json:"type"`
+		ID         string `json:"id"`
+		Attributes struct {
+			Title string `json:"title"`
+		} `json:"attributes"`
+	}
+	body := []byte(`{"data": {"type": "articles", "id": "1", "attributes": {"title": "Hello"}}, "included": [{"type": "people", "id": "9"}]}`)
+	err := get(http.StatusOK, ContentTypeJSONAPI, body, JSONAPI(&article))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if article.ID != "1" || article.Attributes.Title != "Hello" {
+		t.Fatalf("invalid value of article: %+v", article)
+	}
+
+	var doc JSONAPIDocument
+	err = get(http.StatusOK, ContentTypeJSONAPI, body, JSONAPI(&doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var person struct {
+		ID string `json:"id"`
+	}
+	if len(doc.Included) != 1 || json.Unmarshal(doc.Included[0], &person) != nil || person.ID != "9" {
+		t.Fatalf("invalid value of doc: %+v", doc)
+	}
+}
+
+func TestJSONAPIErrors(t *testing.T) {
+	body := []byte(`{"errors": [{"status": "422", "code": "invalid", "title": "Invalid Attribute", "detail": "Title is too short", "source": {"pointer": "/data/attributes/title"}}]}`)
+	err := get(http.StatusUnprocessableEntity, ContentTypeJSONAPI, body, JSONAPI(nil))
+
+	var apiErrs JSONAPIErrors
+	if !errors.As(err, &apiErrs) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(apiErrs) != 1 || apiErrs[0].Source.Pointer != "/data/attributes/title" {
+		t.Fatalf("invalid value of apiErrs: %+v", apiErrs)
+	}
+	if StatusCode(err) != http.StatusUnprocessableEntity {
+		t.Fatalf("unexpected status code of %v", err)
+	}
+	expected := "): HTTP 422, application/vnd.api+json response: invalid: Invalid Attribute: Title is too short (at /data/attributes/title)"
+	if !strings.HasSuffix(err.Error(), expected) {
+		t.Fatalf("err = %q, wanted %q", err.Error(), expected)
+	}
+
+	// errors in a response matched by JSONAPI
+	err = get(http.StatusOK, ContentTypeJSONAPI, body, JSONAPI(nil))
+	respErr := getResponseError(err)
+	if respErr == nil || respErr.DecodingError != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if !errors.As(err, &apiErrs) || len(apiErrs) != 1 {
+		t.Fatalf("invalid value of apiErrs: %+v", apiErrs)
+	}
+}
@@ -0,0 +1,94 @@
+package httpsimp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+/*
+JSONPatchOp is a single operation of a JSON Patch document (RFC 6902).
+
+Op is one of "add", "remove", "replace", "move", "copy" or "test".
+Path and From are JSON Pointers like "/spec/replicas". Value is always
+encoded for add, replace and test operations (even if nil), and never
+for the others.
+*/
+type JSONPatchOp struct {
+	Op    string
+	Path  string
+	From  string
+	Value interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (op JSONPatchOp) MarshalJSON() ([]byte, error) {
+	switch op.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			Op    string      `json:"op"`
+			Path  string      `json:"path"`
+			Value interface{} `json:"value"`
+		}{op.Op, op.Path, op.Value})
+	case "move", "copy":
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			From string `json:"from"`
+			Path string `json:"path"`
+		}{op.Op, op.From, op.Path})
+	default:
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{op.Op, op.Path})
+	}
+}
+
+/*
+EncodeJSONPatchBody encodes the given JSON Patch operations and sets
+the body and Content-Type (application/json-patch+json) on the given request.
+
+To properly handle HTTP redirects, both Body and GetBody are set.
+*/
+func EncodeJSONPatchBody(r *http.Request, ops []JSONPatchOp) *http.Request {
+	if ops == nil {
+		ops = []JSONPatchOp{}
+	}
+	return encodeJSONBodyAs(r, ops, ContentTypeJSONPatch)
+}
+
+/*
+EncodeMergePatchBody encodes the given object into JSON and sets the body
+and Content-Type (application/merge-patch+json) on the given request.
+Per RFC 7396, null values remove the corresponding fields, so use maps or
+structs without omitempty on fields that need to be removed.
+
+If JSON encoding fails, Do returns a *BuildError without sending the request.
+
+To properly handle HTTP redirects, both Body and GetBody are set.
+*/
+func EncodeMergePatchBody(r *http.Request, obj interface{}) *http.Request {
+	return encodeJSONBodyAs(r, obj, ContentTypeMergePatch)
+}
+
+/*
+MakeJSONPatch builds a PATCH request with the given URL, headers and
+JSON Patch operations as the body.
+
+See MakeGet for the meaning of base, path, params and headers.
+*/
+func MakeJSONPatch(base, path string, params url.Values, ops []JSONPatchOp, headers http.Header) *http.Request {
+	return EncodeJSONPatchBody(makeRequest(http.MethodPatch, base, path, params, headers), ops)
+}
+
+/*
+MakeMergePatch builds a PATCH request with the given URL, headers and
+the given object encoded as a JSON Merge Patch body.
+
+See MakeGet for the meaning of base, path, params and headers.
+
+If JSON encoding fails, Do returns a *BuildError.
+*/
+func MakeMergePatch(base, path string, params url.Values, obj interface{}, headers http.Header) *http.Request {
+	return EncodeMergePatchBody(makeRequest(http.MethodPatch, base, path, params, headers), obj)
+}
@@ -0,0 +1,30 @@
+package httpsimp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestJSONPatch(t *testing.T) {
+	r := MakeJSONPatch("http://example.com", "/x", nil, []JSONPatchOp{
+		{Op: "replace", Path: "/spec/replicas", Value: 3},
+		{Op: "add", Path: "/metadata/labels/x", Value: nil},
+		{Op: "remove", Path: "/status"},
+		{Op: "move", From: "/a", Path: "/b"},
+	}, nil)
+	body, _ := ioutil.ReadAll(r.Body)
+	expected := `[{"op":"replace","path":"/spec/replicas","value":3},{"op":"add","path":"/metadata/labels/x","value":null},{"op":"remove","path":"/status"},{"op":"move","from":"/a","path":"/b"}]`
+	if string(body) != expected {
+		t.Errorf("body = %s, wanted %s", body, expected)
+	}
+	if r.Method != http.MethodPatch || r.Header.Get("Content-Type") != ContentTypeJSONPatch {
+		t.Errorf("method = %s, Content-Type = %s", r.Method, r.Header.Get("Content-Type"))
+	}
+
+	r = MakeMergePatch("http://example.com", "/x", nil, map[string]interface{}{"a": nil}, nil)
+	body, _ = ioutil.ReadAll(r.Body)
+	if string(body) != `{"a":null}` || r.Header.Get("Content-Type") != ContentTypeMergePatch {
+		t.Errorf("body = %s, Content-Type = %s", body, r.Header.Get("Content-Type"))
+	}
+}
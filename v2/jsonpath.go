@@ -0,0 +1,110 @@
+package httpsimp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+lookupJSONPath walks a value decoded by encoding/json into interface{}
+following a dot-separated path like "data.items.0.id", where numeric
+components index into arrays. An empty path returns the value itself.
+*/
+func lookupJSONPath(v interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return v, true
+	}
+	for _, comp := range strings.Split(path, ".") {
+		switch o := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			v, ok = o[comp]
+			if !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(comp)
+			if err != nil || i < 0 || i >= len(o) {
+				return nil, false
+			}
+			v = o[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+/*
+JSONPath is a Parser function that verifies the response status code and
+content type (which must be ContentTypeJSON) and unmarshals just the value
+at the given dot-separated path into dst, without requiring structs mirroring
+the whole response:
+
+    var id int
+    err := httpsimp.Do(req, client, httpsimp.JSONPath("data.items.0.id", &id))
+
+Numeric path components index into arrays. Only the objects and arrays along
+the path are decoded (into raw JSON), so this is cheaper than decoding
+the entire response, although the body is still read in full. A missing
+value is reported as a decoding error.
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func JSONPath(path string, dst interface{}, mopt ...ParseOption) Parser {
+	p := MakeParser(ContentTypeJSON, mopt, func(resp *http.Response) (interface{}, error) {
+		defer drainAndClose(resp.Body)
+		var raw json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+			return nil, err
+		}
+		v, ok := lookupRawJSONPath(raw, path)
+		if !ok {
+			return nil, fmt.Errorf("JSON path %q not found", path)
+		}
+		return nil, json.Unmarshal(v, dst)
+	})
+	p.stripBOM = true
+	return p
+}
+
+func lookupRawJSONPath(raw json.RawMessage, path string) (json.RawMessage, bool) {
+	if path == "" {
+		return raw, true
+	}
+	for _, comp := range strings.Split(path, ".") {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			return nil, false
+		}
+		switch raw[0] {
+		case '{':
+			var o map[string]json.RawMessage
+			if json.Unmarshal(raw, &o) != nil {
+				return nil, false
+			}
+			var ok bool
+			raw, ok = o[comp]
+			if !ok {
+				return nil, false
+			}
+		case '[':
+			i, err := strconv.Atoi(comp)
+			if err != nil || i < 0 {
+				return nil, false
+			}
+			var a []json.RawMessage
+			if json.Unmarshal(raw, &a) != nil || i >= len(a) {
+				return nil, false
+			}
+			raw = a[i]
+		default:
+			return nil, false
+		}
+	}
+	return raw, true
+}
@@ -0,0 +1,39 @@
+package httpsimp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	body := []byte(`{"data": {"items": [{"id": 7, "tags": ["x", "y"]}, {"id": 8}]}, "total": 2}`)
+
+	var id int
+	err := get(http.StatusOK, ContentTypeJSON, body, JSONPath("data.items.0.id", &id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 7 {
+		t.Errorf("id = %d", id)
+	}
+
+	var tags []string
+	err = get(http.StatusOK, ContentTypeJSON, body, JSONPath("data.items.0.tags", &tags))
+	if err != nil || len(tags) != 2 {
+		t.Errorf("tags = %v, %v", tags, err)
+	}
+
+	var whole map[string]interface{}
+	err = get(http.StatusOK, ContentTypeJSON, body, JSONPath("", &whole))
+	if err != nil || whole["total"] != 2.0 {
+		t.Errorf("whole = %v, %v", whole, err)
+	}
+
+	for _, path := range []string{"data.items.2.id", "data.items.x", "total.x", "missing"} {
+		err = get(http.StatusOK, ContentTypeJSON, body, JSONPath(path, &id))
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("%s: unexpected error %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package httpsimp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+var lastJSONRPCID uint64
+
+func nextJSONRPCID() uint64 {
+	return atomic.AddUint64(&lastJSONRPCID, 1)
+}
+
+type jsonrpcRequest struct {
+	Version string      `json:"jsonrpc"`
+	ID      *uint64     `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type jsonrpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *JSONRPCError   `json:"error"`
+}
+
+/*
+JSONRPCError is an error object returned by a JSON-RPC 2.0 server.
+*/
+type JSONRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (err *JSONRPCError) Error() string {
+	if len(err.Data) > 0 {
+		return fmt.Sprintf("JSON-RPC error %d: %s (%s)", err.Code, err.Message, err.Data)
+	}
+	return fmt.Sprintf("JSON-RPC error %d: %s", err.Code, err.Message)
+}
+
+/*
+MakeJSONRPC builds a JSON-RPC 2.0 request calling the given method with
+the given params (a struct, map or slice, or nil) at the given endpoint URL.
+A unique request id is assigned automatically. Handle the response with
+the JSONRPC parser:
+
+    r := httpsimp.MakeJSONRPC(endpoint, "eth_getBalance", []interface{}{addr, "latest"}, nil)
+    err := httpsimp.Do(r, client, httpsimp.JSONRPC(&balance))
+
+If JSON encoding fails, Do returns a *BuildError.
+*/
+func MakeJSONRPC(endpoint, method string, params interface{}, headers http.Header) *http.Request {
+	id := nextJSONRPCID()
+	return MakeJSON(http.MethodPost, endpoint, "", nil, &jsonrpcRequest{"2.0", &id, method, params}, headers)
+}
+
+/*
+JSONRPC is a Parser function that decodes a JSON-RPC 2.0 response, unmarshaling
+the result into the result variable (which can be nil to ignore it).
+If the server returns an error object, it is returned as a *JSONRPCError
+(use errors.As to obtain it).
+
+Unlike JSON, the parser matches responses with any status code by default,
+because some servers report JSON-RPC errors with HTTP 4xx or 5xx statuses.
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func JSONRPC(result interface{}, mopt ...ParseOption) Parser {
+	mopt = append([]ParseOption{StatusAny}, mopt...)
+	return MakeParser(ContentTypeJSON, mopt, func(resp *http.Response) (interface{}, error) {
+		defer drainAndClose(resp.Body)
+		var r jsonrpcResponse
+		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+			return nil, err
+		}
+		return r.Result, r.decode(result)
+	})
+}
+
+func (r *jsonrpcResponse) decode(result interface{}) error {
+	if r.Version != "2.0" {
+		return fmt.Errorf("not a JSON-RPC 2.0 response")
+	}
+	if r.Error != nil {
+		return r.Error
+	}
+	if result != nil && len(r.Result) > 0 {
+		return json.Unmarshal(r.Result, result)
+	}
+	return nil
+}
+
+/*
+JSONRPCCall is a single call within a JSON-RPC batch. Set Method, Params and
+Result (a pointer to unmarshal the result into, or nil) before making
+the batch request; Err is filled in by the JSONRPCBatch parser.
+*/
+type JSONRPCCall struct {
+	Method string
+	Params interface{}
+	Result interface{}
+
+	// Notification calls don't expect a response.
+	Notification bool
+
+	// Err is the error returned by the server for this call (a *JSONRPCError),
+	// or an error if the result could not be decoded or is missing.
+	Err error
+
+	id uint64
+}
+
+/*
+MakeJSONRPCBatch builds a JSON-RPC 2.0 batch request performing the given
+calls, assigning a unique id to each of them. Handle the response with
+JSONRPCBatch parser passing the same calls.
+
+If JSON encoding fails, Do returns a *BuildError.
+*/
+func MakeJSONRPCBatch(endpoint string, calls []*JSONRPCCall, headers http.Header) *http.Request {
+	reqs := make([]*jsonrpcRequest, 0, len(calls))
+	for _, c := range calls {
+		req := &jsonrpcRequest{Version: "2.0", Method: c.Method, Params: c.Params}
+		if !c.Notification {
+			c.id = nextJSONRPCID()
+			req.ID = &c.id
+		}
+		reqs = append(reqs, req)
+	}
+	return MakeJSON(http.MethodPost, endpoint, "", nil, reqs, headers)
+}
+
+/*
+JSONRPCBatch is a Parser function that decodes a JSON-RPC 2.0 batch response,
+matching responses to the given calls (which must have been passed into
+MakeJSONRPCBatch) by id and filling in their Result and Err.
+
+Failures of individual calls are only reported via their Err fields.
+The parser itself fails if the response is not a valid batch response,
+e.g. if the server rejected the whole batch with a single error object.
+Like JSONRPC, it matches responses with any status code by default;
+it also accepts any content type, because a batch of notifications
+gets an empty response.
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func JSONRPCBatch(calls []*JSONRPCCall, mopt ...ParseOption) Parser {
+	mopt = append([]ParseOption{StatusAny, ContentType("")}, mopt...)
+	return MakeParser(ContentTypeJSON, mopt, func(resp *http.Response) (interface{}, error) {
+		defer drainAndClose(resp.Body)
+		var raw json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&raw); err == io.EOF {
+			raw = json.RawMessage("[]") // a batch of notifications only
+		} else if err != nil {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+			var r jsonrpcResponse
+			if err := json.Unmarshal(raw, &r); err != nil {
+				return nil, err
+			}
+			if err := r.decode(nil); err != nil {
+				return nil, err
+			}
+			return nil, errors.New("JSON-RPC batch response is not an array")
+		}
+
+		var responses []*jsonrpcResponse
+		if err := json.Unmarshal(raw, &responses); err != nil {
+			return nil, err
+		}
+		byID := make(map[string]*jsonrpcResponse, len(responses))
+		for _, r := range responses {
+			byID[string(bytes.TrimSpace(r.ID))] = r
+		}
+		for _, c := range calls {
+			if c.Notification {
+				continue
+			}
+			if r := byID[strconv.FormatUint(c.id, 10)]; r != nil {
+				c.Err = r.decode(c.Result)
+			} else {
+				c.Err = fmt.Errorf("no response to JSON-RPC call %s", c.Method)
+			}
+		}
+		return responses, nil
+	})
+}
@@ -0,0 +1,94 @@
+package httpsimp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func jsonrpcServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		type request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []int           `json:"params"`
+		}
+		handle := func(req request) interface{} {
+			if req.ID == nil {
+				return nil
+			}
+			if req.Method != "sum" {
+				return map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32601, "message": "Method not found"}}
+			}
+			sum := 0
+			for _, v := range req.Params {
+				sum += v
+			}
+			return map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": sum}
+		}
+
+		var raw json.RawMessage
+		json.NewDecoder(r.Body).Decode(&raw)
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		var batch []request
+		if json.Unmarshal(raw, &batch) == nil {
+			var responses []interface{}
+			for i := len(batch) - 1; i >= 0; i-- {
+				if resp := handle(batch[i]); resp != nil {
+					responses = append(responses, resp)
+				}
+			}
+			json.NewEncoder(w).Encode(responses)
+			return
+		}
+		var req request
+		json.Unmarshal(raw, &req)
+		json.NewEncoder(w).Encode(handle(req))
+	}))
+}
+
+func TestJSONRPC(t *testing.T) {
+	srv := jsonrpcServer()
+	defer srv.Close()
+
+	var sum int
+	err := Do(MakeJSONRPC(srv.URL, "sum", []int{1, 2, 3}, nil), http.DefaultClient, JSONRPC(&sum))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != 6 {
+		t.Fatalf("invalid value of sum: %d", sum)
+	}
+
+	err = Do(MakeJSONRPC(srv.URL, "mul", []int{1, 2, 3}, nil), http.DefaultClient, JSONRPC(&sum))
+	var rpcErr *JSONRPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestJSONRPCBatch(t *testing.T) {
+	srv := jsonrpcServer()
+	defer srv.Close()
+
+	var a, b int
+	calls := []*JSONRPCCall{
+		{Method: "sum", Params: []int{1, 2}, Result: &a},
+		{Method: "sum", Params: []int{3, 4}, Result: &b},
+		{Method: "log", Params: []int{0}, Notification: true},
+		{Method: "mul", Params: []int{5}},
+	}
+	err := Do(MakeJSONRPCBatch(srv.URL, calls, nil), http.DefaultClient, JSONRPCBatch(calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != 3 || b != 7 || calls[0].Err != nil || calls[1].Err != nil || calls[2].Err != nil {
+		t.Fatalf("a = %d, b = %d, errs = %v, %v, %v", a, b, calls[0].Err, calls[1].Err, calls[2].Err)
+	}
+	var rpcErr *JSONRPCError
+	if !errors.As(calls[3].Err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Fatalf("unexpected error: %v", calls[3].Err)
+	}
+}
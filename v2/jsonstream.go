@@ -0,0 +1,89 @@
+package httpsimp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+/*
+JSONStream is a Parser function that verifies the response status code and
+content type (which must be ContentTypeJSON) and hands a json.Decoder reading
+the body to f, so that huge responses can be processed incrementally
+using Token and More instead of being decoded in one go.
+
+An error returned by f is reported as a decoding error in *ResponseError
+(so errors.Is and errors.As still work on it).
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func JSONStream(f func(dec *json.Decoder) error, mopt ...ParseOption) Parser {
+	p := MakeParser(ContentTypeJSON, mopt, nil)
+	p.stripBOM = true
+	useNumber, disallowUnknownFields := p.useNumber, p.disallowUnknownFields
+	p.parseBody = func(resp *http.Response) (interface{}, error) {
+		defer drainAndClose(resp.Body)
+		dec := json.NewDecoder(resp.Body)
+		if useNumber {
+			dec.UseNumber()
+		}
+		if disallowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
+		return nil, f(dec)
+	}
+	return p
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+/*
+JSONArray is a Parser function that decodes a top-level JSON array element
+by element, calling f for each one, so that memory usage doesn't grow with
+the size of the response. f must be a function like func(item T) error,
+where T is any type you'd pass (a pointer to) into json.Unmarshal:
+
+    err := httpsimp.Do(req, client, httpsimp.JSONArray(func(item *Item) error {
+        return process(item)
+    }))
+
+A null body is treated as an empty array. Decoding stops at the first error
+returned by f, which is then reported as a decoding error in *ResponseError.
+JSONArray panics if f has a wrong signature.
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func JSONArray(f interface{}, mopt ...ParseOption) Parser {
+	fv := reflect.ValueOf(f)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.NumOut() != 1 || ft.Out(0) != errorType {
+		panic(fmt.Sprintf("httpsimp.JSONArray: f must be func(T) error, got %T", f))
+	}
+	elemType := ft.In(0)
+
+	return JSONStream(func(dec *json.Decoder) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == nil {
+			return nil
+		}
+		if tok != json.Delim('[') {
+			return errors.New("expected a JSON array")
+		}
+		for dec.More() {
+			elem := reflect.New(elemType)
+			if err := dec.Decode(elem.Interface()); err != nil {
+				return err
+			}
+			if err, _ := fv.Call([]reflect.Value{elem.Elem()})[0].Interface().(error); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	}, mopt...)
+}
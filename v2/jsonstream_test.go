@@ -0,0 +1,91 @@
+package httpsimp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestJSONArray(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+	var ids []int
+	err := get(http.StatusOK, ContentTypeJSON, []byte(`[{"id": 1}, {"id": 2}, {"id": 3}]`), JSONArray(func(it *item) error {
+		ids = append(ids, it.ID)
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []int{1, 2, 3}) {
+		t.Errorf("got %v", ids)
+	}
+
+	var count int
+	err = get(http.StatusOK, ContentTypeJSON, []byte(`null`), JSONArray(func(it item) error {
+		count++
+		return nil
+	}))
+	if err != nil || count != 0 {
+		t.Errorf("null: %d, %v", count, err)
+	}
+
+	err = get(http.StatusOK, ContentTypeJSON, []byte(`{"id": 1}`), JSONArray(func(it item) error { return nil }))
+	if err == nil {
+		t.Errorf("expected an error for a non-array body")
+	}
+}
+
+func TestJSONArrayStopsOnError(t *testing.T) {
+	errStop := errors.New("stop")
+	var seen []string
+	err := get(http.StatusOK, ContentTypeJSON, []byte(`["a", "b", "c"]`), JSONArray(func(s string) error {
+		seen = append(seen, s)
+		if s == "b" {
+			return errStop
+		}
+		return nil
+	}))
+	if !errors.Is(err, errStop) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(seen) != 2 {
+		t.Errorf("seen %v", seen)
+	}
+}
+
+func TestJSONArrayPanicsOnBadFunc(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic")
+		}
+	}()
+	JSONArray(func(s string) {})
+}
+
+func TestJSONStream(t *testing.T) {
+	var keys []string
+	err := get(http.StatusOK, ContentTypeJSON, []byte(`{"a": 1, "b": 2}`), JSONStream(func(dec *json.Decoder) error {
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if s, ok := tok.(string); ok {
+				keys = append(keys, s)
+			}
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("got %v", keys)
+	}
+}
@@ -0,0 +1,89 @@
+package httpsimp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+)
+
+/*
+LoginFlow describes a scripted login performed by Client.Login: a request
+posting the credentials, and where to find the resulting credential.
+
+Session cookies set by the login response are always captured (Login
+installs a cookie jar if the client has none). To capture a token instead,
+set TokenPath or TokenHeader.
+*/
+type LoginFlow struct {
+	// Request builds the login request, e.g. using MakeForm or MakeJSON.
+	// It is called on every Login, so the credentials can be re-read.
+	Request func() *http.Request
+
+	// TokenPath is a JSONPath-style path to the token in a JSON response,
+	// like "data.access_token".
+	TokenPath string
+
+	// TokenHeader is the name of a response header carrying the token.
+	TokenHeader string
+
+	// UseToken returns the client option applying the captured token to
+	// subsequent requests. The default is BearerToken.
+	UseToken func(token string) ClientOption
+}
+
+/*
+Login performs the given login flow and configures the client to send
+the captured session cookie or token with all subsequent requests:
+
+    err := client.Login(httpsimp.LoginFlow{
+        Request: func() *http.Request {
+            return httpsimp.MakeForm(http.MethodPost, baseURL, "/login", url.Values{
+                "username": {user},
+                "password": {password},
+            }, nil)
+        },
+        TokenPath: "token",
+    })
+
+The given parsers handle error responses, e.g. to decode a failure reason;
+a login response that is not 2xx results in an error anyway. Call Login again
+when the session expires.
+*/
+func (c *Client) Login(flow LoginFlow, parsers ...Parser) error {
+	if c.load().config.jar == nil {
+		jar, _ := cookiejar.New(nil)
+		c.Update(Jar(jar))
+	}
+
+	var token string
+	var header http.Header
+	var main Parser
+	if flow.TokenPath != "" {
+		main = JSONPath(flow.TokenPath, &token)
+	} else {
+		main = None()
+	}
+	all := append([]Parser{main, CaptureHeader(&header)}, parsers...)
+	if err := Do(flow.Request(), c, all...); err != nil {
+		return err
+	}
+
+	if flow.TokenHeader != "" {
+		token = header.Get(flow.TokenHeader)
+	}
+	if flow.TokenPath == "" && flow.TokenHeader == "" {
+		return nil
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return errors.New("login: no token in the response")
+	}
+
+	use := flow.UseToken
+	if use == nil {
+		use = BearerToken
+	}
+	c.Update(use(token))
+	return nil
+}
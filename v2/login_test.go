@@ -0,0 +1,74 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestLogin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		switch r.URL.Path {
+		case "/login":
+			if r.FormValue("password") != "pw" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"bad password"}`))
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+			w.Header().Set("X-Auth-Token", "h1")
+			w.Write([]byte(`{"data":{"token":"t1"}}`))
+		default:
+			cookie, _ := r.Cookie("session")
+			value := ""
+			if cookie != nil {
+				value = cookie.Value
+			}
+			w.Write([]byte(`{"cookie":"` + value + `","auth":"` + r.Header.Get("Authorization") + `"}`))
+		}
+	}))
+	defer srv.Close()
+
+	login := func(pw string) func() *http.Request {
+		return func() *http.Request {
+			return MakeForm(http.MethodPost, srv.URL, "/login", url.Values{"password": {pw}}, nil)
+		}
+	}
+	whoami := func(client *Client) map[string]string {
+		var result map[string]string
+		if err := Do(MakeGet(srv.URL, "/me", nil, nil), client, JSON(&result)); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	client := NewClient()
+	if err := client.Login(LoginFlow{Request: login("pw"), TokenPath: "data.token"}); err != nil {
+		t.Fatal(err)
+	}
+	if r := whoami(client); r["cookie"] != "s1" || r["auth"] != "Bearer t1" {
+		t.Errorf("after login: %v", r)
+	}
+
+	client = NewClient()
+	err := client.Login(LoginFlow{
+		Request:     login("pw"),
+		TokenHeader: "X-Auth-Token",
+		UseToken: func(token string) ClientOption {
+			return BearerToken("header-" + token)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := whoami(client); r["auth"] != "Bearer header-h1" {
+		t.Errorf("after header login: %v", r)
+	}
+
+	client = NewClient()
+	if err := client.Login(LoginFlow{Request: login("wrong")}); StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package httpsimp
 
 import (
+	"io"
 	"net/http"
 	"net/url"
 )
@@ -11,17 +12,122 @@ MakeGet builds a GET request with the given URL, headers and params
 
 base and path are concatenated to form a URL; at least one of them must be
 provided, but the other one can be an empty string. The resulting URL must be
-valid and parsable via net/url, otherwise panic ensues.
+valid and parsable via net/url, otherwise Do returns a *BuildError.
 
 url.Values and http.Header are just maps that can be provided in place,
 no need to use their fancy Set or Add methods.
 */
 func MakeGet(base, path string, params url.Values, headers http.Header) *http.Request {
-	return &http.Request{
-		Method: http.MethodGet,
-		URL:    URL(base, path, params),
-		Header: headers,
-	}
+	return makeRequest(http.MethodGet, base, path, params, headers)
+}
+
+/*
+MakeDelete builds a DELETE request with the given URL, headers and params
+(encoded into a query string), and without a body.
+
+base and path are concatenated to form a URL; at least one of them must be
+provided, but the other one can be an empty string. The resulting URL must be
+valid and parsable via net/url, otherwise Do returns a *BuildError.
+
+url.Values and http.Header are just maps that can be provided in place,
+no need to use their fancy Set or Add methods.
+*/
+func MakeDelete(base, path string, params url.Values, headers http.Header) *http.Request {
+	return makeRequest(http.MethodDelete, base, path, params, headers)
+}
+
+/*
+MakeHead builds a HEAD request with the given URL, headers and params
+(encoded into a query string), and without a body.
+
+base and path are concatenated to form a URL; at least one of them must be
+provided, but the other one can be an empty string. The resulting URL must be
+valid and parsable via net/url, otherwise Do returns a *BuildError.
+
+url.Values and http.Header are just maps that can be provided in place,
+no need to use their fancy Set or Add methods.
+*/
+func MakeHead(base, path string, params url.Values, headers http.Header) *http.Request {
+	return makeRequest(http.MethodHead, base, path, params, headers)
+}
+
+/*
+MakeOptions builds a OPTIONS request with the given URL, headers and params
+(encoded into a query string), and without a body.
+
+base and path are concatenated to form a URL; at least one of them must be
+provided, but the other one can be an empty string. The resulting URL must be
+valid and parsable via net/url, otherwise Do returns a *BuildError.
+
+url.Values and http.Header are just maps that can be provided in place,
+no need to use their fancy Set or Add methods.
+*/
+func MakeOptions(base, path string, params url.Values, headers http.Header) *http.Request {
+	return makeRequest(http.MethodOptions, base, path, params, headers)
+}
+
+/*
+MakePatchJSON builds a PATCH request with the given URL, headers and body
+(which contains the given object encoded in JSON format).
+
+base and path are concatenated to form a URL; at least one of them must be
+provided, but the other one can be an empty string. The resulting URL must be
+valid and parsable via net/url, otherwise Do returns a *BuildError.
+
+url.Values and http.Header are just maps that can be provided in place,
+no need to use their fancy Set or Add methods.
+
+If JSON encoding fails, Do returns a *BuildError.
+*/
+func MakePatchJSON(base, path string, params url.Values, obj interface{}, headers http.Header) *http.Request {
+	return MakeJSON(http.MethodPatch, base, path, params, obj, headers)
+}
+
+/*
+MakePatchForm builds a PATCH request with the given URL, headers and body
+(which contains the given params in application/x-www-form-urlencoded format).
+
+base and path are concatenated to form a URL; at least one of them must be
+provided, but the other one can be an empty string. The resulting URL must be
+valid and parsable via net/url, otherwise Do returns a *BuildError.
+
+url.Values and http.Header are just maps that can be provided in place,
+no need to use their fancy Set or Add methods.
+*/
+func MakePatchForm(base, path string, params url.Values, headers http.Header) *http.Request {
+	return MakeForm(http.MethodPatch, base, path, params, headers)
+}
+
+/*
+MakeDeleteJSON builds a DELETE request with the given URL, headers and body
+(which contains the given object encoded in JSON format).
+
+base and path are concatenated to form a URL; at least one of them must be
+provided, but the other one can be an empty string. The resulting URL must be
+valid and parsable via net/url, otherwise Do returns a *BuildError.
+
+url.Values and http.Header are just maps that can be provided in place,
+no need to use their fancy Set or Add methods.
+
+If JSON encoding fails, Do returns a *BuildError.
+*/
+func MakeDeleteJSON(base, path string, params url.Values, obj interface{}, headers http.Header) *http.Request {
+	return MakeJSON(http.MethodDelete, base, path, params, obj, headers)
+}
+
+/*
+MakeDeleteForm builds a DELETE request with the given URL, headers and body
+(which contains the given params in application/x-www-form-urlencoded format).
+
+base and path are concatenated to form a URL; at least one of them must be
+provided, but the other one can be an empty string. The resulting URL must be
+valid and parsable via net/url, otherwise Do returns a *BuildError.
+
+url.Values and http.Header are just maps that can be provided in place,
+no need to use their fancy Set or Add methods.
+*/
+func MakeDeleteForm(base, path string, params url.Values, headers http.Header) *http.Request {
+	return MakeForm(http.MethodDelete, base, path, params, headers)
 }
 
 /*
@@ -30,17 +136,13 @@ MakeForm builds a POST/PUT/etc request with the given URL, headers and body
 
 base and path are concatenated to form a URL; at least one of them must be
 provided, but the other one can be an empty string. The resulting URL must be
-valid and parsable via net/url, otherwise panic ensues.
+valid and parsable via net/url, otherwise Do returns a *BuildError.
 
 url.Values and http.Header are just maps that can be provided in place,
 no need to use their fancy Set or Add methods.
 */
 func MakeForm(method string, base, path string, params url.Values, headers http.Header) *http.Request {
-	return EncodeForm(&http.Request{
-		Method: method,
-		URL:    URL(base, path, nil),
-		Header: headers,
-	}, params)
+	return EncodeForm(makeRequest(method, base, path, nil, headers), params)
 }
 
 /*
@@ -49,19 +151,15 @@ MakeJSON builds a POST/PUT/etc request with the given URL, headers and body
 
 base and path are concatenated to form a URL; at least one of them must be
 provided, but the other one can be an empty string. The resulting URL must be
-valid and parsable via net/url, otherwise panic ensues.
+valid and parsable via net/url, otherwise Do returns a *BuildError.
 
 url.Values and http.Header are just maps that can be provided in place,
 no need to use their fancy Set or Add methods.
 
-If JSON encoding fails, the method panics.
+If JSON encoding fails, Do returns a *BuildError.
 */
 func MakeJSON(method string, base, path string, params url.Values, obj interface{}, headers http.Header) *http.Request {
-	return EncodeJSONBody(&http.Request{
-		Method: method,
-		URL:    URL(base, path, params),
-		Header: headers,
-	}, obj)
+	return EncodeJSONBody(makeRequest(method, base, path, params, headers), obj)
 }
 
 /*
@@ -69,15 +167,100 @@ Make builds a POST/PUT/etc request with the given URL, headers and body.
 
 base and path are concatenated to form a URL; at least one of them must be
 provided, but the other one can be an empty string. The resulting URL must be
-valid and parsable via net/url, otherwise panic ensues.
+valid and parsable via net/url, otherwise Do returns a *BuildError.
 
 url.Values and http.Header are just maps that can be provided in place,
 no need to use their fancy Set or Add methods.
 */
 func Make(method string, base, path string, params url.Values, body []byte, headers http.Header) *http.Request {
-	return SetBody(&http.Request{
+	return SetBody(makeRequest(method, base, path, params, headers), body)
+}
+
+/*
+MakeStream builds a POST/PUT/etc request with the given URL and headers,
+streaming the body from the given reader instead of buffering it in memory.
+See SetBodyReader for the meaning of contentLength and handling of redirects.
+
+base and path are concatenated to form a URL; at least one of them must be
+provided, but the other one can be an empty string. The resulting URL must be
+valid and parsable via net/url, otherwise Do returns a *BuildError.
+
+url.Values and http.Header are just maps that can be provided in place,
+no need to use their fancy Set or Add methods.
+*/
+func MakeStream(method string, base, path string, params url.Values, body io.Reader, contentLength int64, headers http.Header) *http.Request {
+	return SetBodyReader(makeRequest(method, base, path, params, headers), body, contentLength)
+}
+
+/*
+MakeGetE is like MakeGet, but returns the *BuildError right away instead of
+leaving it for Do to report. Use the E variants when the URL comes from
+configuration and should be validated early.
+*/
+func MakeGetE(base, path string, params url.Values, headers http.Header) (*http.Request, error) {
+	return checkBuilt(MakeGet(base, path, params, headers))
+}
+
+/*
+MakeDeleteE is like MakeDelete, but returns the *BuildError right away.
+*/
+func MakeDeleteE(base, path string, params url.Values, headers http.Header) (*http.Request, error) {
+	return checkBuilt(MakeDelete(base, path, params, headers))
+}
+
+/*
+MakeHeadE is like MakeHead, but returns the *BuildError right away.
+*/
+func MakeHeadE(base, path string, params url.Values, headers http.Header) (*http.Request, error) {
+	return checkBuilt(MakeHead(base, path, params, headers))
+}
+
+/*
+MakeOptionsE is like MakeOptions, but returns the *BuildError right away.
+*/
+func MakeOptionsE(base, path string, params url.Values, headers http.Header) (*http.Request, error) {
+	return checkBuilt(MakeOptions(base, path, params, headers))
+}
+
+/*
+MakeFormE is like MakeForm, but returns the *BuildError right away.
+*/
+func MakeFormE(method string, base, path string, params url.Values, headers http.Header) (*http.Request, error) {
+	return checkBuilt(MakeForm(method, base, path, params, headers))
+}
+
+/*
+MakeJSONE is like MakeJSON, but returns the *BuildError right away,
+including JSON encoding failures.
+*/
+func MakeJSONE(method string, base, path string, params url.Values, obj interface{}, headers http.Header) (*http.Request, error) {
+	return checkBuilt(MakeJSON(method, base, path, params, obj, headers))
+}
+
+/*
+MakeE is like Make, but returns the *BuildError right away.
+*/
+func MakeE(method string, base, path string, params url.Values, body []byte, headers http.Header) (*http.Request, error) {
+	return checkBuilt(Make(method, base, path, params, body, headers))
+}
+
+func checkBuilt(r *http.Request) (*http.Request, error) {
+	if be := requestBuildError(r); be != nil {
+		return nil, be
+	}
+	return r, nil
+}
+
+func makeRequest(method string, base, path string, params url.Values, headers http.Header) *http.Request {
+	u, err := buildURL(base, path, params)
+	r := &http.Request{
 		Method: method,
-		URL:    URL(base, path, params),
+		URL:    u,
 		Header: headers,
-	}, body)
+	}
+	if err != nil {
+		r.URL = &url.URL{}
+		setBuildError(r, err)
+	}
+	return r
 }
@@ -0,0 +1,73 @@
+package httpsimp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMakeMethods(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Header().Set("X-Echo", r.Method+" "+r.URL.RequestURI()+" "+r.Header.Get("Content-Type")+" "+string(body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	params := url.Values{"q": {"1"}}
+	tests := []struct {
+		req      *http.Request
+		expected string
+	}{
+		{MakeDelete(srv.URL, "/x", params, nil), "DELETE /x?q=1  "},
+		{MakeHead(srv.URL, "/x", params, nil), "HEAD /x?q=1  "},
+		{MakeOptions(srv.URL, "/x", nil, nil), "OPTIONS /x  "},
+		{MakePatchJSON(srv.URL, "/x", nil, map[string]int{"a": 1}, nil), `PATCH /x application/json {"a":1}`},
+		{MakePatchForm(srv.URL, "/x", params, nil), "PATCH /x application/x-www-form-urlencoded q=1"},
+		{MakeDeleteJSON(srv.URL, "/x", nil, []int{1, 2}, nil), "DELETE /x application/json [1,2]"},
+		{MakeDeleteForm(srv.URL, "/x", params, nil), "DELETE /x application/x-www-form-urlencoded q=1"},
+	}
+	for _, tt := range tests {
+		var h http.Header
+		err := Do(tt.req, http.DefaultClient, None(), CaptureHeader(&h))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := h.Get("X-Echo"); actual != strings.TrimSpace(tt.expected) {
+			t.Errorf("got %q, wanted %q", actual, tt.expected)
+		}
+	}
+}
+
+func TestMakeE(t *testing.T) {
+	if _, err := URLE("://bad", "/x", nil); err == nil {
+		t.Error("URLE: expected an error")
+	} else if _, ok := err.(*BuildError); !ok {
+		t.Errorf("URLE: expected *BuildError, got %T", err)
+	}
+	if u, err := URLE("https://example.com", "/x", url.Values{"q": {"1"}}); err != nil || u.String() != "https://example.com/x?q=1" {
+		t.Errorf("URLE = %v, %v", u, err)
+	}
+
+	r, err := MakeGetE("://bad", "/x", nil, nil)
+	if r != nil || err == nil {
+		t.Errorf("MakeGetE = %v, %v, wanted an error", r, err)
+	}
+	_, err = MakeJSONE(http.MethodPost, "https://example.com", "/x", nil, func() {}, nil)
+	if _, ok := err.(*BuildError); !ok {
+		t.Errorf("MakeJSONE: expected *BuildError, got %v", err)
+	}
+	_, err = MakeGetE("https://example.com", "/x", nil, http.Header{"X-Bad": {"a\nb"}})
+	if _, ok := err.(*BuildError); !ok {
+		t.Errorf("MakeGetE with invalid header: expected *BuildError, got %v", err)
+	}
+
+	r, err = MakeFormE(http.MethodPost, "https://example.com", "/x", url.Values{"a": {"1"}}, nil)
+	if err != nil || r.URL.String() != "https://example.com/x" {
+		t.Errorf("MakeFormE = %v, %v", r, err)
+	}
+}
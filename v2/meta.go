@@ -0,0 +1,126 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+/*
+ResponseMeta describes a response apart from its body. See Meta.
+*/
+type ResponseMeta struct {
+	StatusCode    int
+	Status        string // e.g. "200 OK"
+	Proto         string // e.g. "HTTP/1.1"
+	Header        http.Header
+	ContentLength int64 // -1 if unknown
+
+	// URL is the final URL of the request, after following redirects.
+	URL *url.URL
+
+	// RequestID is the X-Request-Id header of the request, if any
+	// (see RequestID).
+	RequestID string
+
+	// Start is the time the request was sent by Do.
+	Start time.Time
+
+	// Latency is the time it took Do to receive the response headers.
+	Latency time.Duration
+
+	// Duration is the wall time of the entire Do call, including reading
+	// and parsing the body. It is set once Do returns.
+	Duration time.Duration
+}
+
+/*
+Meta returns a pseudo-parser that stores the status, headers, final URL and
+timing of the response into m, no matter which parser handles the body
+(or whether parsing succeeds):
+
+    var meta httpsimp.ResponseMeta
+    err := httpsimp.Do(r, client, httpsimp.JSON(&resp), httpsimp.Meta(&meta))
+    log.Printf("%s in %v", meta.Status, meta.Duration)
+
+Timing is only available when the response is obtained via Do. For failed
+calls, use ElapsedOf on the returned error.
+*/
+func Meta(m *ResponseMeta) Parser {
+	return Parser{
+		wantsCallInfo: true,
+		observe: func(resp *http.Response) error {
+			*m = ResponseMeta{
+				StatusCode:    resp.StatusCode,
+				Status:        resp.Status,
+				Proto:         resp.Proto,
+				Header:        resp.Header,
+				ContentLength: resp.ContentLength,
+			}
+			if resp.Request != nil {
+				m.URL = resp.Request.URL
+				m.RequestID = resp.Request.Header.Get(RequestIDHeader)
+				if ci, ok := resp.Request.Context().Value(callInfoKey{}).(*callInfo); ok {
+					m.Start = ci.start
+					m.Latency = ci.latency
+					ci.metas = append(ci.metas, m)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+/*
+CaptureHeader returns a pseudo-parser that stores the response header map
+into h, no matter which parser handles the body:
+
+    var h http.Header
+    err := httpsimp.Do(r, client, httpsimp.JSON(&resp), httpsimp.CaptureHeader(&h))
+    requestID := h.Get("X-Request-Id")
+*/
+func CaptureHeader(h *http.Header) Parser {
+	return Parser{
+		observe: func(resp *http.Response) error {
+			*h = resp.Header
+			return nil
+		},
+	}
+}
+
+/*
+CaptureStatus returns a pseudo-parser that stores the response status code
+into code, no matter which parser handles the body.
+*/
+func CaptureStatus(code *int) Parser {
+	return Parser{
+		observe: func(resp *http.Response) error {
+			*code = resp.StatusCode
+			return nil
+		},
+	}
+}
+
+type callInfoKey struct{}
+
+// callInfo is attached to the response by Do for use by parsers.
+type callInfo struct {
+	start   time.Time
+	latency time.Duration
+	metas   []*ResponseMeta // filled in by Meta, updated by finish
+}
+
+func (ci *callInfo) finish() {
+	d := time.Since(ci.start)
+	for _, m := range ci.metas {
+		m.Duration = d
+	}
+}
+
+func attachCallInfo(resp *http.Response, r *http.Request, ci *callInfo) {
+	if resp.Request == nil {
+		resp.Request = r
+	}
+	resp.Request = resp.Request.WithContext(context.WithValue(resp.Request.Context(), callInfoKey{}, ci))
+}
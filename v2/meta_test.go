@@ -0,0 +1,72 @@
+package httpsimp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMeta(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Header().Set("X-Request-Id", "abc")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"foo": 42}`))
+	}))
+	defer srv.Close()
+
+	var resp struct {
+		Foo int `json:"foo"`
+	}
+	var meta ResponseMeta
+	err := Do(MakeGet(srv.URL, "/old", nil, nil), http.DefaultClient, JSON(&resp), Meta(&meta))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Foo != 42 {
+		t.Fatalf("invalid value of Foo: %v", resp.Foo)
+	}
+	if meta.StatusCode != http.StatusCreated || meta.Header.Get("X-Request-Id") != "abc" || meta.Proto != "HTTP/1.1" {
+		t.Fatalf("invalid meta: %+v", meta)
+	}
+	if meta.URL.Path != "/new" || meta.Start.IsZero() || meta.Latency <= 0 || meta.Duration < meta.Latency {
+		t.Fatalf("invalid meta: %+v", meta)
+	}
+}
+
+func TestCaptureHeaderAndStatus(t *testing.T) {
+	var h http.Header
+	var code int
+	err := get(http.StatusNotFound, ContentTypeTextPlain, []byte("not found"), None(), CaptureHeader(&h), CaptureStatus(&code))
+	if StatusCode(err) != http.StatusNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != http.StatusNotFound || h.Get("Content-Type") != ContentTypeTextPlain {
+		t.Fatalf("code = %d, h = %v", code, h)
+	}
+}
+
+func TestElapsedOf(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	err := Do(MakeGet(srv.URL, "", nil, nil), http.DefaultClient, None())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if d := ElapsedOf(err); d < 5*time.Millisecond {
+		t.Errorf("ElapsedOf = %v", d)
+	}
+	if d := ElapsedOf(io.EOF); d != 0 {
+		t.Errorf("ElapsedOf(io.EOF) = %v", d)
+	}
+}
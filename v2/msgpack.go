@@ -0,0 +1,47 @@
+package httpsimp
+
+import (
+	"net/http"
+)
+
+// contentTypeXMsgpack is the legacy MessagePack media type still used by many servers.
+const contentTypeXMsgpack = "application/x-msgpack"
+
+/*
+MsgpackCodec is the Codec used by EncodeMsgpackBody and Msgpack. It is nil
+by default to keep this package free of dependencies; set it once during
+initialization, e.g. using github.com/vmihailenco/msgpack:
+
+    httpsimp.MsgpackCodec = httpsimp.CodecFuncs{msgpack.Marshal, msgpack.Unmarshal}
+*/
+var MsgpackCodec Codec
+
+/*
+EncodeMsgpackBody encodes the given object into MessagePack format using
+MsgpackCodec and sets the body and Content-Type (application/msgpack)
+on the given request.
+
+If encoding fails, Do returns a *BuildError without sending the request.
+
+To properly handle HTTP redirects, both Body and GetBody are set.
+*/
+func EncodeMsgpackBody(r *http.Request, obj interface{}) *http.Request {
+	return EncodeBody(r, MsgpackCodec, ContentTypeMsgpack, obj)
+}
+
+/*
+Msgpack is a Parser function that verifies the response status code and
+content type (which must be ContentTypeMsgpack or the legacy
+application/x-msgpack) and unmarshals the body into the result variable
+using MsgpackCodec.
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func Msgpack(result interface{}, mopt ...ParseOption) Parser {
+	p := MakeParser(ContentTypeMsgpack, nil, codecBodyParser(result, func() Codec { return MsgpackCodec }))
+	p.ctypeAliases = []string{contentTypeXMsgpack}
+	for _, o := range mopt {
+		o.applyToParser(&p)
+	}
+	return p
+}
@@ -0,0 +1,73 @@
+package httpsimp
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// gobCodec stands in for a real MessagePack implementation.
+var gobCodec = CodecFuncs{
+	MarshalFunc: func(v interface{}) ([]byte, error) {
+		var buf bytes.Buffer
+		err := gob.NewEncoder(&buf).Encode(v)
+		return buf.Bytes(), err
+	},
+	UnmarshalFunc: func(data []byte, v interface{}) error {
+		return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+	},
+}
+
+func withMsgpackCodec(t *testing.T, c Codec) {
+	old := MsgpackCodec
+	MsgpackCodec = c
+	t.Cleanup(func() { MsgpackCodec = old })
+}
+
+type msgpackPoint struct {
+	X, Y int
+}
+
+func TestMsgpack(t *testing.T) {
+	withMsgpackCodec(t, gobCodec)
+
+	r := EncodeMsgpackBody(&http.Request{}, msgpackPoint{1, 2})
+	if ct := r.Header.Get("Content-Type"); ct != ContentTypeMsgpack {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+
+	for _, ctype := range []string{ContentTypeMsgpack, "application/x-msgpack"} {
+		var p msgpackPoint
+		err := get(200, ctype, body, Msgpack(&p))
+		if err != nil {
+			t.Fatalf("%s: %v", ctype, err)
+		}
+		if p != (msgpackPoint{1, 2}) {
+			t.Errorf("%s: got %+v", ctype, p)
+		}
+	}
+
+	var p msgpackPoint
+	err := get(200, ContentTypeJSON, []byte(`{}`), Msgpack(&p))
+	if err == nil {
+		t.Errorf("expected content type mismatch error")
+	}
+}
+
+func TestMsgpackNoCodec(t *testing.T) {
+	withMsgpackCodec(t, nil)
+
+	r := EncodeMsgpackBody(&http.Request{}, 42)
+	if err := requestBuildError(r); err == nil {
+		t.Errorf("expected a build error")
+	}
+
+	var v int
+	err := get(200, ContentTypeMsgpack, []byte{0x2a}, Msgpack(&v))
+	if err == nil {
+		t.Errorf("expected an error")
+	}
+}
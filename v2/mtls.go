@@ -0,0 +1,83 @@
+package httpsimp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+/*
+NewMTLSClient returns an *http.Client authenticating with the client
+certificate and key from the given PEM files (mutual TLS), with the given
+overall timeout. If caFile is not empty, server certificates must be signed
+by the CAs from that PEM file (instead of the system roots).
+
+To use mutual TLS with only some hosts of a Client, pass the result of
+MTLSConfig to HostTLSConfig instead.
+*/
+func NewMTLSClient(certFile, keyFile, caFile string, timeout time.Duration) (*http.Client, error) {
+	cfg, err := MTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewTLSClient(cfg, timeout), nil
+}
+
+/*
+NewMTLSClientFromPEM is like NewMTLSClient, but accepts the PEM data directly,
+e.g. when the certificates come from a secret store. caPEM can be nil.
+*/
+func NewMTLSClientFromPEM(certPEM, keyPEM, caPEM []byte, timeout time.Duration) (*http.Client, error) {
+	cfg, err := MTLSConfigFromPEM(certPEM, keyPEM, caPEM)
+	if err != nil {
+		return nil, err
+	}
+	return NewTLSClient(cfg, timeout), nil
+}
+
+/*
+MTLSConfig returns a TLS configuration for mutual TLS, see NewMTLSClient.
+*/
+func MTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	certPEM, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	var caPEM []byte
+	if caFile != "" {
+		caPEM, err = ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return MTLSConfigFromPEM(certPEM, keyPEM, caPEM)
+}
+
+/*
+MTLSConfigFromPEM is like MTLSConfig, but accepts the PEM data directly.
+*/
+func MTLSConfigFromPEM(certPEM, keyPEM, caPEM []byte) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caPEM != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no CA certificates found in PEM data")
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
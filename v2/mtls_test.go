@@ -0,0 +1,133 @@
+package httpsimp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert            *x509.Certificate
+	key             *ecdsa.PrivateKey
+	certPEM, keyPEM []byte
+}
+
+// newTestCert issues a certificate signed by parent (self-signed CA if nil).
+func newTestCert(t *testing.T, parent *testCert, client bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+		tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+		if client {
+			tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		} else {
+			tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+			tmpl.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// newTLSTestServer starts a server with a certificate issued by ca,
+// requiring client certificates issued by ca if clientAuth is set.
+func newTLSTestServer(t *testing.T, ca *testCert, clientAuth bool) *httptest.Server {
+	serverCert := newTestCert(t, ca, false)
+	pair, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		if len(r.TLS.PeerCertificates) > 0 {
+			w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+		}
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{pair}}
+	if clientAuth {
+		pool := x509.NewCertPool()
+		pool.AddCert(ca.cert)
+		srv.TLS.ClientCAs = pool
+		srv.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	srv.StartTLS()
+	return srv
+}
+
+func TestMTLSClient(t *testing.T) {
+	ca := newTestCert(t, nil, false)
+	clientCert := newTestCert(t, ca, true)
+	srv := newTLSTestServer(t, ca, true)
+	defer srv.Close()
+
+	client, err := NewMTLSClientFromPEM(clientCert.certPEM, clientCert.keyPEM, ca.certPEM, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text string
+	if err := Do(MakeGet(srv.URL, "", nil, nil), client, PlainText(&text)); err != nil {
+		t.Fatal(err)
+	}
+	if text != "test" {
+		t.Errorf("server saw client %q", text)
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{"cert.pem": clientCert.certPEM, "key.pem": clientCert.keyPEM, "ca.pem": ca.certPEM}
+	for name, data := range files {
+		os.WriteFile(filepath.Join(dir, name), data, 0600)
+	}
+	client, err = NewMTLSClient(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem"), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Do(MakeGet(srv.URL, "", nil, nil), client, PlainText(&text)); err != nil {
+		t.Fatal(err)
+	}
+
+	noCert, _ := NewMTLSClientFromPEM(clientCert.certPEM, clientCert.keyPEM, nil, 5*time.Second)
+	if err := Do(MakeGet(srv.URL, "", nil, nil), noCert, None()); err == nil {
+		t.Error("expected an error without the server CA")
+	}
+
+	if _, err := NewMTLSClientFromPEM(clientCert.certPEM, clientCert.keyPEM, []byte("junk"), 0); err == nil {
+		t.Error("expected an error for a bad CA bundle")
+	}
+}
@@ -0,0 +1,83 @@
+package httpsimp
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+/*
+Multipart is a Parser function that handles multipart responses, like
+multipart/mixed bodies returned by batch APIs or multipart/related ones
+returned by MTOM services. It calls f for each part in order, passing it as
+an *http.Response with the headers of the part (and the status of the whole
+response), so that it can be dispatched to nested parsers based on its own
+content type via Parse:
+
+    var items []Item
+    var notes []string
+    err := httpsimp.Do(r, client, httpsimp.Multipart(func(i int, part *http.Response) error {
+        var item Item
+        var note string
+        if err := httpsimp.Parse(part, httpsimp.JSON(&item), httpsimp.PlainText(&note)); err != nil {
+            return fmt.Errorf("part %d: %w", i, err)
+        }
+        if note != "" {
+            notes = append(notes, note)
+        } else {
+            items = append(items, item)
+        }
+        return nil
+    }))
+
+The parser matches any multipart/* content type by default. The part's body
+is only valid until f returns; parts not read by f are skipped.
+An error returned by f stops the iteration and is reported as
+the decoding error.
+*/
+func Multipart(f func(index int, part *http.Response) error, mopt ...ParseOption) Parser {
+	return MakeParser("multipart/*", mopt, func(resp *http.Response) (interface{}, error) {
+		defer drainAndClose(resp.Body)
+		_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if err != nil {
+			return nil, err
+		}
+		boundary := params["boundary"]
+		if boundary == "" {
+			return nil, fmt.Errorf("multipart response has no boundary")
+		}
+
+		mr := multipart.NewReader(resp.Body, boundary)
+		for i := 0; ; i++ {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return nil, nil
+			} else if err != nil {
+				return nil, err
+			}
+			part := &http.Response{
+				Status:        resp.Status,
+				StatusCode:    resp.StatusCode,
+				Proto:         resp.Proto,
+				ProtoMajor:    resp.ProtoMajor,
+				ProtoMinor:    resp.ProtoMinor,
+				Header:        http.Header(p.Header),
+				Body:          ioutil.NopCloser(p),
+				ContentLength: -1,
+				Request:       resp.Request,
+			}
+			if n, err := strconv.ParseInt(p.Header.Get("Content-Length"), 10, 64); err == nil {
+				part.ContentLength = n
+			}
+			err = f(i, part)
+			p.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package httpsimp
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"testing"
+)
+
+func TestMultipart(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, p := range []struct{ ctype, body string }{
+		{ContentTypeJSON, `{"foo": 1}`},
+		{ContentTypeTextPlain, "hello"},
+		{ContentTypeJSON, `{"foo": 2}`},
+	} {
+		w, _ := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {p.ctype}})
+		w.Write([]byte(p.body))
+	}
+	mw.Close()
+
+	type item struct {
+		Foo int `json:"foo"`
+	}
+	var items []item
+	var texts []string
+	var indices []int
+	err := get(http.StatusOK, "multipart/mixed; boundary="+mw.Boundary(), buf.Bytes(), Multipart(func(i int, part *http.Response) error {
+		indices = append(indices, i)
+		var it item
+		var s string
+		if err := Parse(part, JSON(&it), PlainText(&s)); err != nil {
+			return err
+		}
+		if s != "" {
+			texts = append(texts, s)
+		} else {
+			items = append(items, it)
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indices) != 3 || len(items) != 2 || items[0].Foo != 1 || items[1].Foo != 2 || len(texts) != 1 || texts[0] != "hello" {
+		t.Errorf("indices = %v, items = %v, texts = %q", indices, items, texts)
+	}
+}
+
+func TestMultipartPartError(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	w, _ := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"image/png"}})
+	w.Write([]byte("..."))
+	mw.Close()
+
+	err := get(http.StatusOK, "multipart/related; boundary="+mw.Boundary(), buf.Bytes(), Multipart(func(i int, part *http.Response) error {
+		var v interface{}
+		return Parse(part, JSON(&v))
+	}))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+FileStore is a Store keeping each message in a separate JSON file in
+a directory. Files are written atomically, so a crash never leaves a
+half-written message behind. It suits moderate volumes; Due reads the
+entire directory.
+*/
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+/*
+NewFileStore returns a FileStore keeping messages in the given directory,
+which is created if needed.
+*/
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+const fileExt = ".json"
+
+// Put implements Store.
+func (s *FileStore) Put(m *Message) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := ioutil.TempFile(s.dir, m.ID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.path(m.ID))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.path(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Due implements Store.
+func (s *FileStore) Due(now time.Time, limit int) ([]*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var due []*Message
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileExt) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		m := new(Message)
+		if err := json.Unmarshal(data, m); err != nil {
+			return nil, err
+		}
+		if !m.NextAttempt.After(now) {
+			due = append(due, m)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].Created.Before(due[j].Created)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+fileExt)
+}
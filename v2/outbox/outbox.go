@@ -0,0 +1,313 @@
+/*
+Package outbox provides at-least-once delivery of outgoing HTTP requests to
+unreliable upstreams. Requests are persisted to a Store before being sent,
+and retried with exponential backoff (across process restarts) until they
+succeed or fail permanently:
+
+    store, err := outbox.NewFileStore("/var/lib/myapp/outbox")
+    if err != nil { ... }
+    ob := outbox.New(store, client)
+    ob.OnFailure = func(m *outbox.Message, err error) {
+        log.Printf("giving up on %s %s: %v", m.Method, m.URL, err)
+    }
+    go ob.Run(ctx)
+
+    err = ob.Enqueue(httpsimp.MakeJSON(http.MethodPost, partnerURL, "/events", nil, event, nil))
+
+Since a request might be delivered more than once (e.g. if the process
+crashes right after sending it), upstreams should deduplicate requests,
+for example via an idempotency key header set before enqueueing.
+*/
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	httpsimp "github.com/andreyvit/httpsimplified/v2"
+)
+
+/*
+Message is a persisted outgoing request.
+*/
+type Message struct {
+	ID     string      `json:"id"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+
+	// Endpoint and Timeout preserve httpsimp.ForEndpoint and
+	// httpsimp.WithTimeout settings of the request.
+	Endpoint string        `json:"endpoint,omitempty"`
+	Timeout  time.Duration `json:"timeout,omitempty"`
+
+	Created     time.Time `json:"created"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+/*
+Store persists messages until they are delivered. Implementations must be
+safe for concurrent use. FileStore is provided; implement Store on top of
+SQLite or another database for larger volumes.
+*/
+type Store interface {
+	// Put inserts or replaces the message with the given ID.
+	Put(m *Message) error
+
+	// Delete removes the message with the given ID, if it exists.
+	Delete(id string) error
+
+	// Due returns up to limit messages whose NextAttempt is not after now,
+	// oldest first.
+	Due(now time.Time, limit int) ([]*Message, error)
+}
+
+/*
+Outbox delivers the messages of a Store. Set the fields before calling Run.
+*/
+type Outbox struct {
+	Store  Store
+	Client httpsimp.HTTPClient
+
+	// MaxAttempts is the number of attempts after which a message is given
+	// up on; 0 means DefaultMaxAttempts.
+	MaxAttempts int
+
+	// Backoff returns the delay before the given attempt (2 for the first
+	// retry); nil means exponential backoff from 1s up to 1h.
+	Backoff func(attempt int) time.Duration
+
+	// Interval is how often Run checks the store for due messages;
+	// 0 means 1s.
+	Interval time.Duration
+
+	// OnFailure, if set, is called when a message is given up on, either
+	// because the upstream rejected it or after MaxAttempts attempts.
+	OnFailure func(m *Message, err error)
+
+	now  func() time.Time
+	wake chan struct{}
+	once sync.Once
+}
+
+// DefaultMaxAttempts is the default value of Outbox.MaxAttempts.
+const DefaultMaxAttempts = 20
+
+const maxBackoff = time.Hour
+
+/*
+New returns an Outbox delivering messages of the given store via client.
+*/
+func New(store Store, client httpsimp.HTTPClient) *Outbox {
+	return &Outbox{Store: store, Client: client}
+}
+
+func (o *Outbox) init() {
+	o.once.Do(func() {
+		o.wake = make(chan struct{}, 1)
+		if o.now == nil {
+			o.now = time.Now
+		}
+	})
+}
+
+/*
+Enqueue persists the given request for delivery, reading its body (which
+is closed), and wakes up Run. Body transforms (like httpsimp.GzipBody) are
+applied before persisting, and the request's build error, if any, is
+returned right away. Of the request's context, only httpsimp.ForEndpoint
+and httpsimp.WithTimeout settings are retained.
+*/
+func (o *Outbox) Enqueue(r *http.Request) error {
+	o.init()
+	m, err := newMessage(r, o.now())
+	if err != nil {
+		return err
+	}
+	if err := o.Store.Put(m); err != nil {
+		return err
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func newMessage(r *http.Request, now time.Time) (*Message, error) {
+	if r.URL == nil {
+		return nil, errors.New("outbox: request has no URL")
+	}
+	r, err := httpsimp.Finalize(r)
+	if err != nil {
+		return nil, err
+	}
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("outbox: cannot read request body: %w", err)
+		}
+	}
+	method := r.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	return &Message{
+		ID:          newID(),
+		Method:      method,
+		URL:         r.URL.String(),
+		Header:      r.Header.Clone(),
+		Body:        body,
+		Endpoint:    httpsimp.EndpointName(r),
+		Timeout:     httpsimp.RequestTimeout(r),
+		Created:     now,
+		NextAttempt: now,
+	}, nil
+}
+
+/*
+Run delivers due messages until ctx is done, checking the store every
+Interval and whenever a message is enqueued. Store errors are reported
+via the returned error, which is ctx.Err() otherwise.
+*/
+func (o *Outbox) Run(ctx context.Context) error {
+	o.init()
+	interval := o.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := o.Flush(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-o.wake:
+		}
+	}
+}
+
+/*
+Flush makes a single delivery attempt for every message currently due.
+Messages rescheduled during the flush are not retried until the next one,
+even if they are already due again.
+*/
+func (o *Outbox) Flush(ctx context.Context) error {
+	o.init()
+	attempted := make(map[string]bool)
+	for {
+		msgs, err := o.Store.Due(o.now(), 100)
+		if err != nil {
+			return err
+		}
+		var fresh int
+		for _, m := range msgs {
+			if attempted[m.ID] {
+				continue
+			}
+			attempted[m.ID] = true
+			fresh++
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := o.deliver(ctx, m); err != nil {
+				return err
+			}
+		}
+		if fresh == 0 {
+			return nil
+		}
+	}
+}
+
+// deliver sends the message once, and then deletes or reschedules it.
+func (o *Outbox) deliver(ctx context.Context, m *Message) error {
+	r, err := http.NewRequestWithContext(ctx, m.Method, m.URL, nil)
+	if err == nil {
+		r.Header = m.Header.Clone()
+		if r.Header == nil {
+			r.Header = make(http.Header)
+		}
+		if m.Body != nil {
+			httpsimp.SetBody(r, m.Body)
+		}
+		if m.Endpoint != "" {
+			r = httpsimp.ForEndpoint(r, m.Endpoint)
+		}
+		if m.Timeout > 0 {
+			r = httpsimp.WithTimeout(m.Timeout)(r)
+		}
+		err = httpsimp.Do(r, o.Client, httpsimp.None())
+	}
+	if err == nil {
+		return o.Store.Delete(m.ID)
+	}
+	if ctx.Err() != nil {
+		return nil // interrupted by shutdown, not the upstream's fault
+	}
+
+	m.Attempts++
+	m.LastError = err.Error()
+	maxAttempts := o.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	if isPermanent(err) || m.Attempts >= maxAttempts {
+		if err := o.Store.Delete(m.ID); err != nil {
+			return err
+		}
+		if o.OnFailure != nil {
+			o.OnFailure(m, err)
+		}
+		return nil
+	}
+	m.NextAttempt = o.now().Add(o.backoff(m.Attempts + 1))
+	return o.Store.Put(m)
+}
+
+// isPermanent tells if retrying cannot help: the upstream rejected
+// the request (e.g. with 400 or 422), or the request cannot be built.
+func isPermanent(err error) bool {
+	var be *httpsimp.BuildError
+	if errors.As(err, &be) {
+		return true
+	}
+	return httpsimp.StatusCode(err) != 0 && !httpsimp.IsRetryable(err)
+}
+
+func (o *Outbox) backoff(attempt int) time.Duration {
+	if o.Backoff != nil {
+		return o.Backoff(attempt)
+	}
+	d := time.Second << uint(attempt-2)
+	if d > maxBackoff || d <= 0 {
+		d = maxBackoff
+	}
+	return d
+}
+
+func newID() string {
+	var b [16]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
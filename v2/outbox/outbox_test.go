@@ -0,0 +1,197 @@
+package outbox
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	httpsimp "github.com/andreyvit/httpsimplified/v2"
+)
+
+func TestOutboxRetriesAcrossRestarts(t *testing.T) {
+	var calls int
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		body = r.Method + " " + r.URL.Path + " " + r.Header.Get("X-Event") + " " + string(data)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httpsimp.MakeJSON(http.MethodPost, srv.URL, "/events", nil, map[string]int{"id": 1}, http.Header{"X-Event": {"created"}})
+	if err := New(store, http.DefaultClient).Enqueue(r); err != nil {
+		t.Fatal(err)
+	}
+
+	// first process: one failed attempt, then shutdown
+	ob := New(store, http.DefaultClient)
+	ob.Backoff = func(int) time.Duration { return time.Hour }
+	if err := ob.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d", calls)
+	}
+
+	// second process, some time later
+	store, err = NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ob = New(store, http.DefaultClient)
+	ob.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	ob.Backoff = func(int) time.Duration { return 0 }
+	for i := 0; i < 2; i++ {
+		if err := ob.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 3 || body != `POST /events created {"id":1}` {
+		t.Errorf("calls = %d, body = %q", calls, body)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("%d files left in the store", len(files))
+	}
+}
+
+func TestOutboxPermanentFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer srv.Close()
+
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ob := New(store, http.DefaultClient)
+	var failed *Message
+	var failure error
+	ob.OnFailure = func(m *Message, err error) {
+		failed, failure = m, err
+	}
+	if err := ob.Enqueue(httpsimp.MakeGet(srv.URL, "/x", nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ob.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if failed == nil || failed.Attempts != 1 || httpsimp.StatusCode(failure) != http.StatusUnprocessableEntity {
+		t.Fatalf("failed = %+v, failure = %v", failed, failure)
+	}
+	if due, _ := store.Due(time.Now().Add(time.Hour), 0); len(due) != 0 {
+		t.Errorf("%d messages left in the store", len(due))
+	}
+}
+
+func TestOutboxGivesUpAfterMaxAttempts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ob := New(store, http.DefaultClient)
+	ob.MaxAttempts = 3
+	ob.Backoff = func(int) time.Duration { return 0 }
+	var attempts int
+	ob.OnFailure = func(m *Message, err error) {
+		attempts = m.Attempts
+	}
+	if err := ob.Enqueue(httpsimp.MakeGet(srv.URL, "/x", nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := ob.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if attempts != 3 {
+		t.Errorf("gave up after %d attempts", attempts)
+	}
+}
+
+func TestOutboxFlushAttemptsOnce(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ob := New(store, http.DefaultClient)
+	ob.Backoff = func(int) time.Duration { return 0 }
+	for _, path := range []string{"/a", "/b"} {
+		if err := ob.Enqueue(httpsimp.MakeGet(srv.URL, path, nil, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ob.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, wanted one per message", calls)
+	}
+	if due, _ := store.Due(time.Now(), 0); len(due) != 2 {
+		t.Errorf("%d messages due, wanted 2", len(due))
+	}
+}
+
+func TestOutboxKeepsRequestSettings(t *testing.T) {
+	var encoding string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+	}))
+	defer srv.Close()
+
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ob := New(store, http.DefaultClient)
+
+	r := httpsimp.MakeJSON(http.MethodPost, srv.URL, "/events", nil, map[string]int{"id": 1}, nil)
+	r = httpsimp.ForEndpoint(httpsimp.GzipBody(r), "events")
+	r = httpsimp.WithTimeout(time.Minute)(r)
+	if err := ob.Enqueue(r); err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := store.Due(time.Now(), 10)
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("Due = %v, %v", msgs, err)
+	}
+	if m := msgs[0]; m.Endpoint != "events" || m.Timeout != time.Minute || m.Header.Get("Content-Encoding") != "gzip" {
+		t.Errorf("message = %+v", m)
+	}
+	if err := ob.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if encoding != "gzip" {
+		t.Errorf("Content-Encoding = %q, wanted gzip", encoding)
+	}
+
+	err = ob.Enqueue(httpsimp.MakeGet("http://[::1", "/", nil, nil))
+	if _, ok := err.(*httpsimp.BuildError); !ok {
+		t.Errorf("err = %v, wanted a build error", err)
+	}
+}
@@ -1,9 +1,15 @@
 package httpsimp
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"mime"
 	"net/http"
+	"strings"
 )
 
 /*
@@ -13,10 +19,44 @@ To create a parser, use of the built-in parser functions like JSON,
 PlainText, etc, or build a custom one using MakeParser.
 */
 type Parser struct {
-	ctype      string
-	statusSpec StatusSpec
-	retErr     bool
-	parseBody  func(resp *http.Response) (interface{}, error)
+	ctype string
+	// ctypeAliases are alternative content types matched by default
+	ctypeAliases []string
+	statusSpec   StatusSpec
+	retErr       bool
+	parseBody    func(resp *http.Response) (interface{}, error)
+	observe      func(resp *http.Response) error
+	mapErr       func(err error) error
+	lang         string
+	progress     func(read, total int64)
+	maxBytes     int64
+	checksums    []*checksumSpec
+	// File options
+	checkFreeSpace   bool
+	freeSpaceReserve int64
+	transforms       []BodyTransform
+	// convertCharset enables conversion of the body into UTF-8
+	convertCharset bool
+	stripBOM       bool
+
+	// JSON decoding options
+	useNumber             bool
+	disallowUnknownFields bool
+	vendor                *VendorType
+	matchFuncs            []func(resp *http.Response) bool
+
+	// wantsCallInfo makes Do attach timing information to the response
+	wantsCallInfo bool
+
+	// combinators like FirstOf and Also handle responses via combine
+	combine  func(resp *http.Response, mt *mediaType, wantErr bool) (bool, error)
+	children []Parser
+}
+
+// isPseudo returns whether the parser is an observer or an error mapper,
+// which never handles the body.
+func (p Parser) isPseudo() bool {
+	return p.parseBody == nil && p.combine == nil
 }
 
 /*
@@ -40,7 +80,7 @@ The provided options change the behavior of the parser and may
 override the content type that it matches.
 */
 func MakeParser(defaultCtype string, mopt []ParseOption, bodyParser func(resp *http.Response) (interface{}, error)) Parser {
-	p := Parser{defaultCtype, Status2xx, false, bodyParser}
+	p := Parser{ctype: defaultCtype, statusSpec: Status2xx, parseBody: bodyParser}
 	for _, o := range mopt {
 		o.applyToParser(&p)
 	}
@@ -56,10 +96,20 @@ func (o matchOptionFunc) applyToParser(m *Parser) {
 /*
 ContentType causes the parser to only match responses with the given content type.
 If an empty string is passed in, the parser will match any content type.
+
+A structured syntax suffix pattern like "application/*+json" (see
+ContentTypeAnyJSON) matches application/json as well as any application/...+json
+type like application/hal+json or application/problem+json.
+
+A wildcard pattern like "text/*" matches any subtype of the given type
+(e.g. text/plain, text/html and text/csv). The any-type media range is also
+supported; unlike an empty string, it requires the Content-Type header
+to be present.
 */
 func ContentType(ctype string) ParseOption {
 	return matchOptionFunc(func(m *Parser) {
 		m.ctype = ctype
+		m.ctypeAliases = nil
 	})
 }
 
@@ -75,21 +125,196 @@ var returnError ParseOption = matchOptionFunc(func(m *Parser) {
 	m.retErr = true
 })
 
+/*
+ContentLanguage causes the parser to only match responses with the given
+language in the Content-Language header. A language like "en" matches
+more specific ones like "en-US" too, and "*" matches any declared language.
+*/
+func ContentLanguage(lang string) ParseOption {
+	return matchOptionFunc(func(m *Parser) {
+		m.lang = lang
+	})
+}
+
+/*
+MatchFunc causes the parser to only match responses for which the given
+predicate returns true (in addition to the status code and content type
+checks). Use multiple MatchFunc options to require all of the predicates
+to be satisfied.
+
+The predicate must not consume the body; use PeekBody to look at
+the beginning of it:
+
+	httpsimp.JSON(&legacy, httpsimp.MatchFunc(func(resp *http.Response) bool {
+	    head, _ := httpsimp.PeekBody(resp, 64)
+	    return bytes.Contains(head, []byte(`"version":1`))
+	}))
+*/
+func MatchFunc(f func(resp *http.Response) bool) ParseOption {
+	return matchOptionFunc(func(m *Parser) {
+		m.matchFuncs = append(m.matchFuncs[:len(m.matchFuncs):len(m.matchFuncs)], f)
+	})
+}
+
+/*
+PeekBody returns up to n first bytes of the response body without consuming
+them, so that the body can still be parsed afterwards. An error is only
+returned if reading fails; a shorter body is not an error.
+*/
+func PeekBody(resp *http.Response, n int) ([]byte, error) {
+	pb, ok := resp.Body.(*peekableBody)
+	if !ok || pb.Size() < n {
+		var r io.Reader = resp.Body
+		if ok {
+			r = pb.Reader
+		}
+		pb = &peekableBody{bufio.NewReaderSize(r, n), resp.Body}
+		resp.Body = pb
+	}
+	b, err := pb.Peek(n)
+	if err == io.EOF || err == bufio.ErrBufferFull {
+		err = nil
+	}
+	return b, err
+}
+
+type peekableBody struct {
+	*bufio.Reader
+	io.Closer
+}
+
+/*
+Progress causes the given function to be called as the parser reads
+the response body, with the number of bytes read so far and the total
+size of the body from Content-Length (or -1 if unknown).
+*/
+func Progress(f func(read, total int64)) ParseOption {
+	return matchOptionFunc(func(m *Parser) {
+		m.progress = f
+	})
+}
+
+/*
+MaxBytes limits the size of the response body that the parser will read.
+If the body is larger than n bytes, parsing fails with an error wrapping
+ErrBodyTooLarge. When Content-Length is known to exceed the limit,
+the body is not read at all.
+*/
+func MaxBytes(n int64) ParseOption {
+	return matchOptionFunc(func(m *Parser) {
+		m.maxBytes = n
+	})
+}
+
+// maxBytesReader is like http.MaxBytesReader, but for response bodies.
+type maxBytesReader struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (r *maxBytesReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if int64(n) <= r.remaining {
+		r.remaining -= int64(n)
+		r.err = err
+		return n, err
+	}
+	n = int(r.remaining)
+	r.remaining = 0
+	r.err = ErrBodyTooLarge
+	return n, r.err
+}
+
+type progressReader struct {
+	io.ReadCloser
+	read  int64
+	total int64
+	f     func(read, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.read += int64(n)
+		r.f(r.read, r.total)
+	}
+	return n, err
+}
+
+func matchLanguage(wanted string, values []string) bool {
+	for _, v := range values {
+		for _, lang := range strings.Split(v, ",") {
+			lang = strings.TrimSpace(lang)
+			if lang == "" {
+				continue
+			}
+			if wanted == "*" || strings.EqualFold(lang, wanted) {
+				return true
+			}
+			if len(lang) > len(wanted) && lang[len(wanted)] == '-' && strings.EqualFold(lang[:len(wanted)], wanted) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s StatusSpec) applyToParser(m *Parser) {
 	m.statusSpec = s
 }
 
-func parse(resp *http.Response, p Parser) (bool, error) {
-	mediaType := resp.Header.Get("Content-Type")
-	ctype, _, err := mime.ParseMediaType(mediaType)
+// mediaType is the Content-Type of a response, parsed once for all parsers.
+type mediaType struct {
+	ctype  string
+	params map[string]string
+	err    error
+}
+
+func parseMediaType(resp *http.Response) *mediaType {
+	header := resp.Header.Get("Content-Type")
+	if header == "" {
+		return &mediaType{}
+	}
+	ctype, params, err := mime.ParseMediaType(header)
 	if err != nil {
-		return false, fmt.Errorf("cannot parse Content-Type string %v", mediaType)
+		return &mediaType{err: fmt.Errorf("cannot parse Content-Type string %v", header)}
+	}
+	return &mediaType{ctype: ctype, params: params}
+}
+
+// parse handles the response with the given parser if it matches.
+// The error explaining a mismatch is only built when wantErr is true.
+func parse(resp *http.Response, mt *mediaType, p Parser, wantErr bool) (bool, error) {
+	if p.combine != nil {
+		return p.combine(resp, mt, wantErr)
+	}
+	if mt.err != nil {
+		return false, mt.err
 	}
+	ctype, ctypeParams := mt.ctype, mt.params
 
-	ctypeOK := (p.ctype == "" || ctype == p.ctype)
+	ctypeOK := matchContentType(p.ctype, ctype)
+	for _, alias := range p.ctypeAliases {
+		ctypeOK = ctypeOK || matchContentType(alias, ctype)
+	}
 	statusOK := p.statusSpec.Matches(resp.StatusCode)
+	if !ctypeOK && statusOK && p.vendor != nil {
+		if err := vendorVersionMismatch(resp.StatusCode, p.vendor, ctype); err != nil {
+			return false, err
+		}
+	}
 	if !ctypeOK || !statusOK {
-		return false, &responseError{
+		if !wantErr {
+			return false, nil
+		}
+		return false, &ResponseError{
 			StatusCode:        resp.StatusCode,
 			ContentType:       ctype,
 			WantedContentType: p.ctype,
@@ -99,14 +324,114 @@ func parse(resp *http.Response, p Parser) (bool, error) {
 		}
 	}
 
+	if p.lang != "" && !matchLanguage(p.lang, resp.Header["Content-Language"]) {
+		if !wantErr {
+			return false, nil
+		}
+		return false, &ResponseError{
+			StatusCode:        resp.StatusCode,
+			ContentType:       ctype,
+			WantedContentType: p.ctype,
+			ContentTypeOK:     true,
+			Language:          strings.Join(resp.Header["Content-Language"], ", "),
+			WantedLanguage:    p.lang,
+		}
+	}
+
+	for _, f := range p.matchFuncs {
+		if !f(resp) {
+			if !wantErr {
+				return false, nil
+			}
+			return false, &ResponseError{
+				StatusCode:        resp.StatusCode,
+				ContentType:       ctype,
+				WantedContentType: p.ctype,
+				ContentTypeOK:     true,
+			}
+		}
+	}
+
+	if p.maxBytes > 0 {
+		if resp.ContentLength > p.maxBytes {
+			resp.Body.Close()
+			return true, &ResponseError{
+				StatusCode:        resp.StatusCode,
+				ContentType:       ctype,
+				WantedContentType: p.ctype,
+				ContentTypeOK:     true,
+				DecodingError:     ErrBodyTooLarge,
+			}
+		}
+		resp.Body = &maxBytesReader{resp.Body, p.maxBytes, nil}
+	}
+
+	if p.progress != nil {
+		resp.Body = &progressReader{resp.Body, 0, resp.ContentLength, p.progress}
+	}
+
+	var checksums *checksumBody
+	if len(p.checksums) > 0 {
+		checksums = newChecksumBody(resp.Body, p.checksums)
+		resp.Body = checksums
+	}
+
+	if p.convertCharset {
+		d, ok := charsetDecoder(ctypeParams["charset"])
+		if !ok {
+			drainAndClose(resp.Body)
+			return true, &ResponseError{
+				StatusCode:        resp.StatusCode,
+				ContentType:       ctype,
+				WantedContentType: p.ctype,
+				ContentTypeOK:     true,
+				DecodingError:     fmt.Errorf("unsupported charset %q", ctypeParams["charset"]),
+			}
+		}
+		if d != nil {
+			resp.Body = transformedBody{d(resp.Body), resp.Body}
+		}
+	}
+
+	if p.stripBOM {
+		r, _ := StripBOM(resp.Body)
+		resp.Body = transformedBody{r, resp.Body}
+	}
+
+	for _, t := range p.transforms {
+		r, err := t(resp.Body)
+		if err != nil {
+			drainAndClose(resp.Body)
+			return true, &ResponseError{
+				StatusCode:        resp.StatusCode,
+				ContentType:       ctype,
+				WantedContentType: p.ctype,
+				ContentTypeOK:     true,
+				DecodingError:     err,
+			}
+		}
+		resp.Body = transformedBody{r, resp.Body}
+	}
+
 	body, bodyErr := p.parseBody(resp)
-	if p.retErr || bodyErr != nil {
-		return true, &responseError{
+	if bodyErr == nil && checksums != nil {
+		bodyErr = checksums.verify()
+	}
+	truncated, failed := false, false
+	if tb, ok := body.(truncatedBody); ok {
+		body, truncated = tb.body, true
+	}
+	if fb, ok := body.(failedBody); ok {
+		body, failed = fb.body, true
+	}
+	if p.retErr || failed || bodyErr != nil {
+		return true, &ResponseError{
 			StatusCode:        resp.StatusCode,
 			ContentType:       ctype,
 			WantedContentType: p.ctype,
 			ContentTypeOK:     true,
 			Body:              body,
+			Truncated:         truncated,
 			DecodingError:     bodyErr,
 		}
 	} else {
@@ -114,9 +439,98 @@ func parse(resp *http.Response, p Parser) (bool, error) {
 	}
 }
 
+// maxDrainBytes limits how much of an unwanted body is read before closing
+// it; reading the rest of a small body lets the connection be reused,
+// while a large one is cheaper to abandon.
+const maxDrainBytes = 64 << 10
+
+func drainAndClose(body io.ReadCloser) {
+	io.CopyN(ioutil.Discard, body, maxDrainBytes)
+	body.Close()
+}
+
+func matchContentType(pattern, ctype string) bool {
+	if pattern == "" || pattern == ctype || (pattern == "*/*" && ctype != "") {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		prefix := pattern[:len(pattern)-1]
+		return len(ctype) > len(prefix) && strings.HasPrefix(ctype, prefix)
+	}
+	if i := strings.Index(pattern, "/*+"); i >= 0 {
+		prefix, suffix := pattern[:i+1], pattern[i+2:]
+		if ctype == prefix+suffix[1:] {
+			return true
+		}
+		return len(ctype) > len(prefix)+len(suffix) && strings.HasPrefix(ctype, prefix) && strings.HasSuffix(ctype, suffix)
+	}
+	return false
+}
+
+// acceptRange converts a content type pattern into a media range valid
+// in the Accept header, returning false if a wildcard is needed instead.
+func acceptRange(pattern string) (string, bool) {
+	if i := strings.Index(pattern, "/*+"); i >= 0 {
+		return pattern[:i+1] + pattern[i+3:], false
+	}
+	return pattern, true
+}
+
+func acceptHeader(parsers []Parser) string {
+	var buf [4]string
+	types := buf[:0]
+	anyType := false
+	add := func(pattern string) {
+		ctype, exact := acceptRange(pattern)
+		if !exact {
+			anyType = true
+		}
+		for _, t := range types {
+			if t == ctype {
+				return
+			}
+		}
+		types = append(types, ctype)
+	}
+	for _, p := range flattenParsers(parsers) {
+		if p.isPseudo() {
+			continue
+		}
+		if p.ctype == "" {
+			anyType = true
+			continue
+		}
+		add(p.ctype)
+		for _, alias := range p.ctypeAliases {
+			add(alias)
+		}
+	}
+	switch {
+	case len(types) == 0:
+		return ""
+	case len(types) == 1 && !anyType:
+		return types[0]
+	case anyType:
+		types = append(types, "*/*;q=0.1")
+	}
+	return strings.Join(types, ", ")
+}
+
+func bufferBody(resp *http.Response) ([]byte, error) {
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("error reading body: %w", err)
+	}
+	return b, nil
+}
+
 var fallbackParsers = []Parser{
-	JSON(nil, Status4xx5xx, ReturnError()),
-	PlainText(nil, Status4xx5xx, ContentType(ContentTypeTextPlain), ReturnError()),
+	None(StatusNotModified),
+	fallbackJSONAPI(Status4xx5xx, ReturnError()),
+	fallbackJSON(Status4xx5xx, ReturnError()),
+	fallbackPlainText(Status4xx5xx, ContentType(ContentTypeTextPlain), ReturnError()),
 	None(StatusAny, ReturnError()),
 }
 
@@ -124,31 +538,121 @@ var fallbackParsers = []Parser{
 Parse handles the HTTP response using of the provided parsers.
 The first matching parser wins.
 
+Bodies with a Content-Encoding registered via RegisterContentDecoder
+(like gzip) are decoded before any parsers run.
+
+Observers like Expect run before any parsers; if one of them fails,
+the body is discarded and the error is returned. Error mappers like ErrorMap
+translate the resulting error.
+
+Bodies that end up unused (e.g. handled by None, or when an observer fails)
+are read up to a limit before closing, so that the connection can be reused.
+
 If no parsers match, some predefined fallback parsers are tried;
-all of them cause a non-nil error to be returned.
+all of them except the one handling 304 Not Modified cause a non-nil error
+to be returned. (A 304 response is only ever received in response to
+a conditional request, so it is treated as a successful cache hit.)
+If the response isn't an error that the fallback parsers can describe,
+the returned error explains why each of the parsers didn't match, joined
+via errors.Join.
 */
 func Parse(resp *http.Response, parsers ...Parser) error {
-	var firstErr error
+	decodeContentEncoding(resp)
 
 	for _, p := range parsers {
-		matched, err := parse(resp, p)
+		if p.observe != nil {
+			if err := p.observe(resp); err != nil {
+				drainAndClose(resp.Body)
+				return err
+			}
+		}
+	}
+
+	err := parseWith(resp, parsers)
+	if err != nil {
+		for _, p := range parsers {
+			if p.mapErr != nil {
+				err = p.mapErr(err)
+			}
+		}
+	}
+	return err
+}
+
+func parseWith(resp *http.Response, parsers []Parser) error {
+	mt := parseMediaType(resp)
+
+	anyParsers := false
+	for _, p := range parsers {
+		if p.isPseudo() {
+			continue
+		}
+		anyParsers = true
+		matched, err := parse(resp, mt, p, false)
 		if matched {
 			return err
 		}
-		if firstErr == nil {
-			firstErr = err
-		}
 	}
 
+	var fallbackErr error
 	for i, p := range fallbackParsers {
-		matched, err := parse(resp, p)
+		matched, err := parse(resp, mt, p, fallbackErr == nil && !anyParsers)
 		if matched {
-			if i == len(fallbackParsers)-1 && err != nil {
-				err = firstErr
+			if i == len(fallbackParsers)-1 && err != nil && anyParsers {
+				err = mismatchError(resp, mt, parsers)
 			}
 			return err
 		}
+		if fallbackErr == nil {
+			fallbackErr = err
+		}
+	}
+
+	// only reachable for invalid status codes and Content-Type values
+	drainAndClose(resp.Body)
+	if anyParsers {
+		return mismatchError(resp, mt, parsers)
+	}
+	return fallbackErr
+}
+
+/*
+mismatchError explains why none of the given parsers matched the response.
+Matching is repeated with errors enabled only when the explanation is needed,
+which is possible because a mismatch is detected before reading the body.
+*/
+func mismatchError(resp *http.Response, mt *mediaType, parsers []Parser) error {
+	var errs []error
+	for _, p := range parsers {
+		if p.isPseudo() {
+			continue
+		}
+		if _, err := parse(resp, mt, p, true); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return joinErrors(errs)
+}
 
-	return nil
+// joinErrors is like errors.Join, but returns a single error as is
+// and skips repeated errors.
+func joinErrors(errs []error) error {
+	var unique []error
+outer:
+	for _, err := range errs {
+		for _, u := range unique {
+			if u == err {
+				continue outer
+			}
+		}
+		unique = append(unique, err)
+	}
+	switch len(unique) {
+	case 0:
+		return nil
+	case 1:
+		return unique[0]
+	default:
+		return errors.Join(unique...)
+	}
 }
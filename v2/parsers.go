@@ -29,6 +29,9 @@ JSON is a Parser function that verifies the response status code and content
 type (which must be ContentTypeJSON) and unmarshals the body into the
 result variable (which can be anything that you'd pass to json.Unmarshal).
 
+The body is decoded using JSONCodec. Use UseNumber and DisallowUnknownFields
+options to adjust decoding; these always use encoding/json.
+
 Pass the result of this function into Do or Parse to handle a response.
 */
 func JSON(result interface{}, mopt ...ParseOption) Parser {
@@ -36,11 +39,52 @@ func JSON(result interface{}, mopt ...ParseOption) Parser {
 		var body interface{}
 		result = &body
 	}
-	return MakeParser(ContentTypeJSON, mopt, func(resp *http.Response) (interface{}, error) {
-		defer resp.Body.Close()
-		err := json.NewDecoder(resp.Body).Decode(result)
+	p := MakeParser(ContentTypeJSON, mopt, nil)
+	p.stripBOM = true
+	useNumber, disallowUnknownFields := p.useNumber, p.disallowUnknownFields
+	p.parseBody = func(resp *http.Response) (interface{}, error) {
+		defer drainAndClose(resp.Body)
+		codec := JSONCodec
+		if _, std := codec.(stdJSONCodec); !std && !useNumber && !disallowUnknownFields {
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return nil, fmt.Errorf("error reading body: %w", err)
+			}
+			err = codec.Unmarshal(b, result)
+			body := reflect.ValueOf(result).Elem().Interface()
+			return body, err
+		}
+		dec := json.NewDecoder(resp.Body)
+		if useNumber {
+			dec.UseNumber()
+		}
+		if disallowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
+		err := dec.Decode(result)
 		body := reflect.ValueOf(result).Elem().Interface()
 		return body, err
+	}
+	return p
+}
+
+/*
+UseNumber causes JSON to decode numbers into interface{} values
+as json.Number instead of float64, preserving their precision.
+*/
+func UseNumber() ParseOption {
+	return matchOptionFunc(func(m *Parser) {
+		m.useNumber = true
+	})
+}
+
+/*
+DisallowUnknownFields causes JSON to fail when the body contains object keys
+that do not match any non-ignored, exported fields of the destination struct.
+*/
+func DisallowUnknownFields() ParseOption {
+	return matchOptionFunc(func(m *Parser) {
+		m.disallowUnknownFields = true
 	})
 }
 
@@ -53,9 +97,12 @@ Pass the result of this function into Do or Parse to handle a response.
 func Bytes(result *[]byte, mopt ...ParseOption) Parser {
 	return MakeParser("", mopt, func(resp *http.Response) (interface{}, error) {
 		defer resp.Body.Close()
-		b, err := ioutil.ReadAll(resp.Body)
+		var b []byte
+		err := readAllPooled(resp.Body, func(data []byte) {
+			b = append(make([]byte, 0, len(data)), data...)
+		})
 		if err != nil {
-			err = fmt.Errorf("error reading body: %v", err)
+			err = fmt.Errorf("error reading body: %w", err)
 		}
 		*result = b
 		return b, err
@@ -64,7 +111,8 @@ func Bytes(result *[]byte, mopt ...ParseOption) Parser {
 
 /*
 PlainText is a Parser function that verifies the response status code and reads
-the entire body into a string.
+the entire body into a string, converting it into UTF-8 according to
+the charset parameter of Content-Type (see ConvertCharset).
 
 Pass the result of this function into Do or Parse to handle a response.
 */
@@ -73,31 +121,75 @@ func PlainText(result *string, mopt ...ParseOption) Parser {
 		var body string
 		result = &body
 	}
-	return MakeParser("", mopt, func(resp *http.Response) (interface{}, error) {
+	p := MakeParser("", mopt, func(resp *http.Response) (interface{}, error) {
 		defer resp.Body.Close()
-		b, err := ioutil.ReadAll(resp.Body)
+		var s string
+		var valid bool
+		err := readAllPooled(resp.Body, func(b []byte) {
+			s, valid = string(b), utf8.Valid(b)
+		})
 		if err != nil {
-			err = fmt.Errorf("error reading body: %v", err)
+			err = fmt.Errorf("error reading body: %w", err)
 		}
-		if !utf8.Valid(b) {
-			return b, errors.New("invalid utf-8 sequence encountered")
+		if !valid {
+			return []byte(s), errors.New("invalid utf-8 sequence encountered")
 		}
 
-		s := string(b)
 		*result = s
 		return s, err
 	})
+	p.convertCharset, p.stripBOM = true, true
+	return p
+}
+
+/*
+File is a Parser function that verifies the response status code and streams
+the body into a file at the given path, without loading it into memory.
+
+The body is written into a temporary file in the same directory, which is
+renamed to path once the entire body has been received, atomically replacing
+an existing file. If the download fails, the temporary file is removed and
+an existing file at path is left intact, so consumers never pick up
+a half-written file.
+
+Use Progress option to track the download:
+
+    err := httpsimp.Do(req, client, httpsimp.File("artifact.tar.gz", httpsimp.Progress(func(read, total int64) {
+        log.Printf("downloaded %d of %d bytes", read, total)
+    })))
+
+Use FreeSpaceCheck to fail early when the disk is too full.
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func File(path string, mopt ...ParseOption) Parser {
+	p := MakeParser("", mopt, nil)
+	checkSpace, reserve := p.checkFreeSpace, p.freeSpaceReserve
+	p.parseBody = func(resp *http.Response) (interface{}, error) {
+		defer resp.Body.Close()
+		if checkSpace && resp.ContentLength > 0 {
+			if err := checkFreeSpace(path, resp.ContentLength+reserve); err != nil {
+				return nil, err
+			}
+		}
+		if err := writeFileAtomically(path, resp.Body); err != nil {
+			return nil, fmt.Errorf("error downloading into %s: %w", path, err)
+		}
+		return nil, nil
+	}
+	return p
 }
 
 /*
 None is a Parser function that verifies the response status code and discards
-the response body.
+the response body (reading a reasonably small body to the end, so that
+the connection can be reused).
 
 Pass the result of this function into Do or Parse to handle a response.
 */
 func None(mopt ...ParseOption) Parser {
 	return MakeParser("", mopt, func(resp *http.Response) (interface{}, error) {
-		resp.Body.Close()
+		drainAndClose(resp.Body)
 		return nil, nil
 	})
 }
@@ -0,0 +1,182 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+/*
+Priority is the lane a request is queued in by PriorityQueue.
+*/
+type Priority int
+
+const (
+	// PriorityInteractive is for user-facing requests; this is the default.
+	PriorityInteractive Priority = iota
+
+	// PriorityBackground is for bulk and batch jobs, which only get
+	// a slot when no interactive requests are waiting.
+	PriorityBackground
+)
+
+/*
+WithPriority marks the request as interactive or background for PriorityQueue.
+*/
+func WithPriority(p Priority) RequestOption {
+	return func(r *http.Request) *http.Request {
+		return r.WithContext(ContextWithPriority(r.Context(), p))
+	}
+}
+
+/*
+ContextWithPriority returns a context marking all requests made with it
+as interactive or background for PriorityQueue, e.g. for the entire
+duration of a sync job.
+*/
+func ContextWithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+type priorityKey struct{}
+
+func priorityOf(r *http.Request) Priority {
+	p, _ := r.Context().Value(priorityKey{}).(Priority)
+	return p
+}
+
+/*
+PriorityQueue returns an HTTPClient that sends at most maxInFlight requests
+at a time via the given client, queueing the rest. When a slot frees up,
+waiting interactive requests go first, so bulk sync jobs can't starve
+user-facing calls to the same upstream:
+
+    client := httpsimp.PriorityQueue(&http.Client{Timeout: 10 * time.Second}, 8, 6)
+
+    // in a sync job
+    ctx = httpsimp.ContextWithPriority(ctx, httpsimp.PriorityBackground)
+
+Background requests additionally never occupy more than maxBackground slots,
+keeping the rest available for interactive requests that arrive while
+slow background requests are in flight. Pass maxInFlight (or 0) as
+maxBackground to not reserve any slots. If maxInFlight <= 0, there is no
+limit, and the given client is returned as is.
+
+A slot is held until the response body is closed (Do does this after
+parsing). Requests waiting in the queue fail when their context is done.
+*/
+func PriorityQueue(client HTTPClient, maxInFlight, maxBackground int) HTTPClient {
+	if maxInFlight <= 0 {
+		return client
+	}
+	if maxBackground <= 0 || maxBackground > maxInFlight {
+		maxBackground = maxInFlight
+	}
+	return &priorityClient{
+		client:        client,
+		maxInFlight:   maxInFlight,
+		maxBackground: maxBackground,
+	}
+}
+
+type priorityClient struct {
+	client        HTTPClient
+	maxInFlight   int
+	maxBackground int
+
+	mu           sync.Mutex
+	inFlight     int
+	background   int // background requests in flight
+	interactiveQ []chan struct{}
+	backgroundQ  []chan struct{}
+}
+
+func (c *priorityClient) Do(r *http.Request) (*http.Response, error) {
+	p := priorityOf(r)
+	if err := c.acquire(r.Context(), p); err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	release := func() { once.Do(func() { c.release(p) }) }
+
+	resp, err := c.client.Do(r)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{resp.Body, release}
+	return resp, nil
+}
+
+func (c *priorityClient) acquire(ctx context.Context, p Priority) error {
+	c.mu.Lock()
+	q := &c.interactiveQ
+	if p == PriorityBackground {
+		q = &c.backgroundQ
+	}
+	if len(c.interactiveQ) == 0 && len(*q) == 0 && c.canStart(p) {
+		c.start(p)
+		c.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	*q = append(*q, ready)
+	c.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, ch := range *q {
+			if ch == ready {
+				*q = append((*q)[:i:i], (*q)[i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// the slot has been granted in the meantime, pass it on
+		c.finish(p)
+		c.dispatch()
+		return ctx.Err()
+	}
+}
+
+func (c *priorityClient) release(p Priority) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.finish(p)
+	c.dispatch()
+}
+
+func (c *priorityClient) canStart(p Priority) bool {
+	return c.inFlight < c.maxInFlight && (p != PriorityBackground || c.background < c.maxBackground)
+}
+
+func (c *priorityClient) start(p Priority) {
+	c.inFlight++
+	if p == PriorityBackground {
+		c.background++
+	}
+}
+
+func (c *priorityClient) finish(p Priority) {
+	c.inFlight--
+	if p == PriorityBackground {
+		c.background--
+	}
+}
+
+// dispatch hands out free slots to the queued requests, interactive first.
+func (c *priorityClient) dispatch() {
+	for len(c.interactiveQ) > 0 && c.canStart(PriorityInteractive) {
+		c.start(PriorityInteractive)
+		close(c.interactiveQ[0])
+		c.interactiveQ = c.interactiveQ[1:]
+	}
+	for len(c.interactiveQ) == 0 && len(c.backgroundQ) > 0 && c.canStart(PriorityBackground) {
+		c.start(PriorityBackground)
+		close(c.backgroundQ[0])
+		c.backgroundQ = c.backgroundQ[1:]
+	}
+}
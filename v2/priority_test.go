@@ -0,0 +1,127 @@
+package httpsimp
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingClient struct {
+	mu    sync.Mutex
+	paths []string
+	block chan struct{}
+}
+
+func (c *recordingClient) Do(r *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.paths = append(c.paths, r.URL.Path)
+	c.mu.Unlock()
+	if r.URL.Path == "/blocker" {
+		<-c.block
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {ContentTypeTextPlain}},
+		Body:       ioutil.NopCloser(strings.NewReader("ok")),
+		Request:    r,
+	}, nil
+}
+
+func (c *recordingClient) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.paths)
+}
+
+func TestPriorityQueue(t *testing.T) {
+	rc := &recordingClient{block: make(chan struct{})}
+	client := PriorityQueue(rc, 1, 1)
+	pc := client.(*priorityClient)
+
+	waitQueued := func(interactive, background int) {
+		for i := 0; i < 1000; i++ {
+			pc.mu.Lock()
+			ok := len(pc.interactiveQ) == interactive && len(pc.backgroundQ) == background
+			pc.mu.Unlock()
+			if ok {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("requests not queued")
+	}
+
+	var wg sync.WaitGroup
+	send := func(path string, p Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := MakeWith(http.MethodGet, "http://example.com", path, WithPriority(p))
+			if err := Do(r, client, None()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	send("/blocker", PriorityBackground)
+	for i := 0; i < 1000 && rc.count() == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	send("/bg1", PriorityBackground)
+	waitQueued(0, 1)
+	send("/bg2", PriorityBackground)
+	waitQueued(0, 2)
+	send("/ui", PriorityInteractive)
+	waitQueued(1, 2)
+
+	close(rc.block)
+	wg.Wait()
+
+	if a, e := strings.Join(rc.paths, " "), "/blocker /ui /bg1 /bg2"; a != e {
+		t.Errorf("order = %q, wanted %q", a, e)
+	}
+}
+
+func TestPriorityQueueReservesSlots(t *testing.T) {
+	rc := &recordingClient{block: make(chan struct{})}
+	client := PriorityQueue(rc, 2, 1)
+
+	go Do(MakeGet("http://example.com", "/blocker", nil, nil).WithContext(ContextWithPriority(context.Background(), PriorityBackground)), client, None())
+	defer close(rc.block)
+	for i := 0; i < 1000 && rc.count() == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	bg := MakeGet("http://example.com", "/bg", nil, nil).WithContext(ContextWithPriority(ctx, PriorityBackground))
+	if err := Do(bg, client, None()); !IsTimeout(err) {
+		t.Errorf("background request: %v", err)
+	}
+	if err := Do(MakeGet("http://example.com", "/ui", nil, nil), client, None()); err != nil {
+		t.Errorf("interactive request: %v", err)
+	}
+}
+
+func TestPriorityQueueZeroLimits(t *testing.T) {
+	rc := &recordingClient{}
+	if client := PriorityQueue(rc, 0, 0); client != HTTPClient(rc) {
+		t.Errorf("maxInFlight = 0 did not disable the queue")
+	}
+
+	client := PriorityQueue(rc, 1, 0)
+	bg := MakeGet("http://example.com", "/bg", nil, nil).WithContext(ContextWithPriority(context.Background(), PriorityBackground))
+	done := make(chan error, 1)
+	go func() { done <- Do(bg, client, None()) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("background request never ran with maxBackground = 0")
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/andreyvit/httpsimplified/v2/protosimp
+
+go 1.23
+
+require (
+	github.com/andreyvit/httpsimplified/v2 v2.0.1
+	google.golang.org/protobuf v1.36.12
+)
+
+replace github.com/andreyvit/httpsimplified/v2 => ../
@@ -0,0 +1,69 @@
+/*
+Package protosimp adds Protocol Buffers support to httpsimp, for gRPC-gateway
+and Prometheus remote-write style endpoints:
+
+    r := protosimp.EncodeProtoBody(httpsimp.Make(http.MethodPost, baseURL, path, nil, nil, nil), req)
+    err := httpsimp.Do(r, client, protosimp.Proto(&resp))
+
+It is a separate module, so that httpsimp itself doesn't depend on
+google.golang.org/protobuf.
+*/
+package protosimp
+
+import (
+	"fmt"
+	"net/http"
+
+	httpsimp "github.com/andreyvit/httpsimplified/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+EncodeProtoBody encodes the given message into Protocol Buffers wire format
+and sets the body and Content-Type (application/x-protobuf) on the given
+request.
+
+If encoding fails, httpsimp.Do returns a *httpsimp.BuildError without sending
+the request.
+
+To properly handle HTTP redirects, both Body and GetBody are set.
+*/
+func EncodeProtoBody(r *http.Request, msg proto.Message) *http.Request {
+	return httpsimp.EncodeBody(r, Codec, httpsimp.ContentTypeProtobuf, msg)
+}
+
+/*
+Proto is a Parser function that verifies the response status code and content
+type (which must be httpsimp.ContentTypeProtobuf) and unmarshals the body
+into the given message.
+
+Pass the result of this function into httpsimp.Do or httpsimp.Parse to handle
+a response.
+*/
+func Proto(msg proto.Message, mopt ...httpsimp.ParseOption) httpsimp.Parser {
+	return httpsimp.Decode(msg, Codec, httpsimp.ContentTypeProtobuf, mopt...)
+}
+
+/*
+Codec is the httpsimp.Codec used by EncodeProtoBody and Proto; values
+must implement proto.Message.
+*/
+var Codec httpsimp.Codec = protoCodec{}
+
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Marshal(msg)
+}
+
+func (protoCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, msg)
+}
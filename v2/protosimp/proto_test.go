@@ -0,0 +1,49 @@
+package protosimp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpsimp "github.com/andreyvit/httpsimplified/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProto(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req wrapperspb.StringValue
+		data, _ := ioutil.ReadAll(r.Body)
+		if err := proto.Unmarshal(data, &req); err != nil || r.Header.Get("Content-Type") != httpsimp.ContentTypeProtobuf {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp, _ := proto.Marshal(wrapperspb.String("hello, " + req.Value))
+		w.Header().Set("Content-Type", httpsimp.ContentTypeProtobuf)
+		w.Write(resp)
+	}))
+	defer srv.Close()
+
+	r := EncodeProtoBody(httpsimp.Make(http.MethodPost, srv.URL, "/", nil, nil, nil), wrapperspb.String("world"))
+	var resp wrapperspb.StringValue
+	if err := httpsimp.Do(r, http.DefaultClient, Proto(&resp)); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Value != "hello, world" {
+		t.Fatalf("resp = %q", resp.Value)
+	}
+}
+
+func TestProtoInvalidBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", httpsimp.ContentTypeProtobuf)
+		w.Write([]byte{0xff, 0xff})
+	}))
+	defer srv.Close()
+
+	var resp wrapperspb.StringValue
+	if err := httpsimp.Do(httpsimp.MakeGet(srv.URL, "/", nil, nil), http.DefaultClient, Proto(&resp)); err == nil {
+		t.Fatal("err is nil for an invalid message")
+	}
+}
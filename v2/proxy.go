@@ -0,0 +1,66 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+/*
+Proxy routes all requests made by the client through the given proxy
+(like "http://egress.internal:3128"), ignoring the HTTP_PROXY, HTTPS_PROXY
+and NO_PROXY environment variables. A nil URL restores the default of
+using the environment.
+
+To route only some requests through a proxy, use WithProxy.
+*/
+func Proxy(proxyURL *url.URL) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.proxy = proxyURL
+		c.transportChanged = true
+	})
+}
+
+type proxyKey struct{}
+
+/*
+WithProxy routes the request through the given proxy, overriding the proxy
+configured for the Client and the environment variables. For example, to let
+vendor calls exit via a static-IP egress proxy while the rest go direct:
+
+    r := httpsimp.MakeWith(http.MethodPost, vendorURL, "/orders",
+        httpsimp.WithProxy(egressProxy),
+        httpsimp.WithJSONBody(order))
+
+This requires the request to be sent via a Client (or another client whose
+transport uses the proxy func returned by ProxyFunc).
+*/
+func WithProxy(proxyURL *url.URL) RequestOption {
+	return func(r *http.Request) *http.Request {
+		return r.WithContext(context.WithValue(r.Context(), proxyKey{}, proxyURL))
+	}
+}
+
+/*
+ProxyFunc returns a function suitable for http.Transport's Proxy field that
+honors WithProxy, and otherwise uses the given fallback (which can be nil
+for no proxy, or http.ProxyFromEnvironment).
+*/
+func ProxyFunc(fallback func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(r *http.Request) (*url.URL, error) {
+		if u, ok := r.Context().Value(proxyKey{}).(*url.URL); ok && u != nil {
+			return u, nil
+		}
+		if fallback == nil {
+			return nil, nil
+		}
+		return fallback(r)
+	}
+}
+
+func (c *clientConfig) proxyFunc() func(*http.Request) (*url.URL, error) {
+	if c.proxy != nil {
+		return ProxyFunc(http.ProxyURL(c.proxy))
+	}
+	return ProxyFunc(http.ProxyFromEnvironment)
+}
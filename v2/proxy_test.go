@@ -0,0 +1,51 @@
+package httpsimp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte("direct"))
+	}))
+	defer target.Close()
+	newProxy := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ContentTypeTextPlain)
+			w.Write([]byte(name + " " + r.URL.String()))
+		}))
+	}
+	egress, other := newProxy("egress"), newProxy("other")
+	defer egress.Close()
+	defer other.Close()
+	egressURL, _ := url.Parse(egress.URL)
+	otherURL, _ := url.Parse(other.URL)
+
+	get := func(client HTTPClient, opts ...RequestOption) string {
+		var text string
+		if err := Do(MakeWith(http.MethodGet, target.URL, "/x", opts...), client, PlainText(&text)); err != nil {
+			t.Fatal(err)
+		}
+		return text
+	}
+
+	client := NewClient()
+	if text := get(client); text != "direct" {
+		t.Errorf("without proxy: %q", text)
+	}
+	if text := get(client, WithProxy(egressURL)); text != "egress "+target.URL+"/x" {
+		t.Errorf("with request proxy: %q", text)
+	}
+
+	client = NewClient(Proxy(otherURL))
+	if text := get(client); text != "other "+target.URL+"/x" {
+		t.Errorf("with client proxy: %q", text)
+	}
+	if text := get(client, WithProxy(egressURL)); text != "egress "+target.URL+"/x" {
+		t.Errorf("request proxy should override client proxy: %q", text)
+	}
+}
@@ -0,0 +1,156 @@
+package httpsimp
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+QueryValues converts a struct into url.Values, so that complex filters can
+be defined as typed structs instead of url.Values literals:
+
+    type ListFilter struct {
+        Page    int       `url:"page,omitempty"`
+        Status  []string  `url:"status"`
+        Since   time.Time `url:"since,omitempty"`
+        Verbose bool      `url:"verbose,omitempty"`
+        Secret  string    `url:"-"`
+    }
+    r := httpsimp.MakeGet(baseURL, "/items", httpsimp.QueryValues(filter), nil)
+
+The url tag sets the parameter name (the field name is used by default);
+omitempty skips zero values, and "-" skips the field entirely. Slices produce
+repeated parameters, nil pointers are skipped, and embedded structs are
+flattened. time.Time values are formatted per RFC 3339; types implementing
+encoding.TextMarshaler or fmt.Stringer are formatted using those.
+
+v can be a struct, a pointer to one (nil results in empty values),
+url.Values or map[string]string.
+*/
+func QueryValues(v interface{}) url.Values {
+	values := url.Values{}
+	switch v := v.(type) {
+	case nil:
+		return values
+	case url.Values:
+		return v
+	case map[string]string:
+		for k, s := range v {
+			values.Set(k, s)
+		}
+		return values
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return values
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("httpsimp.QueryValues: expected a struct, got %T", v))
+	}
+	addStructQueryValues(values, rv)
+	return values
+}
+
+func addStructQueryValues(values url.Values, rv reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		fv := rv.Field(i)
+		tag := f.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.IndexByte(tag, ','); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+		omitEmpty := opts == "omitempty"
+
+		if f.Anonymous && name == "" {
+			for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				addStructQueryValues(values, fv)
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if (fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface) && fv.IsNil() {
+			continue
+		}
+		if omitEmpty && isZeroQueryValue(fv) {
+			continue
+		}
+
+		if (fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8) || fv.Kind() == reflect.Array {
+			for j := 0; j < fv.Len(); j++ {
+				values.Add(name, formatQueryValue(fv.Index(j)))
+			}
+			continue
+		}
+		values.Add(name, formatQueryValue(fv))
+	}
+}
+
+func isZeroQueryValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.IsZero()
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+func formatQueryValue(v reflect.Value) string {
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case encoding.TextMarshaler:
+		if b, err := x.MarshalText(); err == nil {
+			return string(b)
+		}
+	case fmt.Stringer:
+		return x.String()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,66 @@
+package httpsimp
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type queryPaging struct {
+	Page    int `url:"page,omitempty"`
+	PerPage int `url:"per_page"`
+}
+
+type queryFilter struct {
+	queryPaging
+	Status  []string  `url:"status"`
+	Since   time.Time `url:"since,omitempty"`
+	Until   time.Time `url:"until,omitempty"`
+	Verbose bool      `url:"verbose,omitempty"`
+	Limit   *int      `url:"limit"`
+	Offset  *int      `url:"offset"`
+	Secret  string    `url:"-"`
+	Query   string
+	Ratio   float64 `url:"ratio,omitempty"`
+	hidden  string
+}
+
+func TestQueryValues(t *testing.T) {
+	limit := 10
+	f := &queryFilter{
+		queryPaging: queryPaging{PerPage: 50},
+		Status:      []string{"open", "closed"},
+		Since:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Limit:       &limit,
+		Secret:      "x",
+		Query:       "a b",
+		Ratio:       0.25,
+		hidden:      "y",
+	}
+	expected := url.Values{
+		"per_page": {"50"},
+		"status":   {"open", "closed"},
+		"since":    {"2024-01-02T03:04:05Z"},
+		"limit":    {"10"},
+		"Query":    {"a b"},
+		"ratio":    {"0.25"},
+	}
+	if actual := QueryValues(f); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %v, wanted %v", actual, expected)
+	}
+
+	if actual := QueryValues((*queryFilter)(nil)); len(actual) != 0 {
+		t.Errorf("nil pointer: got %v", actual)
+	}
+	if actual := QueryValues(map[string]string{"a": "b"}); actual.Get("a") != "b" {
+		t.Errorf("map: got %v", actual)
+	}
+}
+
+func TestQueryValuesInMakeGet(t *testing.T) {
+	r := MakeGet("https://example.com", "/items", QueryValues(queryPaging{Page: 2, PerPage: 20}), nil)
+	if r.URL.RawQuery != "page=2&per_page=20" {
+		t.Errorf("RawQuery = %q", r.URL.RawQuery)
+	}
+}
@@ -0,0 +1,199 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+RateLimitTracker watches the rate limit headers returned by APIs like
+api.github.com (X-RateLimit-Limit, X-RateLimit-Remaining and
+X-RateLimit-Reset, or their unprefixed RateLimit-* counterparts) and keeps
+track of the remaining request budget per host.
+
+Use Client to wrap an HTTPClient:
+
+    limits := httpsimp.NewRateLimitTracker(10)
+    client := limits.Client(&http.Client{Timeout: 10 * time.Second})
+
+When the remaining budget of a host drops to the reserve passed into
+NewRateLimitTracker, further requests to that host wait until the limit
+resets (or the request's context is done), instead of burning the rest
+of the quota and running into 429 errors.
+*/
+type RateLimitTracker struct {
+	reserve int
+
+	mu     sync.Mutex
+	limits map[string]*hostRateLimit
+
+	now  func() time.Time
+	wait func(ctx context.Context, d time.Duration) error
+}
+
+/*
+RateLimit describes the request budget of a single host,
+as reported by the latest response.
+*/
+type RateLimit struct {
+	// Limit is the maximum number of requests allowed per window,
+	// or 0 if not reported.
+	Limit int
+
+	// Remaining is the number of requests left in the current window,
+	// minus the requests currently in flight.
+	Remaining int
+
+	// Reset is the time when the current window ends.
+	Reset time.Time
+}
+
+/*
+NewRateLimitTracker returns a tracker that delays requests once the
+remaining budget of a host drops to the given reserve (pass 0 to only
+delay when the budget is fully exhausted).
+*/
+func NewRateLimitTracker(reserve int) *RateLimitTracker {
+	return &RateLimitTracker{
+		reserve: reserve,
+		limits:  make(map[string]*hostRateLimit),
+		now:     time.Now,
+		wait:    sleepContext,
+	}
+}
+
+/*
+Budget returns the current rate limit of the given host (as in URL.Host,
+e.g. "api.github.com"), and false if no rate limit has been reported yet.
+*/
+func (t *RateLimitTracker) Budget(host string) (RateLimit, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if rl := t.limits[host]; rl != nil && rl.reported {
+		result := rl.RateLimit
+		result.Remaining -= rl.inFlight
+		return result, true
+	}
+	return RateLimit{}, false
+}
+
+/*
+Client returns an HTTPClient that sends requests via the given client,
+tracking rate limits and delaying requests when the budget is low.
+*/
+func (t *RateLimitTracker) Client(client HTTPClient) HTTPClient {
+	return &rateLimitClient{t, client}
+}
+
+type rateLimitClient struct {
+	tracker *RateLimitTracker
+	client  HTTPClient
+}
+
+func (c *rateLimitClient) Do(r *http.Request) (*http.Response, error) {
+	host := r.URL.Host
+	if err := c.tracker.acquire(r.Context(), host); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(r)
+	var h http.Header
+	if err == nil {
+		h = resp.Header
+	}
+	c.tracker.update(host, h)
+	return resp, err
+}
+
+type hostRateLimit struct {
+	RateLimit
+	reported bool
+	inFlight int
+}
+
+// acquire waits until the host has budget for one more request,
+// and reserves it.
+func (t *RateLimitTracker) acquire(ctx context.Context, host string) error {
+	for {
+		t.mu.Lock()
+		rl := t.limits[host]
+		if rl == nil {
+			rl = new(hostRateLimit)
+			t.limits[host] = rl
+		}
+		delay := rl.Reset.Sub(t.now())
+		if rl.Reset.IsZero() || delay <= 0 || rl.Remaining-rl.inFlight > t.reserve {
+			rl.inFlight++
+			t.mu.Unlock()
+			return nil
+		}
+		t.mu.Unlock()
+
+		if err := t.wait(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// update releases the reservation made by acquire and records the budget
+// reported by the response headers, if any.
+func (t *RateLimitTracker) update(host string, h http.Header) {
+	now := t.now()
+	remaining, ok := rateLimitHeader(h, "Remaining")
+	limit, _ := rateLimitHeader(h, "Limit")
+	var reset time.Time
+	if v, ok := rateLimitHeader(h, "Reset"); ok {
+		if v > 1000000000 {
+			reset = time.Unix(int64(v), 0) // epoch seconds (GitHub)
+		} else {
+			reset = now.Add(time.Duration(v) * time.Second) // delta seconds (IETF draft)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rl := t.limits[host]
+	rl.inFlight--
+	if !ok {
+		return
+	}
+	// responses can arrive out of order, so within the same window
+	// trust the lowest remaining count
+	if !reset.IsZero() && rl.Reset.Equal(reset) && remaining > rl.Remaining {
+		return
+	}
+	rl.Limit = limit
+	rl.Remaining = remaining
+	rl.Reset = reset
+	rl.reported = true
+}
+
+func rateLimitHeader(h http.Header, name string) (int, bool) {
+	v := h.Get("X-RateLimit-" + name)
+	if v == "" {
+		v = h.Get("RateLimit-" + name)
+	}
+	if v == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
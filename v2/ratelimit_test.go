@@ -0,0 +1,84 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimitTracker(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	reset := now.Add(time.Minute)
+	remaining := 3
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remaining--
+		w.Header().Set("X-RateLimit-Limit", "5")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	limits := NewRateLimitTracker(1)
+	limits.now = func() time.Time { return now }
+	var waits []time.Duration
+	limits.wait = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		now = now.Add(d)
+		remaining = 5
+		reset = now.Add(time.Minute)
+		return nil
+	}
+	client := limits.Client(http.DefaultClient)
+	host := mustParseURL(srv.URL).Host
+
+	if _, ok := limits.Budget(host); ok {
+		t.Fatal("budget known before the first request")
+	}
+	for i := 0; i < 3; i++ {
+		if err := Do(MakeGet(srv.URL, "", nil, nil), client, None()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(waits) != 1 || waits[0] != time.Minute {
+		t.Fatalf("waits = %v, wanted one wait of 1m", waits)
+	}
+
+	rl, ok := limits.Budget(host)
+	if !ok || rl.Limit != 5 || rl.Remaining != 4 || !rl.Reset.Equal(now.Add(time.Minute)) {
+		t.Fatalf("unexpected budget: %+v", rl)
+	}
+}
+
+func TestRateLimitTrackerCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Remaining", "0")
+		w.Header().Set("RateLimit-Reset", "3600")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := NewRateLimitTracker(0).Client(http.DefaultClient)
+	if err := Do(MakeGet(srv.URL, "", nil, nil), client, None()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := Do(MakeGet(srv.URL, "", nil, nil).WithContext(ctx), client, None())
+	if err == nil {
+		t.Fatal("err is nil, wanted context deadline error")
+	}
+}
+
+func mustParseURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
@@ -0,0 +1,82 @@
+package httpsimp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+/*
+ConcurrentModificationError is returned by ReadModifyWrite when the resource
+kept changing between reading and writing it (i.e. the server kept
+responding with 412 Precondition Failed). It wraps the error of the last
+write, so errors.Is(err, ErrPreconditionFailed) holds too.
+*/
+type ConcurrentModificationError struct {
+	// ETag is the version the last write was based on.
+	ETag     string
+	Attempts int
+	Cause    error
+}
+
+func (err *ConcurrentModificationError) Error() string {
+	return fmt.Sprintf("resource modified concurrently (%d attempts, last ETag %s): %v", err.Attempts, err.ETag, err.Cause)
+}
+
+// Unwrap returns the underlying error.
+func (err *ConcurrentModificationError) Unwrap() error {
+	return err.Cause
+}
+
+/*
+ReadModifyWrite updates a JSON resource using optimistic concurrency:
+it GETs the resource, remembering its ETag, calls modify on the decoded
+value, and sends it back with the given method (PUT or PATCH) and
+an If-Match header. If the resource has been changed in the meantime
+and the server responds with 412 Precondition Failed, the whole cycle
+is repeated, up to maxAttempts times:
+
+    err := httpsimp.ReadModifyWrite(ctx, client, http.MethodPut, baseURL, "/docs/42", 3,
+        func(doc *Document) error {
+            doc.Tags = append(doc.Tags, "reviewed")
+            return nil
+        },
+        httpsimp.WithBasicAuth(user, password))
+
+The options apply to both requests. An error returned by modify aborts
+the update and is returned as is. If the attempts run out, the error is
+a *ConcurrentModificationError. A GET response without an ETag is
+an error, since the write could not be made conditional.
+*/
+func ReadModifyWrite[T any](ctx context.Context, client HTTPClient, method, base, path string, maxAttempts int, modify func(v *T) error, opts ...RequestOption) error {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	ctxOpt := WithContext(ctx)
+	for attempt := 1; ; attempt++ {
+		var v T
+		var h http.Header
+		get := MakeWith(http.MethodGet, base, path, append(opts[:len(opts):len(opts)], ctxOpt)...)
+		if err := Do(get, client, JSON(&v), CaptureHeader(&h)); err != nil {
+			return err
+		}
+		etag := h.Get("ETag")
+		if etag == "" {
+			return fmt.Errorf("GET %s: response has no ETag, cannot update safely", redactedURL(get.URL))
+		}
+
+		if err := modify(&v); err != nil {
+			return err
+		}
+
+		put := MakeWith(method, base, path, append(opts[:len(opts):len(opts)], ctxOpt, WithJSONBody(v), WithHeaderValue("If-Match", etag))...)
+		err := Do(put, client, None())
+		if err == nil || !errors.Is(err, ErrPreconditionFailed) {
+			return err
+		}
+		if attempt >= maxAttempts {
+			return &ConcurrentModificationError{etag, attempt, err}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package httpsimp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type versionedDoc struct {
+	Tags []string `json:"tags"`
+}
+
+// newVersionedServer serves a JSON document with an ETag, changing it
+// behind the client's back for the first interference writes.
+func newVersionedServer(t *testing.T, interference int) (*httptest.Server, *versionedDoc) {
+	var mu sync.Mutex
+	doc, version := &versionedDoc{}, 1
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		etag := `"` + strconv.Itoa(version) + `"`
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			json.NewEncoder(w).Encode(doc)
+		case http.MethodPut:
+			if interference > 0 {
+				interference--
+				doc.Tags = append(doc.Tags, "other")
+				version++
+				etag = `"` + strconv.Itoa(version) + `"`
+			}
+			if r.Header.Get("If-Match") != etag {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			if err := json.NewDecoder(r.Body).Decode(doc); err != nil {
+				t.Error(err)
+			}
+			version++
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})), doc
+}
+
+func TestReadModifyWrite(t *testing.T) {
+	srv, doc := newVersionedServer(t, 1)
+	defer srv.Close()
+
+	var calls int
+	err := ReadModifyWrite(context.Background(), http.DefaultClient, http.MethodPut, srv.URL, "/doc", 3, func(d *versionedDoc) error {
+		calls++
+		d.Tags = append(d.Tags, "reviewed")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || len(doc.Tags) != 2 || doc.Tags[0] != "other" || doc.Tags[1] != "reviewed" {
+		t.Errorf("calls = %d, tags = %q", calls, doc.Tags)
+	}
+}
+
+func TestReadModifyWriteConflict(t *testing.T) {
+	srv, _ := newVersionedServer(t, 5)
+	defer srv.Close()
+
+	err := ReadModifyWrite(context.Background(), http.DefaultClient, http.MethodPut, srv.URL, "/doc", 2, func(d *versionedDoc) error {
+		return nil
+	})
+	var cme *ConcurrentModificationError
+	if !errors.As(err, &cme) || cme.Attempts != 2 || !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("err = %v", err)
+	}
+
+	abort := errors.New("abort")
+	err = ReadModifyWrite(context.Background(), http.DefaultClient, http.MethodPut, srv.URL, "/doc", 2, func(d *versionedDoc) error {
+		return abort
+	})
+	if err != abort {
+		t.Errorf("err = %v", err)
+	}
+}
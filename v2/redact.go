@@ -0,0 +1,167 @@
+package httpsimp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// redactedMask replaces secret values in error messages.
+const redactedMask = "xxxxx"
+
+var (
+	redactMu       sync.RWMutex
+	redactedFields = []string{"password", "passwd", "secret", "token", "api_key", "apikey"}
+)
+
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+/*
+RedactFields adds names of body fields whose values are masked whenever this
+package stringifies a response body, e.g. in ResponseError messages. A field
+is masked if its name contains one of the registered names, ignoring case.
+By default, these are password, passwd, secret, token, api_key and apikey,
+which also covers names like access_token and client_secret.
+
+Only bodies decoded from JSON (or JSON text) are inspected. Authorization,
+Cookie and similar headers are always masked, see RedactedHeader.
+
+RedactFields is typically called during initialization; it is safe for
+concurrent use.
+*/
+func RedactFields(names ...string) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	for _, name := range names {
+		redactedFields = append(redactedFields, strings.ToLower(name))
+	}
+}
+
+/*
+RedactedHeader returns a copy of the given header with the values of
+headers carrying credentials (Authorization, Proxy-Authorization, Cookie,
+Set-Cookie and X-Api-Key) masked, suitable for logging.
+*/
+func RedactedHeader(h http.Header) http.Header {
+	result := make(http.Header, len(h))
+	for k, v := range h {
+		if isSensitiveHeader(k) {
+			masked := make([]string, len(v))
+			for i := range masked {
+				masked[i] = redactedMask
+			}
+			v = masked
+		}
+		result[k] = v
+	}
+	return result
+}
+
+func isSensitiveHeader(name string) bool {
+	return sensitiveHeaders[http.CanonicalHeaderKey(name)]
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	for _, f := range redactedFields {
+		if strings.Contains(name, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactBody returns the given decoded body with the values of sensitive
+// fields masked, or the body itself if there's nothing to mask.
+func redactBody(body interface{}) interface{} {
+	switch v := body.(type) {
+	case nil, bool, float64, json.Number, int, int64:
+		return body
+	case string:
+		s := strings.TrimSpace(v)
+		if !(strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")) {
+			return body
+		}
+		var decoded interface{}
+		if json.Unmarshal([]byte(s), &decoded) != nil {
+			return body
+		}
+		if redacted, changed := redactValue(decoded); changed {
+			if b, err := json.Marshal(redacted); err == nil {
+				return string(b)
+			}
+		}
+		return body
+	case map[string]interface{}, []interface{}:
+		redacted, _ := redactValue(v)
+		return redacted
+	default:
+		// a struct or a typed map; inspect its JSON form
+		b, err := json.Marshal(body)
+		if err != nil {
+			return body
+		}
+		var decoded interface{}
+		if json.Unmarshal(b, &decoded) != nil {
+			return body
+		}
+		if redacted, changed := redactValue(decoded); changed {
+			return redacted
+		}
+		return body
+	}
+}
+
+func redactValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		var result map[string]interface{}
+		for k, item := range v {
+			var changed bool
+			if isSensitiveField(k) {
+				item, changed = redactedMask, true
+			} else {
+				item, changed = redactValue(item)
+			}
+			if changed && result == nil {
+				result = make(map[string]interface{}, len(v))
+				for k2, item2 := range v {
+					result[k2] = item2
+				}
+			}
+			if result != nil {
+				result[k] = item
+			}
+		}
+		if result == nil {
+			return v, false
+		}
+		return result, true
+	case []interface{}:
+		var result []interface{}
+		for i, item := range v {
+			item, changed := redactValue(item)
+			if changed && result == nil {
+				result = append([]interface{}(nil), v...)
+			}
+			if result != nil {
+				result[i] = item
+			}
+		}
+		if result == nil {
+			return v, false
+		}
+		return result, true
+	default:
+		return v, false
+	}
+}
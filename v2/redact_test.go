@@ -0,0 +1,55 @@
+package httpsimp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	type loginError struct {
+		Message     string `json:"message"`
+		AccessToken string `json:"access_token"`
+	}
+	tests := []struct {
+		body     interface{}
+		expected string
+	}{
+		{map[string]interface{}{"user": "bob", "password": "hunter2"}, "map[password:xxxxx user:bob]"},
+		{[]interface{}{map[string]interface{}{"Client_Secret": "s"}}, "[map[Client_Secret:xxxxx]]"},
+		{`{"error":"bad","token":"abc"}`, `{"error":"bad","token":"xxxxx"}`},
+		{"password is wrong", "password is wrong"},
+		{loginError{"denied", "abc"}, "map[access_token:xxxxx message:denied]"},
+		{struct{ Message string }{"denied"}, "{denied}"},
+	}
+	for _, tt := range tests {
+		err := &ResponseError{StatusCode: 400, ContentType: ContentTypeJSON, ContentTypeOK: true, Body: tt.body}
+		if actual := err.Error(); !strings.HasSuffix(actual, ": "+tt.expected) {
+			t.Errorf("%v: got %q, wanted body %q", tt.body, actual, tt.expected)
+		}
+	}
+}
+
+func TestRedactFields(t *testing.T) {
+	RedactFields("SSN")
+	defer func() {
+		redactMu.Lock()
+		redactedFields = redactedFields[:len(redactedFields)-1]
+		redactMu.Unlock()
+	}()
+	err := &ResponseError{StatusCode: 400, ContentTypeOK: true, Body: map[string]interface{}{"ssn": "123"}}
+	if strings.Contains(err.Error(), "123") {
+		t.Errorf("ssn not redacted in %q", err.Error())
+	}
+}
+
+func TestRedactedHeader(t *testing.T) {
+	h := http.Header{"Authorization": {"Bearer abc"}, "Cookie": {"a=1", "b=2"}, "Accept": {"*/*"}}
+	r := RedactedHeader(h)
+	if r.Get("Authorization") != "xxxxx" || len(r["Cookie"]) != 2 || r["Cookie"][1] != "xxxxx" || r.Get("Accept") != "*/*" {
+		t.Errorf("RedactedHeader = %v", r)
+	}
+	if h.Get("Authorization") != "Bearer abc" {
+		t.Error("original header modified")
+	}
+}
@@ -0,0 +1,218 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+/*
+RequestOption modifies a request being built by MakeWith or Apply.
+Any function of this shape works, so request helpers like GzipBody can be
+used as options directly, and others can be adapted with a closure.
+*/
+type RequestOption func(r *http.Request) *http.Request
+
+/*
+MakeWith builds a request with the given method and URL, configured by
+the given options. It is an alternative to the other Make functions
+that avoids passing nil for unused params and headers:
+
+    r := httpsimp.MakeWith(http.MethodPost, baseURL, "/items",
+        httpsimp.WithQuery("dry_run", "1"),
+        httpsimp.WithBasicAuth(user, password),
+        httpsimp.WithJSONBody(item))
+
+base and path are concatenated to form a URL; at least one of them must be
+provided, but the other one can be an empty string. The resulting URL must be
+valid and parsable via net/url, otherwise Do returns a *BuildError.
+*/
+func MakeWith(method string, base, path string, opts ...RequestOption) *http.Request {
+	return Apply(makeRequest(method, base, path, nil, make(http.Header)), opts...)
+}
+
+/*
+Apply applies the given options to the given request in order.
+*/
+func Apply(r *http.Request, opts ...RequestOption) *http.Request {
+	for _, o := range opts {
+		r = o(r)
+	}
+	return r
+}
+
+/*
+WithHeader adds a value to the given request header.
+*/
+func WithHeader(name, value string) RequestOption {
+	return func(r *http.Request) *http.Request {
+		if r.Header == nil {
+			r.Header = make(http.Header)
+		}
+		r.Header.Add(name, value)
+		return r
+	}
+}
+
+/*
+WithHeaderValue sets the given request header, replacing any existing values.
+*/
+func WithHeaderValue(name, value string) RequestOption {
+	return func(r *http.Request) *http.Request {
+		if r.Header == nil {
+			r.Header = make(http.Header)
+		}
+		r.Header.Set(name, value)
+		return r
+	}
+}
+
+/*
+WithHeaders adds all values of the given headers to the request.
+*/
+func WithHeaders(h http.Header) RequestOption {
+	return func(r *http.Request) *http.Request {
+		if r.Header == nil {
+			r.Header = make(http.Header, len(h))
+		}
+		for k, v := range h {
+			r.Header[k] = append(r.Header[k], v...)
+		}
+		return r
+	}
+}
+
+/*
+WithQuery adds a parameter to the query string of the request URL.
+*/
+func WithQuery(name, value string) RequestOption {
+	return func(r *http.Request) *http.Request {
+		if r.URL == nil {
+			return r
+		}
+		values := r.URL.Query()[name]
+		return WithParams(url.Values{name: append(values, value)})(r)
+	}
+}
+
+/*
+WithParams adds the given params to the query string of the request URL,
+replacing any parameters of the same names, like the params argument of
+the Make functions does.
+*/
+func WithParams(params url.Values) RequestOption {
+	return func(r *http.Request) *http.Request {
+		if r.URL == nil {
+			return r
+		}
+		rawQuery, err := mergeQuery(r.URL.RawQuery, params)
+		if err != nil {
+			return setBuildError(r, err)
+		}
+		u := *r.URL
+		u.RawQuery = rawQuery
+		r.URL = &u
+		return r
+	}
+}
+
+/*
+WithBasicAuth sets the Authorization header for HTTP Basic authentication.
+*/
+func WithBasicAuth(username, password string) RequestOption {
+	return WithHeaderValue(AuthorizationHeader, BasicAuthValue(username, password))
+}
+
+/*
+WithContext makes the request use the given context for cancelation and
+deadlines. Unlike http.Request.WithContext, it keeps the settings this
+package stores on the request (like build errors, ForEndpoint or
+TransformRequestBody), so it can be used in any position.
+*/
+func WithContext(ctx context.Context) RequestOption {
+	return func(r *http.Request) *http.Request {
+		return r.WithContext(&layeredContext{ctx, r.Context()})
+	}
+}
+
+// layeredContext is ctx with values falling back to prev.
+type layeredContext struct {
+	context.Context
+	prev context.Context
+}
+
+func (c *layeredContext) Value(key interface{}) interface{} {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+	return c.prev.Value(key)
+}
+
+/*
+WithTimeout limits the time Do may spend on the request, including
+retries and reading the response body, to the given duration.
+Do derives a context with the deadline and cancels it when done,
+so there's no context to manage; with Raw, the context is canceled
+when the caller closes the body.
+
+Unlike a client-wide timeout, this applies to a single request; use it
+when some requests are expected to take much longer (or shorter) than others.
+Like ForEndpoint, the timeout is kept in the request context, so set the
+context with WithContext rather than http.Request.WithContext.
+*/
+func WithTimeout(d time.Duration) RequestOption {
+	return func(r *http.Request) *http.Request {
+		return r.WithContext(context.WithValue(r.Context(), timeoutKey{}, d))
+	}
+}
+
+/*
+RequestTimeout returns the duration set by WithTimeout, if any.
+*/
+func RequestTimeout(r *http.Request) time.Duration {
+	d, _ := r.Context().Value(timeoutKey{}).(time.Duration)
+	return d
+}
+
+type timeoutKey struct{}
+
+// withRequestTimeout applies WithTimeout, returning the function to cancel
+// the derived context.
+func withRequestTimeout(r *http.Request) (*http.Request, context.CancelFunc) {
+	d, ok := r.Context().Value(timeoutKey{}).(time.Duration)
+	if !ok || d <= 0 {
+		return r, nil
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), d)
+	return r.WithContext(ctx), cancel
+}
+
+/*
+WithJSONBody encodes the given object as the JSON request body,
+see EncodeJSONBody.
+*/
+func WithJSONBody(obj interface{}) RequestOption {
+	return func(r *http.Request) *http.Request {
+		return EncodeJSONBody(r, obj)
+	}
+}
+
+/*
+WithFormBody encodes the given params as the request body in
+application/x-www-form-urlencoded format, see EncodeForm.
+*/
+func WithFormBody(params url.Values) RequestOption {
+	return func(r *http.Request) *http.Request {
+		return EncodeForm(r, params)
+	}
+}
+
+/*
+WithBody sets the request body to the given data, see SetBody.
+*/
+func WithBody(data []byte) RequestOption {
+	return func(r *http.Request) *http.Request {
+		return SetBody(r, data)
+	}
+}
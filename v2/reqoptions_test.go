@@ -0,0 +1,92 @@
+package httpsimp
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestMakeWith(t *testing.T) {
+	r := MakeWith(http.MethodPost, "https://example.com/api?key=abc", "/items",
+		WithQuery("tag", "a"),
+		WithQuery("tag", "b c"),
+		WithParams(url.Values{"key": {"xyz"}}),
+		WithHeader("X-Foo", "1"),
+		WithHeaders(http.Header{"X-Foo": {"2"}}),
+		WithBasicAuth("user", "pw"),
+		WithJSONBody(map[string]int{"a": 1}))
+
+	if err := requestBuildError(r); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := r.URL.String(), "https://example.com/api/items?tag=a&tag=b%20c&key=xyz"; actual != expected {
+		t.Errorf("URL = %q, wanted %q", actual, expected)
+	}
+	if actual := r.Header["X-Foo"]; len(actual) != 2 || actual[0] != "1" || actual[1] != "2" {
+		t.Errorf("X-Foo = %v", actual)
+	}
+	if user, pw, ok := r.BasicAuth(); !ok || user != "user" || pw != "pw" {
+		t.Errorf("BasicAuth = %q, %q, %v", user, pw, ok)
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	if string(body) != `{"a":1}` || r.Header.Get("Content-Type") != ContentTypeJSON {
+		t.Errorf("body = %q (%s)", body, r.Header.Get("Content-Type"))
+	}
+}
+
+func TestWithContextKeepsBuildError(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "v")
+	r := MakeWith(http.MethodGet, "://bad", "/x", WithContext(ctx))
+	if requestBuildError(r) == nil {
+		t.Error("build error lost")
+	}
+	if r.Context().Value(key{}) != "v" {
+		t.Error("context value not set")
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	err := Do(MakeWith(http.MethodGet, srv.URL, "/slow", WithTimeout(50*time.Millisecond)), http.DefaultClient, None())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("timeout not applied")
+	}
+
+	var s string
+	err = Do(MakeWith(http.MethodGet, srv.URL, "/fast", WithTimeout(5*time.Second)), http.DefaultClient, PlainText(&s))
+	if err != nil || s != "ok" {
+		t.Errorf("got %q, %v", s, err)
+	}
+
+	var resp *http.Response
+	err = Do(MakeWith(http.MethodGet, srv.URL, "/fast", WithTimeout(5*time.Second)), http.DefaultClient, Raw(&resp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(body) != "ok" {
+		t.Errorf("Raw body = %q, %v", body, err)
+	}
+}
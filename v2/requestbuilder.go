@@ -0,0 +1,103 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+/*
+RequestBuilder builds a request via chained calls, for those who prefer
+this style to the Make functions:
+
+    err := httpsimp.NewRequest(http.MethodPost, baseURL, "/items").
+        Query("dry_run", "1").
+        Header("X-Foo", "1").
+        JSONBody(item).
+        Do(ctx, client, httpsimp.JSON(&resp))
+
+Each method applies the corresponding RequestOption (see MakeWith), so errors
+are reported by Do as a *BuildError. A RequestBuilder is not safe for
+concurrent use, and must not be reused after Do.
+*/
+type RequestBuilder struct {
+	r *http.Request
+}
+
+/*
+NewRequest starts building a request with the given method and URL.
+base and path are concatenated like in the Make functions.
+*/
+func NewRequest(method string, base, path string) *RequestBuilder {
+	return &RequestBuilder{MakeWith(method, base, path)}
+}
+
+// With applies the given options to the request.
+func (b *RequestBuilder) With(opts ...RequestOption) *RequestBuilder {
+	b.r = Apply(b.r, opts...)
+	return b
+}
+
+// Query adds a query string parameter, see WithQuery.
+func (b *RequestBuilder) Query(name, value string) *RequestBuilder {
+	return b.With(WithQuery(name, value))
+}
+
+// Params adds query string parameters, see WithParams.
+func (b *RequestBuilder) Params(params url.Values) *RequestBuilder {
+	return b.With(WithParams(params))
+}
+
+// Header adds a header value, see WithHeader.
+func (b *RequestBuilder) Header(name, value string) *RequestBuilder {
+	return b.With(WithHeader(name, value))
+}
+
+// Headers adds header values, see WithHeaders.
+func (b *RequestBuilder) Headers(h http.Header) *RequestBuilder {
+	return b.With(WithHeaders(h))
+}
+
+// BasicAuth sets HTTP Basic credentials, see WithBasicAuth.
+func (b *RequestBuilder) BasicAuth(username, password string) *RequestBuilder {
+	return b.With(WithBasicAuth(username, password))
+}
+
+// Timeout limits the time spent on the request, see WithTimeout.
+func (b *RequestBuilder) Timeout(d time.Duration) *RequestBuilder {
+	return b.With(WithTimeout(d))
+}
+
+// JSONBody sets the body to the given object encoded as JSON, see WithJSONBody.
+func (b *RequestBuilder) JSONBody(obj interface{}) *RequestBuilder {
+	return b.With(WithJSONBody(obj))
+}
+
+// FormBody sets a form-encoded body, see WithFormBody.
+func (b *RequestBuilder) FormBody(params url.Values) *RequestBuilder {
+	return b.With(WithFormBody(params))
+}
+
+// Body sets the body to the given data, see WithBody.
+func (b *RequestBuilder) Body(data []byte) *RequestBuilder {
+	return b.With(WithBody(data))
+}
+
+// Request returns the built request.
+func (b *RequestBuilder) Request() *http.Request {
+	return b.r
+}
+
+/*
+Do sends the request using the given context (which can be nil to keep
+the one set via WithContext) and handles the response like the package-level
+Do function.
+*/
+func (b *RequestBuilder) Do(ctx context.Context, client HTTPClient, parsers ...Parser) error {
+	r := b.r
+	if ctx != nil {
+		r = WithContext(ctx)(r)
+	}
+	return Do(r, client, parsers...)
+}
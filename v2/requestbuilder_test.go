@@ -0,0 +1,36 @@
+package httpsimp
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte(r.Method + " " + r.URL.RequestURI() + " " + r.Header.Get("X-Foo") + " " + string(body)))
+	}))
+	defer srv.Close()
+
+	var s string
+	err := NewRequest(http.MethodPost, srv.URL, "/items").
+		Query("q", "x").
+		Header("X-Foo", "1").
+		JSONBody(map[string]int{"a": 1}).
+		Do(context.Background(), http.DefaultClient, PlainText(&s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `POST /items?q=x 1 {"a":1}`; s != expected {
+		t.Errorf("got %q, wanted %q", s, expected)
+	}
+
+	err = NewRequest(http.MethodGet, "://bad", "/").Do(nil, http.DefaultClient, None())
+	if _, ok := err.(*BuildError); !ok {
+		t.Errorf("expected *BuildError, got %v", err)
+	}
+}
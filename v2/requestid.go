@@ -0,0 +1,83 @@
+package httpsimp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+// RequestIDHeader is the header carrying the request ID, see RequestID.
+const RequestIDHeader = "X-Request-Id"
+
+/*
+RequestID makes the client send an X-Request-Id header with every request
+that doesn't have one. The ID is taken from the request's context if set
+via ContextWithRequestID (e.g. to propagate the ID of an incoming request),
+or generated by the given function otherwise (nil means random 128-bit hex
+strings).
+
+The ID is reported by ResponseMeta and included in errors returned by Do;
+use RequestIDOf to extract it from an error.
+*/
+func RequestID(generate func() string) ClientOption {
+	if generate == nil {
+		generate = randomRequestID
+	}
+	return clientOptionFunc(func(c *clientConfig) {
+		c.requestID = generate
+	})
+}
+
+type requestIDKey struct{}
+
+/*
+ContextWithRequestID returns a copy of ctx carrying the given request ID,
+which the RequestID client option sends instead of generating a new one.
+*/
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+/*
+RequestIDOf returns the request ID mentioned in the given error
+returned by Do, or an empty string.
+*/
+func RequestIDOf(err error) string {
+	var we *wrapperError
+	if errors.As(err, &we) {
+		return we.RequestID
+	}
+	return ""
+}
+
+func (c *clientConfig) newRequestID(r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDKey{}).(string); ok && id != "" {
+		return id
+	}
+	return c.requestID()
+}
+
+func randomRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// requestIDError carries the ID of a request that failed without
+// a response, so that Do can report it.
+type requestIDError struct {
+	id  string
+	err error
+}
+
+func (e *requestIDError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestIDError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,57 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get(RequestIDHeader))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	n := 0
+	client := NewClient(RequestID(func() string {
+		n++
+		return "gen" + strings.Repeat("!", n)
+	}))
+
+	var meta ResponseMeta
+	err := Do(MakeGet(srv.URL, "/x", nil, nil), client, None(), Meta(&meta))
+	if RequestIDOf(err) != "gen!" || meta.RequestID != "gen!" {
+		t.Errorf("request ID = %q, meta %q", RequestIDOf(err), meta.RequestID)
+	}
+	if !strings.Contains(err.Error(), "request ID gen!") {
+		t.Errorf("request ID missing from %q", err.Error())
+	}
+
+	ctx := ContextWithRequestID(context.Background(), "incoming")
+	err = Do(MakeGet(srv.URL, "/x", nil, nil).WithContext(ctx), client, None())
+	if RequestIDOf(err) != "incoming" {
+		t.Errorf("request ID = %q, wanted incoming", RequestIDOf(err))
+	}
+
+	err = Do(MakeGet(srv.URL, "/x", nil, http.Header{RequestIDHeader: {"explicit"}}), client, None())
+	if RequestIDOf(err) != "explicit" {
+		t.Errorf("request ID = %q, wanted explicit", RequestIDOf(err))
+	}
+
+	if expected := []string{"gen!", "incoming", "explicit"}; strings.Join(seen, ",") != strings.Join(expected, ",") {
+		t.Errorf("server saw %v, wanted %v", seen, expected)
+	}
+
+	err = Do(MakeGet("http://127.0.0.1:1", "/x", nil, nil), client, None())
+	if RequestIDOf(err) != "gen!!" {
+		t.Errorf("request ID of transport error = %q", RequestIDOf(err))
+	}
+
+	if id := randomRequestID(); len(id) != 32 {
+		t.Errorf("randomRequestID = %q", id)
+	}
+}
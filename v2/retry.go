@@ -0,0 +1,169 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type retryConfig struct {
+	attempts int
+	statuses []int
+}
+
+/*
+RetryAfter makes the client transparently retry requests that received
+a response with one of the given status codes (429 Too Many Requests if
+none are specified), waiting for the duration requested by the Retry-After
+header (either in seconds or as an HTTP date), or using exponential backoff
+starting at 1 second if there's no such header:
+
+    client := httpsimp.NewClient(httpsimp.RetryAfter(5, http.StatusTooManyRequests, http.StatusServiceUnavailable))
+
+The request is sent at most attempts times. A retry is not attempted if it
+would have to wait past the deadline of the request's context or longer
+than 30 seconds (e.g. Retry-After: 3600), or if the request has a body that
+cannot be replayed (see SetBodyReader); the last response is returned as is
+in those cases.
+*/
+func RetryAfter(attempts int, statuses ...int) ClientOption {
+	if len(statuses) == 0 {
+		statuses = []int{http.StatusTooManyRequests}
+	}
+	rc := &retryConfig{attempts, statuses}
+	return clientOptionFunc(func(c *clientConfig) {
+		c.retry = rc
+	})
+}
+
+func (p *RetryPolicy) config() *retryConfig {
+	statuses := p.Statuses
+	if len(statuses) == 0 {
+		statuses = []int{http.StatusTooManyRequests}
+	}
+	return &retryConfig{p.Attempts, statuses}
+}
+
+func (rc *retryConfig) matches(statusCode int) bool {
+	for _, s := range rc.statuses {
+		if s == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *clientState) doWithRetries(r *http.Request, policy *Policy) (*http.Response, error) {
+	rc := c.config.retry
+	if policy != nil && policy.Retry != nil {
+		rc = policy.Retry.config()
+	}
+	if be := requestBuildError(r); be != nil {
+		return nil, be
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := c.do(r, policy)
+		if err != nil && attempt > 1 {
+			return nil, &attemptError{attempt, err}
+		}
+		if err != nil || rc == nil || attempt >= rc.attempts || !rc.matches(resp.StatusCode) {
+			if attempt > 1 {
+				resp = withResponseAttempt(resp, r, attempt)
+			}
+			return resp, err
+		}
+
+		ctx := r.Context()
+		delay := retryDelay(resp.Header.Get("Retry-After"), attempt, time.Now())
+		if delay > maxRetryBackoff {
+			return withResponseAttempt(resp, r, attempt), nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return withResponseAttempt(resp, r, attempt), nil
+		}
+
+		if r.Body != nil && r.Body != http.NoBody {
+			if r.GetBody == nil {
+				return withResponseAttempt(resp, r, attempt), nil
+			}
+			body, err := r.GetBody()
+			if err != nil {
+				return withResponseAttempt(resp, r, attempt), nil
+			}
+			r = r.Clone(ctx)
+			r.Body = body
+		}
+
+		drainAndClose(resp.Body)
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attemptError carries the attempt number of a retried request that
+// failed, so that Do can report it.
+type attemptError struct {
+	attempt int
+	err     error
+}
+
+func (e *attemptError) Error() string {
+	return e.err.Error()
+}
+
+func (e *attemptError) Unwrap() error {
+	return e.err
+}
+
+type attemptKey struct{}
+
+// withResponseAttempt records the attempt number of a retried request
+// on the response, so that Do can report it.
+func withResponseAttempt(resp *http.Response, r *http.Request, attempt int) *http.Response {
+	if attempt <= 1 {
+		return resp
+	}
+	if resp.Request == nil {
+		resp.Request = r
+	}
+	resp.Request = resp.Request.WithContext(context.WithValue(resp.Request.Context(), attemptKey{}, attempt))
+	return resp
+}
+
+func responseAttempt(resp *http.Response) int {
+	if resp.Request != nil {
+		if attempt, ok := resp.Request.Context().Value(attemptKey{}).(int); ok {
+			return attempt
+		}
+	}
+	return 1
+}
+
+const maxRetryBackoff = 30 * time.Second
+
+func retryDelay(retryAfter string, attempt int, now time.Time) time.Duration {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if retryAfter != "" {
+		if secs, err := strconv.Atoi(retryAfter); err == nil {
+			if secs < 0 {
+				secs = 0
+			}
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(retryAfter); err == nil {
+			if d := t.Sub(now); d > 0 {
+				return d
+			}
+			return 0
+		}
+	}
+
+	d := time.Second << uint(attempt-1)
+	if d > maxRetryBackoff || d <= 0 {
+		d = maxRetryBackoff
+	}
+	return d
+}
@@ -0,0 +1,112 @@
+package httpsimp
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/busy":
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case "/later":
+			w.Header().Set("Retry-After", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		case "/tomorrow":
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if calls < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	client := NewClient(RetryAfter(3))
+
+	var text string
+	err := Do(Make(http.MethodPost, srv.URL, "/", nil, []byte("payload"), nil), client, PlainText(&text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || text != "payload" {
+		t.Fatalf("calls = %d, text = %q", calls, text)
+	}
+
+	calls = 0
+	err = Do(MakeGet(srv.URL, "/busy", nil, nil), client, None())
+	if StatusCode(err) != http.StatusServiceUnavailable || calls != 1 {
+		t.Fatalf("calls = %d, err = %v", calls, err)
+	}
+
+	calls = 0
+	err = Do(MakeGet(srv.URL, "/busy", nil, nil), NewClient(RetryAfter(2, http.StatusServiceUnavailable)), None())
+	if StatusCode(err) != http.StatusServiceUnavailable || calls != 2 {
+		t.Fatalf("calls = %d, err = %v", calls, err)
+	}
+
+	calls = 0
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = Do(MakeGet(srv.URL, "/later", nil, nil).WithContext(ctx), client, None())
+	if StatusCode(err) != http.StatusTooManyRequests || calls != 1 {
+		t.Fatalf("calls = %d, err = %v", calls, err)
+	}
+
+	// no deadline, but too long to wait
+	calls = 0
+	done := make(chan error, 1)
+	go func() { done <- Do(MakeGet(srv.URL, "/tomorrow", nil, nil), client, None()) }()
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client is waiting for Retry-After: 86400")
+	}
+	if StatusCode(err) != http.StatusTooManyRequests || calls != 1 {
+		t.Fatalf("calls = %d, err = %v", calls, err)
+	}
+
+	// sent via Client.Do directly, bypassing the checks in Do
+	calls = 0
+	_, err = client.Do(MakeJSON(http.MethodPost, srv.URL, "/", nil, make(chan int), nil))
+	if _, ok := err.(*BuildError); !ok || calls != 0 {
+		t.Fatalf("calls = %d, err = %T: %v", calls, err, err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header   string
+		attempt  int
+		expected time.Duration
+	}{
+		{"120", 1, 2 * time.Minute},
+		{"Mon, 01 Jan 2024 00:00:30 GMT", 1, 30 * time.Second},
+		{"Sun, 31 Dec 2023 00:00:30 GMT", 1, 0},
+		{"", 1, time.Second},
+		{"", 3, 4 * time.Second},
+		{"garbage", 10, maxRetryBackoff},
+	}
+	for _, tt := range tests {
+		if actual := retryDelay(tt.header, tt.attempt, now); actual != tt.expected {
+			t.Errorf("retryDelay(%q, %d) = %v, wanted %v", tt.header, tt.attempt, actual, tt.expected)
+		}
+	}
+}
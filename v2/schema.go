@@ -0,0 +1,450 @@
+package httpsimp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+JSONSchema is a compiled JSON Schema used by ValidateJSONSchema.
+
+A practical subset of the specification is supported: type, enum, const,
+properties, required, additionalProperties, items, minItems, maxItems,
+minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength,
+pattern, allOf, anyOf, oneOf, not, and $ref to a definition within the same
+schema (like #/$defs/item or #/definitions/item). Other keywords, including
+format, are ignored.
+*/
+type JSONSchema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+/*
+CompileJSONSchema parses the given JSON Schema document.
+*/
+func CompileJSONSchema(data []byte) (*JSONSchema, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var root interface{}
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	s := &JSONSchema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compilePatterns(root); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+/*
+MustCompileJSONSchema is like CompileJSONSchema, but panics if the schema
+is invalid. Use it to initialize global variables.
+*/
+func MustCompileJSONSchema(data []byte) *JSONSchema {
+	s, err := CompileJSONSchema(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *JSONSchema) compilePatterns(v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if p, ok := v["pattern"].(string); ok {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("invalid JSON schema pattern %q: %w", p, err)
+			}
+			s.patterns[p] = re
+		}
+		for _, child := range v {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/*
+ValidateJSONSchema causes the parser to validate the JSON body against
+the given schema before parsing it. If the body doesn't conform,
+the parser fails with a decoding error holding a *SchemaValidationError
+that lists all violations:
+
+    var itemSchema = httpsimp.MustCompileJSONSchema([]byte(`{"type": "object", "required": ["id"]}`))
+    ...
+    err := httpsimp.Do(req, client, httpsimp.JSON(&item, httpsimp.ValidateJSONSchema(itemSchema)))
+
+The body is buffered in memory.
+*/
+func ValidateJSONSchema(s *JSONSchema) ParseOption {
+	return Transform(func(body io.Reader) (io.Reader, error) {
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading body: %w", err)
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		if err := s.Validate(v); err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(b), nil
+	})
+}
+
+/*
+SchemaValidationError is returned when a value doesn't conform to a JSONSchema.
+*/
+type SchemaValidationError struct {
+	Violations []SchemaViolation
+}
+
+/*
+SchemaViolation describes a single reason a value doesn't conform
+to a JSONSchema.
+*/
+type SchemaViolation struct {
+	// Path is the dot-separated path to the offending value (like the paths
+	// accepted by JSONPath), or an empty string for the root value.
+	Path string
+	// Message describes the violation.
+	Message string
+}
+
+func (v SchemaViolation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+func (err *SchemaValidationError) Error() string {
+	msgs := make([]string, len(err.Violations))
+	for i, v := range err.Violations {
+		msgs[i] = v.String()
+	}
+	return "JSON schema validation failed: " + strings.Join(msgs, "; ")
+}
+
+/*
+Validate checks a value decoded by encoding/json (into interface{}, with
+or without UseNumber) against the schema, returning a *SchemaValidationError
+if it doesn't conform.
+*/
+func (s *JSONSchema) Validate(v interface{}) error {
+	var violations []SchemaViolation
+	s.validate(s.root, v, "", &violations, 0)
+	if len(violations) > 0 {
+		return &SchemaValidationError{violations}
+	}
+	return nil
+}
+
+// maxSchemaDepth guards against $ref cycles.
+const maxSchemaDepth = 100
+
+func (s *JSONSchema) validate(schema, v interface{}, path string, out *[]SchemaViolation, depth int) {
+	fail := func(format string, args ...interface{}) {
+		*out = append(*out, SchemaViolation{path, fmt.Sprintf(format, args...)})
+	}
+	if depth > maxSchemaDepth {
+		fail("schema nesting too deep")
+		return
+	}
+
+	switch schema := schema.(type) {
+	case bool:
+		if !schema {
+			fail("no value allowed here")
+		}
+		return
+	case map[string]interface{}:
+		s.validateObject(schema, v, path, out, depth, fail)
+	}
+}
+
+func (s *JSONSchema) validateObject(schema map[string]interface{}, v interface{}, path string, out *[]SchemaViolation, depth int, fail func(format string, args ...interface{})) {
+	if ref, ok := schema["$ref"].(string); ok {
+		target, ok := s.resolveRef(ref)
+		if !ok {
+			fail("unresolvable schema reference %q", ref)
+		} else {
+			s.validate(target, v, path, out, depth+1)
+		}
+	}
+
+	if t, ok := schema["type"]; ok && !matchSchemaType(t, v) {
+		fail("expected %s, got %s", describeSchemaType(t), jsonTypeOf(v))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value %s is not one of the allowed values", jsonString(v))
+		}
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(c, v) {
+		fail("expected %s, got %s", jsonString(c), jsonString(v))
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		s.validateProperties(schema, v, path, out, depth, fail)
+	case []interface{}:
+		if n, ok := schemaNumber(schema["minItems"]); ok && float64(len(v)) < n {
+			fail("expected at least %v items, got %d", n, len(v))
+		}
+		if n, ok := schemaNumber(schema["maxItems"]); ok && float64(len(v)) > n {
+			fail("expected at most %v items, got %d", n, len(v))
+		}
+		if items, ok := schema["items"]; ok {
+			for i, item := range v {
+				s.validate(items, item, joinSchemaPath(path, strconv.Itoa(i)), out, depth+1)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if n, ok := schemaNumber(schema["minLength"]); ok && length < n {
+			fail("expected at least %v characters, got %v", n, length)
+		}
+		if n, ok := schemaNumber(schema["maxLength"]); ok && length > n {
+			fail("expected at most %v characters, got %v", n, length)
+		}
+		if p, ok := schema["pattern"].(string); ok && !s.patterns[p].MatchString(v) {
+			fail("value %q does not match pattern %q", v, p)
+		}
+	default:
+		if x, ok := schemaNumber(v); ok {
+			if n, ok := schemaNumber(schema["minimum"]); ok && x < n {
+				fail("expected a value >= %v, got %v", n, x)
+			}
+			if n, ok := schemaNumber(schema["maximum"]); ok && x > n {
+				fail("expected a value <= %v, got %v", n, x)
+			}
+			if n, ok := schemaNumber(schema["exclusiveMinimum"]); ok && x <= n {
+				fail("expected a value > %v, got %v", n, x)
+			}
+			if n, ok := schemaNumber(schema["exclusiveMaximum"]); ok && x >= n {
+				fail("expected a value < %v, got %v", n, x)
+			}
+		}
+	}
+
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			s.validate(sub, v, path, out, depth+1)
+		}
+	}
+	if any, ok := schema["anyOf"].([]interface{}); ok {
+		if s.countMatches(any, v, depth) == 0 {
+			fail("value does not match any of the allowed schemas")
+		}
+	}
+	if one, ok := schema["oneOf"].([]interface{}); ok {
+		if n := s.countMatches(one, v, depth); n != 1 {
+			fail("value must match exactly one schema, matches %d", n)
+		}
+	}
+	if not, ok := schema["not"]; ok {
+		if s.countMatches([]interface{}{not}, v, depth) > 0 {
+			fail("value matches a disallowed schema")
+		}
+	}
+}
+
+func (s *JSONSchema) validateProperties(schema map[string]interface{}, v map[string]interface{}, path string, out *[]SchemaViolation, depth int, fail func(format string, args ...interface{})) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, present := v[name]; !present {
+					fail("missing required property %q", name)
+				}
+			}
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if sub, ok := props[k]; ok {
+			s.validate(sub, v[k], joinSchemaPath(path, k), out, depth+1)
+		} else if hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				fail("unexpected property %q", k)
+			} else if !ok {
+				s.validate(additional, v[k], joinSchemaPath(path, k), out, depth+1)
+			}
+		}
+	}
+}
+
+func (s *JSONSchema) countMatches(schemas []interface{}, v interface{}, depth int) int {
+	n := 0
+	for _, sub := range schemas {
+		var violations []SchemaViolation
+		s.validate(sub, v, "", &violations, depth+1)
+		if len(violations) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *JSONSchema) resolveRef(ref string) (interface{}, bool) {
+	if ref == "#" {
+		return s.root, true
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+	v := s.root
+	for _, comp := range strings.Split(ref[2:], "/") {
+		comp = strings.Replace(strings.Replace(comp, "~1", "/", -1), "~0", "~", -1)
+		o, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = o[comp]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func joinSchemaPath(path, comp string) string {
+	if path == "" {
+		return comp
+	}
+	return path + "." + comp
+}
+
+func matchSchemaType(t interface{}, v interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		actual := jsonTypeOf(v)
+		return actual == t || (t == "number" && actual == "integer")
+	case []interface{}:
+		for _, alt := range t {
+			if matchSchemaType(alt, v) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func describeSchemaType(t interface{}) string {
+	if alts, ok := t.([]interface{}); ok {
+		names := make([]string, len(alts))
+		for i, alt := range alts {
+			names[i] = fmt.Sprint(alt)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func jsonTypeOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		if x, ok := schemaNumber(v); ok {
+			if x == math.Trunc(x) {
+				return "integer"
+			}
+			return "number"
+		}
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func schemaNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func jsonEqual(a, b interface{}) bool {
+	if x, ok := schemaNumber(a); ok {
+		y, ok := schemaNumber(b)
+		return ok && x == y
+	}
+	switch a := a.(type) {
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			bv, ok := b[k]
+			if !ok || !jsonEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
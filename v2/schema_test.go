@@ -0,0 +1,86 @@
+package httpsimp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+var testItemSchema = MustCompileJSONSchema([]byte(`{
+	"type": "object",
+	"required": ["id", "name"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+		"kind": {"enum": ["a", "b"]},
+		"tags": {"type": "array", "maxItems": 2, "items": {"$ref": "#/$defs/tag"}},
+		"price": {"oneOf": [{"type": "null"}, {"type": "number", "exclusiveMinimum": 0}]}
+	},
+	"$defs": {
+		"tag": {"type": "string", "maxLength": 3}
+	}
+}`))
+
+func TestJSONSchemaValid(t *testing.T) {
+	var item map[string]interface{}
+	body := []byte(`{"id": 1, "name": "widget", "kind": "a", "tags": ["x", "yz"], "price": 9.5}`)
+	err := get(http.StatusOK, ContentTypeJSON, body, JSON(&item, ValidateJSONSchema(testItemSchema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item["name"] != "widget" {
+		t.Errorf("got %v", item)
+	}
+}
+
+func TestJSONSchemaViolations(t *testing.T) {
+	body := []byte(`{"id": 1.5, "name": "Widget", "kind": "c", "tags": ["x", "long", "z"], "price": 0, "extra": true}`)
+	err := get(http.StatusOK, ContentTypeJSON, body, JSON(nil, ValidateJSONSchema(testItemSchema)))
+
+	var schemaErr *SchemaValidationError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected *SchemaValidationError, got %v", err)
+	}
+	var actual []string
+	for _, v := range schemaErr.Violations {
+		actual = append(actual, v.String())
+	}
+	expected := []string{
+		`unexpected property "extra"`,
+		`id: expected integer, got number`,
+		`kind: value "c" is not one of the allowed values`,
+		`name: value "Widget" does not match pattern "^[a-z]+$"`,
+		`price: value must match exactly one schema, matches 0`,
+		`tags: expected at most 2 items, got 3`,
+		`tags.1: expected at most 3 characters, got 4`,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got violations:\n%q\nwanted:\n%q", actual, expected)
+	}
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	s := MustCompileJSONSchema([]byte(`{"type": ["string", "null"], "not": {"const": "forbidden"}}`))
+	for _, tt := range []struct {
+		value string
+		ok    bool
+	}{
+		{`"ok"`, true},
+		{`null`, true},
+		{`"forbidden"`, false},
+		{`42`, false},
+	} {
+		var v interface{}
+		json.Unmarshal([]byte(tt.value), &v)
+		if err := s.Validate(v); (err == nil) != tt.ok {
+			t.Errorf("%s: %v", tt.value, err)
+		}
+	}
+
+	if _, err := CompileJSONSchema([]byte(`{"pattern": "("}`)); err == nil {
+		t.Errorf("expected an error for an invalid pattern")
+	}
+}
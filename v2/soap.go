@@ -0,0 +1,180 @@
+package httpsimp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+/*
+SOAPVersion selects the SOAP protocol version used by MakeSOAP.
+*/
+type SOAPVersion int
+
+const (
+	// SOAP11 is SOAP 1.1: text/xml with a SOAPAction header.
+	SOAP11 SOAPVersion = 11
+
+	// SOAP12 is SOAP 1.2: application/soap+xml with an action parameter.
+	SOAP12 SOAPVersion = 12
+)
+
+const (
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+
+	contentTypeTextXML  = "text/xml"
+	contentTypeSOAP12   = "application/soap+xml"
+	soapEnvelopeOpening = `<?xml version="1.0" encoding="utf-8"?>` + "\n" + `<soap:Envelope xmlns:soap="%s"><soap:Body>`
+	soapEnvelopeClosing = `</soap:Body></soap:Envelope>`
+)
+
+/*
+MakeSOAP builds a SOAP request to the given endpoint, wrapping the XML
+encoding of payload into a SOAP Envelope and Body and setting the action
+(SOAPAction header for SOAP 1.1, or the action parameter of Content-Type for
+SOAP 1.2). Handle the response with the SOAP parser:
+
+    req := GetQuote{Symbol: "ACME"}
+    var resp GetQuoteResponse
+    err := httpsimp.Do(httpsimp.MakeSOAP(httpsimp.SOAP11, endpoint, "urn:GetQuote", &req, nil), client, httpsimp.SOAP(&resp))
+
+If XML encoding fails, Do returns a *BuildError.
+*/
+func MakeSOAP(version SOAPVersion, endpoint, action string, payload interface{}, headers http.Header) *http.Request {
+	r := makeRequest(http.MethodPost, endpoint, "", nil, headers)
+
+	content, err := xml.Marshal(payload)
+	if err != nil {
+		return setBuildError(r, fmt.Errorf("cannot encode SOAP body: %w", err))
+	}
+
+	ns, ctype := soap11Namespace, contentTypeTextXML+"; charset=utf-8"
+	if version == SOAP12 {
+		ns, ctype = soap12Namespace, contentTypeSOAP12+"; charset=utf-8"
+		if action != "" {
+			ctype += "; action=" + strconv.Quote(action)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, soapEnvelopeOpening, ns)
+	buf.Write(content)
+	buf.WriteString(soapEnvelopeClosing)
+	SetBody(r, buf.Bytes())
+
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	if r.Header["Content-Type"] == nil {
+		r.Header["Content-Type"] = []string{ctype}
+	}
+	if r.Header["Accept"] == nil {
+		r.Header["Accept"] = []string{contentTypeTextXML + ", " + contentTypeSOAP12}
+	}
+	if version != SOAP12 && r.Header["Soapaction"] == nil {
+		r.Header["Soapaction"] = []string{strconv.Quote(action)}
+	}
+	return r
+}
+
+/*
+SOAPFault is a SOAP Fault returned by the server, normalized across SOAP 1.1
+(faultcode, faultstring, detail) and SOAP 1.2 (Code, Reason, Detail).
+*/
+type SOAPFault struct {
+	Code   string
+	Reason string
+	Actor  string
+
+	// Detail is the raw inner XML of the detail element.
+	Detail []byte
+}
+
+func (f *SOAPFault) Error() string {
+	return fmt.Sprintf("SOAP fault %s: %s", f.Code, f.Reason)
+}
+
+/*
+DecodeDetail unmarshals the fault detail into v, typically a struct
+describing the application-specific fault.
+*/
+func (f *SOAPFault) DecodeDetail(v interface{}) error {
+	return xml.Unmarshal(f.Detail, v)
+}
+
+type soapEnvelopeXML struct {
+	Body struct {
+		Fault   *soapFaultXML `xml:"Fault"`
+		Content []byte        `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+type soapFaultXML struct {
+	// SOAP 1.1
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	FaultActor  string `xml:"faultactor"`
+	Detail11    struct {
+		Content []byte `xml:",innerxml"`
+	} `xml:"detail"`
+
+	// SOAP 1.2
+	Code struct {
+		Value string `xml:"Value"`
+	} `xml:"Code"`
+	Reason struct {
+		Text string `xml:"Text"`
+	} `xml:"Reason"`
+	Role     string `xml:"Role"`
+	Detail12 struct {
+		Content []byte `xml:",innerxml"`
+	} `xml:"Detail"`
+}
+
+func (f *soapFaultXML) normalize() *SOAPFault {
+	if f.FaultCode != "" || f.FaultString != "" {
+		return &SOAPFault{f.FaultCode, f.FaultString, f.FaultActor, bytes.TrimSpace(f.Detail11.Content)}
+	}
+	return &SOAPFault{f.Code.Value, f.Reason.Text, f.Role, bytes.TrimSpace(f.Detail12.Content)}
+}
+
+/*
+SOAP is a Parser function that decodes a SOAP 1.1 or 1.2 response envelope,
+unmarshaling the first element of the Body into the result variable (which
+can be nil to ignore it). If the Body contains a Fault, it is returned as
+a *SOAPFault (use errors.As to obtain it).
+
+The parser matches text/xml and application/soap+xml responses with any status
+code, because SOAP faults are normally sent with HTTP 500.
+
+Pass the result of this function into Do or Parse to handle a response.
+*/
+func SOAP(result interface{}, mopt ...ParseOption) Parser {
+	mopt = append([]ParseOption{StatusAny, MatchFunc(isSOAPResponse)}, mopt...)
+	return MakeParser("", mopt, func(resp *http.Response) (interface{}, error) {
+		defer drainAndClose(resp.Body)
+		var env soapEnvelopeXML
+		if err := xml.NewDecoder(resp.Body).Decode(&env); err != nil {
+			return nil, err
+		}
+		if env.Body.Fault != nil {
+			fault := env.Body.Fault.normalize()
+			return fault, fault
+		}
+		if result != nil {
+			if err := xml.Unmarshal(env.Body.Content, result); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	})
+}
+
+func isSOAPResponse(resp *http.Response) bool {
+	ctype, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return ctype == contentTypeTextXML || ctype == contentTypeSOAP12
+}
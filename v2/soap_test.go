@@ -0,0 +1,82 @@
+package httpsimp
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type getQuote struct {
+	XMLName xml.Name `xml:"urn:quotes GetQuote"`
+	Symbol  string   `xml:"Symbol"`
+}
+
+type getQuoteResponse struct {
+	Price float64 `xml:"Price"`
+}
+
+func TestSOAP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.Header.Get("SOAPAction") == `"urn:GetQuote"` && strings.Contains(string(body), "<Symbol>ACME</Symbol>"):
+			w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+			w.Write([]byte(`<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <q:GetQuoteResponse xmlns:q="urn:quotes"><Price>42.5</Price></q:GetQuoteResponse>
+  </soap:Body>
+</soap:Envelope>`))
+		case strings.Contains(r.Header.Get("Content-Type"), `action="urn:GetQuote"`):
+			w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+  <env:Body><env:Fault>
+    <env:Code><env:Value>env:Sender</env:Value></env:Code>
+    <env:Reason><env:Text xml:lang="en">Unknown symbol</env:Text></env:Reason>
+  </env:Fault></env:Body>
+</env:Envelope>`))
+		default:
+			w.Header().Set("Content-Type", "text/xml")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body><soap:Fault>
+    <faultcode>soap:Client</faultcode>
+    <faultstring>Invalid request</faultstring>
+    <detail><Error><Code>17</Code></Error></detail>
+  </soap:Fault></soap:Body>
+</soap:Envelope>`))
+		}
+	}))
+	defer srv.Close()
+
+	var resp getQuoteResponse
+	err := Do(MakeSOAP(SOAP11, srv.URL, "urn:GetQuote", &getQuote{Symbol: "ACME"}, nil), http.DefaultClient, SOAP(&resp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Price != 42.5 {
+		t.Fatalf("invalid value of Price: %v", resp.Price)
+	}
+
+	err = Do(MakeSOAP(SOAP11, srv.URL, "urn:Other", &getQuote{Symbol: "ACME"}, nil), http.DefaultClient, SOAP(&resp))
+	var fault *SOAPFault
+	if !errors.As(err, &fault) || fault.Code != "soap:Client" || fault.Reason != "Invalid request" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var detail struct {
+		Code int `xml:"Code"`
+	}
+	if err := fault.DecodeDetail(&detail); err != nil || detail.Code != 17 {
+		t.Fatalf("detail = %+v, err = %v", detail, err)
+	}
+
+	err = Do(MakeSOAP(SOAP12, srv.URL, "urn:GetQuote", &getQuote{Symbol: "XXX"}, nil), http.DefaultClient, SOAP(&resp))
+	if !errors.As(err, &fault) || fault.Code != "env:Sender" || fault.Reason != "Unknown symbol" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
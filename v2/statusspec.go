@@ -2,6 +2,7 @@ package httpsimp
 
 import (
 	"net/http"
+	"strconv"
 )
 
 type StatusSpec int
@@ -37,6 +38,8 @@ const (
 	StatusNoContent      = StatusSpec(http.StatusNoContent)
 	StatusPartialContent = StatusSpec(http.StatusPartialContent)
 
+	StatusNotModified = StatusSpec(http.StatusNotModified)
+
 	StatusUnauthorized = StatusSpec(http.StatusUnauthorized)
 	StatusForbidden    = StatusSpec(http.StatusForbidden)
 	StatusNotFound     = StatusSpec(http.StatusNotFound)
@@ -47,10 +50,12 @@ Matches returns whether the given actual HTTP status code matches
 the desired status code spec, which may be a specific status code or one
 of special constants: StatusNone (won't match anything), Status1xx, Status2xx,
 Status3xx, Status4xx, Status5xx.
+
+Status codes outside of the 100..599 range never match anything.
 */
 func (desired StatusSpec) Matches(actual int) bool {
 	if actual < 100 || actual > 599 {
-		panic("invalid actual status code")
+		return false
 	}
 
 	switch desired {
@@ -77,3 +82,30 @@ func (desired StatusSpec) Matches(actual int) bool {
 		return actual == int(desired)
 	}
 }
+
+/*
+String returns a human-readable representation of the status code spec,
+like "404" or "4xx", for use in error messages.
+*/
+func (desired StatusSpec) String() string {
+	switch desired {
+	case StatusNone:
+		return "none"
+	case StatusAny:
+		return "any"
+	case Status1xx:
+		return "1xx"
+	case Status2xx:
+		return "2xx"
+	case Status3xx:
+		return "3xx"
+	case Status4xx:
+		return "4xx"
+	case Status5xx:
+		return "5xx"
+	case Status4xx5xx:
+		return "4xx/5xx"
+	default:
+		return strconv.Itoa(int(desired))
+	}
+}
@@ -0,0 +1,88 @@
+package httpsimp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMakeStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusTemporaryRedirect)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte(r.URL.Path + ":" + string(body)))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "upload.txt")
+	if err := ioutil.WriteFile(path, []byte("file contents"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var text string
+	err = Do(MakeStream(http.MethodPut, srv.URL, "/old", nil, f, 13, nil), http.DefaultClient, PlainText(&text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "/new:file contents" {
+		t.Fatalf("invalid value of text: %q", text)
+	}
+
+	r := MakeStream(http.MethodPut, srv.URL, "/old", nil, strings.NewReader("hello"), -1, nil)
+	if r.ContentLength != 5 || r.GetBody == nil {
+		t.Fatalf("ContentLength = %d, has GetBody = %v", r.ContentLength, r.GetBody != nil)
+	}
+	err = Do(r, http.DefaultClient, PlainText(&text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "/new:hello" {
+		t.Fatalf("invalid value of text: %q", text)
+	}
+}
+
+func TestOnUploadProgress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	payload := strings.Repeat("x", 100000)
+	var lastSent, lastTotal int64
+	var calls int
+	r := MakeStream(http.MethodPost, srv.URL, "/", nil, strings.NewReader(payload), -1, nil)
+	r = OnUploadProgress(r, func(sent, total int64) {
+		if sent < lastSent {
+			t.Errorf("progress went backwards: %d after %d", sent, lastSent)
+		}
+		lastSent, lastTotal = sent, total
+		calls++
+	})
+
+	var text string
+	err := Do(r, http.DefaultClient, PlainText(&text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != payload {
+		t.Fatalf("echoed body has length %d", len(text))
+	}
+	if calls == 0 || lastSent != int64(len(payload)) || lastTotal != int64(len(payload)) {
+		t.Fatalf("calls = %d, last progress = %d/%d", calls, lastSent, lastTotal)
+	}
+}
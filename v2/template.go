@@ -0,0 +1,81 @@
+package httpsimp
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+/*
+Template is a frozen request that can be sent many times, including
+concurrently from multiple goroutines.
+
+An *http.Request cannot be reused safely: its Body can only be read once,
+and both net/http and callers may modify its Header. A Template holds a
+private copy of the request and hands out a fresh clone (with its own
+headers, URL and body) for every send.
+
+Use Freeze to create a Template.
+*/
+type Template struct {
+	req *http.Request
+}
+
+/*
+Freeze captures the given request (typically built via one of the Make
+functions) into a Template. The request must not be used afterwards.
+
+If the request has a body but no GetBody function, the body is read into
+memory so that it can be replayed.
+*/
+func Freeze(r *http.Request) *Template {
+	req := r.Clone(r.Context())
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		data, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				return nil, err
+			}
+		} else {
+			req.GetBody = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(bytes.NewReader(data)), nil
+			}
+		}
+	}
+	req.Body = nil
+	return &Template{req}
+}
+
+/*
+Request returns a new independent copy of the frozen request,
+ready to be sent.
+*/
+func (t *Template) Request() *http.Request {
+	r := t.req.Clone(t.req.Context())
+	if t.req.GetBody != nil {
+		body, err := t.req.GetBody()
+		if err != nil {
+			body = ioutil.NopCloser(&errorReader{err})
+		}
+		r.Body = body
+	}
+	return r
+}
+
+/*
+Do sends a new copy of the frozen request via the given client and handles
+the response using the given parsers, just like the package-level Do.
+*/
+func (t *Template) Do(client HTTPClient, parsers ...Parser) error {
+	return Do(t.Request(), client, parsers...)
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,35 @@
+package httpsimp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestTemplateConcurrentSends(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	tmpl := Freeze(MakeJSON(http.MethodPost, srv.URL, "", nil, map[string]int{"foo": 42}, nil))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var text string
+			if err := tmpl.Do(http.DefaultClient, PlainText(&text)); err != nil {
+				t.Error(err)
+			} else if text != `{"foo":42}` {
+				t.Errorf("invalid value of text: %q", text)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,136 @@
+package httpsimp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+Throttle returns an HTTPClient that sends requests via the given client,
+limiting the aggregate rate of all request bodies being uploaded and all
+response bodies being downloaded through it to the given number of bytes
+per second (0 means unlimited), so that background jobs don't saturate
+the network:
+
+    client := httpsimp.Throttle(&http.Client{Timeout: 10 * time.Minute}, 1<<20, 4<<20)
+
+The limits are enforced with token buckets allowing short bursts of
+a quarter of a second worth of data. Headers are not counted, and
+neither is the overhead of TLS and compression (bodies are counted
+as seen by the client). Waiting honors the request's context.
+*/
+func Throttle(client HTTPClient, uploadBytesPerSec, downloadBytesPerSec int64) HTTPClient {
+	return &throttledClient{
+		client:   client,
+		upload:   newTokenBucket(uploadBytesPerSec),
+		download: newTokenBucket(downloadBytesPerSec),
+	}
+}
+
+type throttledClient struct {
+	client   HTTPClient
+	upload   *tokenBucket
+	download *tokenBucket
+}
+
+func (c *throttledClient) Do(r *http.Request) (*http.Response, error) {
+	ctx := r.Context()
+	if c.upload != nil && r.Body != nil && r.Body != http.NoBody {
+		r = r.Clone(ctx)
+		r.Body = &throttledBody{r.Body, c.upload, ctx}
+		if getBody := r.GetBody; getBody != nil {
+			r.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return &throttledBody{body, c.upload, ctx}, nil
+			}
+		}
+	}
+
+	resp, err := c.client.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	if c.download != nil {
+		resp.Body = &throttledBody{resp.Body, c.download, ctx}
+	}
+	return resp, nil
+}
+
+type throttledBody struct {
+	io.ReadCloser
+	bucket *tokenBucket
+	ctx    context.Context
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	if len(p) > b.bucket.burst {
+		p = p[:b.bucket.burst]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if werr := b.bucket.take(b.ctx, n); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
+
+// tokenBucket allows rate bytes per second on average, with bursts of up
+// to burst bytes. Tokens are taken after the fact, possibly going into
+// debt, which the taker then waits out.
+type tokenBucket struct {
+	rate  float64
+	burst int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	now  func() time.Time
+	wait func(ctx context.Context, d time.Duration) error
+}
+
+func newTokenBucket(rate int64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	burst := int(rate / 4)
+	if burst < 1024 {
+		burst = 1024
+	}
+	return &tokenBucket{
+		rate:   float64(rate),
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+		wait:   sleepContext,
+	}
+}
+
+func (b *tokenBucket) take(ctx context.Context, n int) error {
+	b.mu.Lock()
+	now := b.now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay > 0 {
+		return b.wait(ctx, delay)
+	}
+	return nil
+}
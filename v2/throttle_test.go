@@ -0,0 +1,63 @@
+package httpsimp
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var waited time.Duration
+	b := newTokenBucket(8192)
+	b.last = now
+	b.now = func() time.Time { return now }
+	b.wait = func(ctx context.Context, d time.Duration) error {
+		waited += d
+		now = now.Add(d)
+		return nil
+	}
+
+	// the initial burst is free
+	b.take(context.Background(), 2048)
+	if waited != 0 {
+		t.Fatalf("waited %v for the burst", waited)
+	}
+	// then it's 8 KB per second
+	for i := 0; i < 4; i++ {
+		b.take(context.Background(), 2048)
+	}
+	if waited != time.Second {
+		t.Errorf("waited %v, wanted 1s", waited)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 6000)
+	var uploaded int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		uploaded = len(body)
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	client := Throttle(http.DefaultClient, 20000, 16000)
+	start := time.Now()
+	var b []byte
+	if err := Do(Make(http.MethodPost, srv.URL, "", nil, data, nil), client, Bytes(&b)); err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	if uploaded != len(data) || len(b) != len(data) {
+		t.Fatalf("uploaded %d, downloaded %d", uploaded, len(b))
+	}
+	// download: (6000 - 4000 burst) / 16000 = 125ms; upload: (6000 - 5000) / 20000 = 50ms
+	if elapsed < 150*time.Millisecond {
+		t.Errorf("took only %v", elapsed)
+	}
+}
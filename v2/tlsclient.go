@@ -0,0 +1,79 @@
+package httpsimp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+/*
+NewTLSClient returns an *http.Client using the given TLS configuration
+(based on http.DefaultTransport) with the given overall timeout.
+Unless cfg sets MinVersion, TLS 1.2 is required; to require TLS 1.3, set
+cfg.MinVersion to tls.VersionTLS13 (or use NewClient with MinTLSVersion).
+*/
+func NewTLSClient(cfg *tls.Config, timeout time.Duration) *http.Client {
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	if cfg.MinVersion == 0 {
+		cfg = cfg.Clone()
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = cfg
+	return &http.Client{Transport: t, Timeout: timeout}
+}
+
+/*
+NewClientTrustingCA returns an *http.Client that trusts the CAs from the given
+PEM file in addition to the system roots, e.g. for services using certificates
+issued by a corporate CA.
+*/
+func NewClientTrustingCA(caFile string, timeout time.Duration) (*http.Client, error) {
+	caPEM, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := ExtraCAConfig(caPEM)
+	if err != nil {
+		return nil, err
+	}
+	return NewTLSClient(cfg, timeout), nil
+}
+
+/*
+ExtraCAConfig returns a TLS configuration trusting the CAs from the given
+PEM data in addition to the system roots. Use it with NewTLSClient,
+or with TLSConfig and HostTLSConfig client options.
+*/
+func ExtraCAConfig(caPEM []byte) (*tls.Config, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("no CA certificates found in PEM data")
+	}
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+/*
+NewInsecureClient returns an *http.Client that does not verify server
+certificates, which makes it vulnerable to man-in-the-middle attacks.
+Only use it in test environments with self-signed certificates.
+*/
+func NewInsecureClient(timeout time.Duration) *http.Client {
+	return NewTLSClient(InsecureTLSConfig(), timeout)
+}
+
+/*
+InsecureTLSConfig returns a TLS configuration that does not verify server
+certificates. See NewInsecureClient for the caveats.
+*/
+func InsecureTLSConfig() *tls.Config {
+	return &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12}
+}
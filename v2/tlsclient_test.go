@@ -0,0 +1,41 @@
+package httpsimp
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTLSClients(t *testing.T) {
+	ca := newTestCert(t, nil, false)
+	srv := newTLSTestServer(t, ca, false)
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caFile, ca.certPEM, 0600)
+	client, err := NewClientTrustingCA(caFile, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Do(MakeGet(srv.URL, "", nil, nil), client, None()); err != nil {
+		t.Errorf("trusting CA: %v", err)
+	}
+
+	if err := Do(MakeGet(srv.URL, "", nil, nil), NewTLSClient(nil, 5*time.Second), None()); err == nil {
+		t.Error("expected an error for an unknown CA")
+	}
+	if err := Do(MakeGet(srv.URL, "", nil, nil), NewInsecureClient(5*time.Second), None()); err != nil {
+		t.Errorf("insecure: %v", err)
+	}
+
+	cfg := &tls.Config{}
+	NewTLSClient(cfg, 0)
+	if cfg.MinVersion != 0 {
+		t.Error("NewTLSClient modified the given config")
+	}
+	if _, err := ExtraCAConfig([]byte("junk")); err == nil {
+		t.Error("expected an error for a bad CA bundle")
+	}
+}
@@ -0,0 +1,147 @@
+package httpsimp
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+const (
+	// CipherPolicyModern allows TLS 1.3 only, per Mozilla's "modern" guidelines.
+	CipherPolicyModern = "modern"
+
+	// CipherPolicyIntermediate allows TLS 1.2 with forward-secret AEAD cipher
+	// suites and TLS 1.3, per Mozilla's "intermediate" guidelines.
+	CipherPolicyIntermediate = "intermediate"
+)
+
+var intermediateCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+/*
+MinTLSVersion sets the minimum TLS version (like tls.VersionTLS12)
+for all connections made by the client, including ones using TLS
+configurations passed via TLSConfig and HostTLSConfig.
+*/
+func MinTLSVersion(version uint16) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.minTLSVersion = version
+		c.transportChanged = true
+	})
+}
+
+/*
+CipherPolicy restricts TLS versions and cipher suites used by the client
+to a named policy: CipherPolicyModern or CipherPolicyIntermediate.
+With any other name, the client fails all requests with a *ConfigError
+without sending them, so a typo in a configuration file doesn't silently
+downgrade TLS settings.
+
+MinTLSVersion can further raise the minimum version allowed by the policy.
+*/
+func CipherPolicy(name string) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.cipherPolicy = name
+		c.transportChanged = true
+	})
+}
+
+/*
+TLSWarningHook sets a function to be called whenever a TLS connection
+falls back to the weakest settings allowed by the client: the minimum
+allowed TLS version (when it's lower than TLS 1.3), or a cipher suite
+considered insecure by crypto/tls. Use it to log, or to export metrics
+proving your outbound TLS posture before raising the minimum.
+
+The function is called during the handshake, so it must not block.
+*/
+func TLSWarningHook(f func(host string, state tls.ConnectionState, reason string)) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.tlsWarningHook = f
+		c.transportChanged = true
+	})
+}
+
+// tlsPolicyError reports an unknown cipher policy.
+func (c *clientConfig) tlsPolicyError() error {
+	switch c.cipherPolicy {
+	case "", CipherPolicyModern, CipherPolicyIntermediate:
+		return nil
+	default:
+		return fmt.Errorf("unknown cipher policy %q", c.cipherPolicy)
+	}
+}
+
+func (c *clientConfig) hasTLSPolicy() bool {
+	return c.minTLSVersion != 0 || c.cipherPolicy != "" || c.tlsWarningHook != nil
+}
+
+func (c *clientConfig) applyTLSPolicy(cfg *tls.Config) *tls.Config {
+	if !c.hasTLSPolicy() {
+		return cfg
+	}
+	if cfg == nil {
+		cfg = &tls.Config{}
+	} else {
+		cfg = cfg.Clone()
+	}
+
+	minVersion := c.minTLSVersion
+	switch c.cipherPolicy {
+	case CipherPolicyModern:
+		if minVersion < tls.VersionTLS13 {
+			minVersion = tls.VersionTLS13
+		}
+	case CipherPolicyIntermediate:
+		if minVersion < tls.VersionTLS12 {
+			minVersion = tls.VersionTLS12
+		}
+		cfg.CipherSuites = intermediateCipherSuites
+	}
+	if minVersion > cfg.MinVersion {
+		cfg.MinVersion = minVersion
+	}
+
+	if hook := c.tlsWarningHook; hook != nil {
+		effectiveMin := cfg.MinVersion
+		if effectiveMin == 0 {
+			effectiveMin = tls.VersionTLS12
+		}
+		verify := cfg.VerifyConnection
+		cfg.VerifyConnection = func(state tls.ConnectionState) error {
+			if state.Version == effectiveMin && state.Version < tls.VersionTLS13 {
+				hook(state.ServerName, state, fmt.Sprintf("negotiated the minimum allowed TLS version %s", tlsVersionName(state.Version)))
+			}
+			for _, suite := range tls.InsecureCipherSuites() {
+				if suite.ID == state.CipherSuite {
+					hook(state.ServerName, state, fmt.Sprintf("negotiated insecure cipher suite %s", suite.Name))
+				}
+			}
+			if verify != nil {
+				return verify(state)
+			}
+			return nil
+		}
+	}
+	return cfg
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04X", version)
+	}
+}
@@ -0,0 +1,96 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	// TraceParentHeader is the W3C Trace Context "traceparent" header.
+	TraceParentHeader = "Traceparent"
+	// TraceStateHeader is the W3C Trace Context "tracestate" header.
+	TraceStateHeader = "Tracestate"
+)
+
+/*
+PropagateTrace makes the client add W3C Trace Context headers (traceparent
+and tracestate) to requests that don't have them, propagating the trace
+of the request's context without creating any spans.
+
+By default, the headers come from a context prepared via
+ContextWithTraceParent. Pass inject to take them from elsewhere instead,
+e.g. an OpenTelemetry propagator:
+
+    httpsimp.PropagateTrace(func(ctx context.Context, h http.Header) {
+        otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(h))
+    })
+*/
+func PropagateTrace(inject func(ctx context.Context, h http.Header)) ClientOption {
+	if inject == nil {
+		inject = injectTraceParent
+	}
+	return clientOptionFunc(func(c *clientConfig) {
+		c.injectTrace = inject
+	})
+}
+
+type traceParentKey struct{}
+
+type traceParent struct {
+	parent, state string
+}
+
+/*
+ContextWithTraceParent returns a copy of ctx carrying the given traceparent
+and tracestate header values (the latter is optional), which PropagateTrace
+sends with outgoing requests. To continue the trace of an incoming request:
+
+    ctx := httpsimp.ContextWithTraceParent(r.Context(),
+        r.Header.Get("traceparent"), r.Header.Get("tracestate"))
+
+An invalid traceparent is ignored.
+*/
+func ContextWithTraceParent(ctx context.Context, traceparent, tracestate string) context.Context {
+	if !validTraceParent(traceparent) {
+		return ctx
+	}
+	return context.WithValue(ctx, traceParentKey{}, traceParent{traceparent, tracestate})
+}
+
+func injectTraceParent(ctx context.Context, h http.Header) {
+	if tp, ok := ctx.Value(traceParentKey{}).(traceParent); ok {
+		h.Set(TraceParentHeader, tp.parent)
+		if tp.state != "" {
+			h.Set(TraceStateHeader, tp.state)
+		}
+	}
+}
+
+// validTraceParent checks the version-00 format:
+// 00-<32 hex trace ID>-<16 hex parent ID>-<2 hex flags>.
+func validTraceParent(s string) bool {
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return false
+	}
+	if len(s) > 55 && (s[:2] == "00" || s[55] != '-') {
+		return false
+	}
+	allZero := func(s string) bool {
+		for i := 0; i < len(s); i++ {
+			if s[i] != '0' {
+				return false
+			}
+		}
+		return true
+	}
+	for i := 0; i < 55; i++ {
+		if i == 2 || i == 35 || i == 52 {
+			continue
+		}
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return s[:2] != "ff" && !allZero(s[3:35]) && !allZero(s[36:52])
+}
@@ -0,0 +1,66 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPropagateTrace(t *testing.T) {
+	var parent, state string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parent, state = r.Header.Get("traceparent"), r.Header.Get("tracestate")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	client := NewClient(PropagateTrace(nil))
+
+	ctx := ContextWithTraceParent(context.Background(), tp, "vendor=1")
+	if err := Do(MakeGet(srv.URL, "", nil, nil).WithContext(ctx), client, None()); err != nil {
+		t.Fatal(err)
+	}
+	if parent != tp || state != "vendor=1" {
+		t.Errorf("got %q, %q", parent, state)
+	}
+
+	if err := Do(MakeGet(srv.URL, "", nil, nil), client, None()); err != nil {
+		t.Fatal(err)
+	}
+	if parent != "" || state != "" {
+		t.Errorf("unexpected headers %q, %q", parent, state)
+	}
+
+	client = NewClient(PropagateTrace(func(ctx context.Context, h http.Header) {
+		h.Set("traceparent", "custom")
+	}))
+	if err := Do(MakeGet(srv.URL, "", nil, nil), client, None()); err != nil {
+		t.Fatal(err)
+	}
+	if parent != "custom" {
+		t.Errorf("got %q from custom injector", parent)
+	}
+}
+
+func TestValidTraceParent(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"garbage", false},
+	}
+	for _, tt := range tests {
+		if actual := validTraceParent(tt.value); actual != tt.valid {
+			t.Errorf("validTraceParent(%q) = %v, wanted %v", tt.value, actual, tt.valid)
+		}
+	}
+}
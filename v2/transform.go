@@ -0,0 +1,285 @@
+package httpsimp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+/*
+BodyTransform converts a response body before it is handed to the parser,
+for example to strip a prefix or decode a wrapping encoding. The returned
+reader replaces the body; closing the original body is handled by the parser.
+
+Use StripXSSIPrefix, UnwrapJSONP, DecodeBase64, or your own function
+with the Transform option.
+*/
+type BodyTransform func(body io.Reader) (io.Reader, error)
+
+/*
+Transform causes the parser to pass the response body through the given
+transforms (in order) before parsing it:
+
+    httpsimp.JSON(&resp, httpsimp.Transform(httpsimp.StripXSSIPrefix))
+*/
+func Transform(transforms ...BodyTransform) ParseOption {
+	return matchOptionFunc(func(m *Parser) {
+		m.transforms = append(m.transforms[:len(m.transforms):len(m.transforms)], transforms...)
+	})
+}
+
+type transformedBody struct {
+	io.Reader
+	io.Closer
+}
+
+var xssiPrefixes = [][]byte{
+	[]byte(")]}'"),
+	[]byte("while(1);"),
+	[]byte("for(;;);"),
+}
+
+/*
+StripXSSIPrefix is a BodyTransform that removes an anti-XSSI prefix
+like )]}' (followed by an optional comma and newline), while(1); or for(;;);
+that some APIs put in front of JSON responses. Bodies without a prefix
+are left unchanged.
+*/
+func StripXSSIPrefix(body io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(body)
+	head, _ := br.Peek(16)
+	for _, prefix := range xssiPrefixes {
+		if bytes.HasPrefix(head, prefix) {
+			n := len(prefix)
+			if n < len(head) && head[n] == ',' {
+				n++
+			}
+			if n < len(head) && head[n] == '\r' {
+				n++
+			}
+			if n < len(head) && head[n] == '\n' {
+				n++
+			}
+			br.Discard(n)
+			break
+		}
+	}
+	return br, nil
+}
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+/*
+StripBOM is a BodyTransform that removes a leading UTF-8 byte order mark,
+which some Windows-hosted servers put in front of JSON and text responses.
+Bodies without a BOM are left unchanged.
+
+JSON, PlainText and CSV strip the BOM by default; use TolerateBOM option
+to do the same for other parsers.
+*/
+func StripBOM(body io.Reader) (io.Reader, error) {
+	return &bomStripper{r: body}, nil
+}
+
+// bomStripper avoids allocating a bufio.Reader for every JSON response.
+type bomStripper struct {
+	r    io.Reader
+	head []byte
+	buf  [3]byte
+	done bool
+}
+
+func (s *bomStripper) Read(p []byte) (int, error) {
+	if !s.done {
+		s.done = true
+		n, err := io.ReadFull(s.r, s.buf[:])
+		if n == len(utf8BOM) && bytes.Equal(s.buf[:n], utf8BOM) {
+			n = 0
+		}
+		s.head = s.buf[:n]
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		if err != nil && len(s.head) == 0 {
+			return 0, err
+		}
+	}
+	if len(s.head) > 0 {
+		n := copy(p, s.head)
+		s.head = s.head[n:]
+		return n, nil
+	}
+	return s.r.Read(p)
+}
+
+/*
+TolerateBOM causes the parser to remove a leading UTF-8 byte order mark
+from the body (see StripBOM) before any other transforms.
+*/
+func TolerateBOM() ParseOption {
+	return tolerateBOM
+}
+
+var tolerateBOM ParseOption = matchOptionFunc(func(m *Parser) {
+	m.stripBOM = true
+})
+
+/*
+UnwrapJSONP is a BodyTransform that extracts the payload from a JSONP
+response like callback({...}); so that it can be parsed as JSON.
+Bodies that don't look like a JSONP call are left unchanged.
+
+The whole body is buffered in memory.
+*/
+func UnwrapJSONP(body io.Reader) (io.Reader, error) {
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading body: %w", err)
+	}
+	if payload, ok := unwrapJSONP(b); ok {
+		return bytes.NewReader(payload), nil
+	}
+	return bytes.NewReader(b), nil
+}
+
+func unwrapJSONP(b []byte) ([]byte, bool) {
+	s := bytes.TrimSpace(b)
+	s = bytes.TrimSpace(bytes.TrimPrefix(s, []byte("/**/")))
+
+	i := 0
+	for i < len(s) && isJSONPCallbackChar(s[i]) {
+		i++
+	}
+	if i == 0 {
+		return nil, false
+	}
+	s = bytes.TrimSpace(s[i:])
+	if len(s) == 0 || s[0] != '(' {
+		return nil, false
+	}
+
+	s = bytes.TrimSuffix(bytes.TrimSpace(s[1:]), []byte(";"))
+	s = bytes.TrimSpace(s)
+	if len(s) == 0 || s[len(s)-1] != ')' {
+		return nil, false
+	}
+	return s[:len(s)-1], true
+}
+
+func isJSONPCallbackChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '$' || c == '.'
+}
+
+/*
+DecodeBase64 is a BodyTransform that decodes a base64-encoded body
+(standard encoding with padding; line breaks are ignored).
+*/
+func DecodeBase64(body io.Reader) (io.Reader, error) {
+	return base64.NewDecoder(base64.StdEncoding, body), nil
+}
+
+/*
+RequestBodyTransform converts an outgoing request body right before the
+request is sent, and can inspect or modify the request headers (e.g.
+to add a signature of the final body). An empty body is passed in
+for requests without one.
+*/
+type RequestBodyTransform func(body []byte, header http.Header) ([]byte, error)
+
+type requestTransformsKey struct{}
+
+/*
+TransformRequestBody arranges for Do to pass the request body through
+the given transforms (in order, after any transforms added previously)
+right before sending the request. This runs after the body has been encoded
+by EncodeJSONBody, EncodeForm etc, regardless of the order of calls:
+
+    r := httpsimp.MakeJSON(http.MethodPost, baseURL, path, nil, payload, nil)
+    r = httpsimp.TransformRequestBody(r, httpsimp.CanonicalizeJSON, sign)
+
+The body is buffered in memory. If a transform fails, Do returns
+a *BuildError without sending the request.
+
+The transforms are stored in the request context, and are lost if
+http.Request.WithContext is called afterwards; use WithContext instead.
+*/
+func TransformRequestBody(r *http.Request, transforms ...RequestBodyTransform) *http.Request {
+	existing, _ := r.Context().Value(requestTransformsKey{}).([]RequestBodyTransform)
+	all := append(existing[:len(existing):len(existing)], transforms...)
+	*r = *r.WithContext(context.WithValue(r.Context(), requestTransformsKey{}, all))
+	return r
+}
+
+func applyRequestTransforms(r *http.Request) (*http.Request, error) {
+	transforms, _ := r.Context().Value(requestTransformsKey{}).([]RequestBodyTransform)
+	if len(transforms) == 0 {
+		return r, nil
+	}
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, &BuildError{fmt.Errorf("error reading body: %w", err)}
+		}
+	}
+
+	// clear the transforms, so that they are not applied again by Do
+	// to a request returned by Finalize
+	r = r.Clone(context.WithValue(r.Context(), requestTransformsKey{}, []RequestBodyTransform(nil)))
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	for _, t := range transforms {
+		var err error
+		body, err = t(body, r.Header)
+		if err != nil {
+			return nil, &BuildError{err}
+		}
+	}
+
+	if len(body) == 0 {
+		r.Body, r.GetBody, r.ContentLength = http.NoBody, nil, 0
+		return r, nil
+	}
+	return SetBodyReader(r, bytes.NewReader(body), int64(len(body))), nil
+}
+
+/*
+Finalize returns the request in the form Do would send it: it returns
+the build error of the request, if any (see BuildError), and applies
+the body transforms added via TransformRequestBody. This is needed to
+persist a request for sending later, like the outbox package does.
+*/
+func Finalize(r *http.Request) (*http.Request, error) {
+	if err := requestBuildError(r); err != nil {
+		return nil, err
+	}
+	return applyRequestTransforms(r)
+}
+
+/*
+CanonicalizeJSON is a RequestBodyTransform that re-encodes a JSON body
+in a canonical form: without insignificant whitespace and with object keys
+sorted, which is handy for computing signatures. Numbers are kept verbatim.
+*/
+func CanonicalizeJSON(body []byte, header http.Header) ([]byte, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("cannot canonicalize JSON body: %w", err)
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,134 @@
+package httpsimp
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		body       string
+		transforms []BodyTransform
+	}{
+		{`{"foo": 42}`, []BodyTransform{StripXSSIPrefix}},
+		{")]}'\n{\"foo\": 42}", []BodyTransform{StripXSSIPrefix}},
+		{")]}',\n{\"foo\": 42}", []BodyTransform{StripXSSIPrefix}},
+		{`while(1);{"foo": 42}`, []BodyTransform{StripXSSIPrefix}},
+		{`cb({"foo": 42});`, []BodyTransform{UnwrapJSONP}},
+		{`/**/ jQuery.cb_1 ( {"foo": 42} ) ;`, []BodyTransform{UnwrapJSONP}},
+		{`eyJmb28iOiA0Mn0=`, []BodyTransform{DecodeBase64}},
+		{"KV19JwpleUptYjI4aU9pQTBNbjA9", []BodyTransform{DecodeBase64, StripXSSIPrefix, DecodeBase64}},
+	}
+	for _, tt := range tests {
+		var resp struct {
+			Foo int `json:"foo"`
+		}
+		err := get(http.StatusOK, ContentTypeJSON, []byte(tt.body), JSON(&resp, Transform(tt.transforms...)))
+		if err != nil {
+			t.Errorf("%q: %v", tt.body, err)
+		} else if resp.Foo != 42 {
+			t.Errorf("%q: invalid value of Foo: %v", tt.body, resp.Foo)
+		}
+	}
+}
+
+func TestTransformBase64Error(t *testing.T) {
+	var resp interface{}
+	err := get(http.StatusOK, ContentTypeJSON, []byte("!!!"), JSON(&resp, Transform(DecodeBase64)))
+	if err == nil {
+		t.Fatal("err is nil for invalid base64")
+	}
+}
+
+func TestTransformRequestBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte(r.Header.Get("X-Signature") + " " + string(body)))
+	}))
+	defer srv.Close()
+
+	sign := func(body []byte, header http.Header) ([]byte, error) {
+		header.Set("X-Signature", fmt.Sprintf("len=%d", len(body)))
+		return body, nil
+	}
+	envelope := func(body []byte, header http.Header) ([]byte, error) {
+		return []byte(`{"data": ` + string(body) + `}`), nil
+	}
+
+	r := MakeJSON(http.MethodPost, srv.URL, "/", nil, map[string]interface{}{"b": 1.50, "a": []int{2, 1}}, nil)
+	r = TransformRequestBody(r, envelope)
+	r = TransformRequestBody(r, CanonicalizeJSON, sign)
+
+	var text string
+	err := Do(r, http.DefaultClient, PlainText(&text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `len=28 {"data":{"a":[2,1],"b":1.5}}`; text != expected {
+		t.Fatalf("got %q, wanted %q", text, expected)
+	}
+
+	// a finalized request is sent as is
+	r = MakeJSON(http.MethodPost, srv.URL, "/", nil, 1, nil)
+	r, err = Finalize(TransformRequestBody(r, envelope))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Do(r, http.DefaultClient, PlainText(&text)); err != nil {
+		t.Fatal(err)
+	}
+	if expected := ` {"data": 1}`; text != expected {
+		t.Fatalf("got %q, wanted %q", text, expected)
+	}
+
+	r = MakeForm(http.MethodPost, srv.URL, "/", url.Values{"a": {"1"}}, nil)
+	r = TransformRequestBody(r, CanonicalizeJSON)
+	err = Do(r, http.DefaultClient, None())
+	var be *BuildError
+	if !errors.As(err, &be) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStripBOM(t *testing.T) {
+	var obj struct {
+		A int `json:"a"`
+	}
+	err := get(http.StatusOK, ContentTypeJSON, []byte("\xef\xbb\xbf{\"a\":1}"), JSON(&obj))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.A != 1 {
+		t.Errorf("got %+v", obj)
+	}
+
+	var s string
+	err = get(http.StatusOK, ContentTypeTextPlain, []byte("\xef\xbb\xbfhello"), PlainText(&s))
+	if err != nil || s != "hello" {
+		t.Errorf("PlainText: %q, %v", s, err)
+	}
+
+	for _, body := range []string{"", "h", "hi", "\xef\xbb"} {
+		var b []byte
+		err = get(http.StatusOK, ContentTypeTextPlain, []byte(body), Bytes(&b, TolerateBOM()))
+		if err != nil || string(b) != body {
+			t.Errorf("short body %q: %q, %v", body, b, err)
+		}
+	}
+
+	var b []byte
+	err = get(http.StatusOK, ContentTypeTextPlain, []byte("\xef\xbb\xbfhi"), Bytes(&b))
+	if err != nil || string(b) != "\xef\xbb\xbfhi" {
+		t.Errorf("Bytes: %q, %v", b, err)
+	}
+	err = get(http.StatusOK, ContentTypeTextPlain, []byte("\xef\xbb\xbfhi"), Bytes(&b, TolerateBOM()))
+	if err != nil || string(b) != "hi" {
+		t.Errorf("Bytes with TolerateBOM: %q, %v", b, err)
+	}
+}
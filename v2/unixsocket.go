@@ -0,0 +1,70 @@
+package httpsimp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+/*
+UnixSocket makes the client connect to the Unix domain socket at the given
+path for requests to the given host name, so that local daemons like Docker
+can be reached with ordinary URLs:
+
+    client := httpsimp.NewClient(
+        httpsimp.UnixSocket("docker", "/var/run/docker.sock"),
+        httpsimp.BaseURL("http://docker/v1.41"))
+    err := httpsimp.Do(httpsimp.MakeGet("", "/containers/json", nil, nil), client, httpsimp.JSON(&containers))
+
+The host name is only used to pick the socket (and is sent in the Host
+header); requests to other hosts are not affected. Proxies are never used
+for such hosts. Specifying the same host again replaces its socket, and
+an empty path removes it.
+*/
+func UnixSocket(host, path string) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		host = strings.ToLower(host)
+		sockets := make(map[string]string, len(c.unixSockets)+1)
+		for h, p := range c.unixSockets {
+			sockets[h] = p
+		}
+		if path == "" {
+			delete(sockets, host)
+		} else {
+			sockets[host] = path
+		}
+		c.unixSockets = sockets
+		c.transportChanged = true
+	})
+}
+
+func (c *clientConfig) applyUnixSockets(t *http.Transport) {
+	if len(c.unixSockets) == 0 {
+		return
+	}
+	sockets := c.unixSockets
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	dial := t.DialContext
+	if dial == nil {
+		dial = dialer.DialContext
+	}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			if path, ok := sockets[strings.ToLower(host)]; ok {
+				return dialer.DialContext(ctx, "unix", path)
+			}
+		}
+		return dial(ctx, network, addr)
+	}
+
+	proxy := t.Proxy
+	t.Proxy = func(r *http.Request) (*url.URL, error) {
+		if _, ok := sockets[strings.ToLower(r.URL.Hostname())]; ok || proxy == nil {
+			return nil, nil
+		}
+		return proxy(r)
+	}
+}
@@ -0,0 +1,48 @@
+package httpsimp
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "test.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("cannot listen on a Unix socket: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte("unix " + r.Host + " " + r.URL.Path))
+	}))
+	srv.Listener.Close()
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+
+	tcp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeTextPlain)
+		w.Write([]byte("tcp"))
+	}))
+	defer tcp.Close()
+
+	client := NewClient(UnixSocket("daemon", sock), BaseURL("http://daemon/v1"))
+	var text string
+	if err := Do(MakeGet("", "/info", nil, nil), client, PlainText(&text)); err != nil {
+		t.Fatal(err)
+	}
+	if text != "unix daemon /v1/info" {
+		t.Errorf("got %q", text)
+	}
+	if err := Do(MakeGet(tcp.URL, "", nil, nil), client, PlainText(&text)); err != nil || text != "tcp" {
+		t.Errorf("other hosts: %q, %v", text, err)
+	}
+
+	client.Update(UnixSocket("daemon", ""))
+	if err := Do(MakeGet("", "/info", nil, nil), client, None()); err == nil {
+		t.Error("expected an error after removing the socket")
+	}
+}
@@ -0,0 +1,75 @@
+package httpsimp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+Validate checks the given request for common mistakes that servers tend
+to report as an opaque 400 Bad Request:
+
+- no URL;
+- a non-empty body without a Content-Type header;
+- a GET or HEAD request with a body;
+- a Content-Length header that disagrees with the actual body length
+  (net/http ignores the header and uses the ContentLength field).
+
+It also reports build errors (see BuildError). All problems found are
+reported together as a *BuildError.
+
+Use the ValidateRequests client option to validate every request sent
+via a Client.
+*/
+func Validate(r *http.Request) error {
+	if be := requestBuildError(r); be != nil {
+		return be
+	}
+
+	var problems []string
+	if r.URL == nil {
+		problems = append(problems, "no URL")
+	}
+
+	hasBody := r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+	if hasBody {
+		if r.Header.Get("Content-Type") == "" {
+			problems = append(problems, "body without Content-Type")
+		}
+		if r.Method == "" || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			method := r.Method
+			if method == "" {
+				method = http.MethodGet
+			}
+			problems = append(problems, fmt.Sprintf("%s request with a body", method))
+		}
+	}
+
+	if values := r.Header["Content-Length"]; len(values) > 0 {
+		n, err := strconv.ParseInt(values[0], 10, 64)
+		if err != nil || len(values) > 1 {
+			problems = append(problems, fmt.Sprintf("invalid Content-Length header %q", strings.Join(values, ", ")))
+		} else if !hasBody && n != 0 || hasBody && n != r.ContentLength {
+			problems = append(problems, fmt.Sprintf("Content-Length header %d conflicts with body length %d", n, r.ContentLength))
+		}
+	}
+
+	if problems != nil {
+		return &BuildError{errors.New(strings.Join(problems, "; "))}
+	}
+	return nil
+}
+
+/*
+ValidateRequests makes the client call Validate on every request before
+sending it, failing with a *BuildError instead of sending a request
+that is likely broken.
+*/
+func ValidateRequests() ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.validate = true
+	})
+}
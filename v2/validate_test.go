@@ -0,0 +1,57 @@
+package httpsimp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	withLength := MakeJSON(http.MethodPost, "http://example.com", "/x", nil, 1, http.Header{"Content-Length": {"10"}})
+	tests := []struct {
+		name     string
+		req      *http.Request
+		expected string
+	}{
+		{"ok GET", MakeGet("http://example.com", "/x", nil, nil), ""},
+		{"ok JSON", MakeJSON(http.MethodPost, "http://example.com", "/x", nil, 1, nil), ""},
+		{"ok empty body", Make(http.MethodPost, "http://example.com", "/x", nil, nil, nil), ""},
+		{"no URL", &http.Request{Method: http.MethodGet}, "no URL"},
+		{"no content type", Make(http.MethodPost, "http://example.com", "/x", nil, []byte("hi"), nil), "body without Content-Type"},
+		{"GET with body", Make(http.MethodGet, "http://example.com", "/x", nil, []byte("hi"), http.Header{"Content-Type": {ContentTypeTextPlain}}), "GET request with a body"},
+		{"content length", withLength, "Content-Length header 10 conflicts with body length 1"},
+		{"bad content length", MakeGet("http://example.com", "/x", nil, http.Header{"Content-Length": {"x"}}), "invalid Content-Length"},
+		{"build error", MakeGet("://bad", "/x", nil, nil), "missing protocol scheme"},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.req)
+		if tt.expected == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		if _, ok := err.(*BuildError); !ok || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s: got %v, wanted an error containing %q", tt.name, err, tt.expected)
+		}
+	}
+}
+
+func TestValidateRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := NewClient(ValidateRequests())
+	err := Do(Make(http.MethodGet, srv.URL, "/x", nil, []byte("hi"), nil), client, None())
+	var be *BuildError
+	if !errors.As(err, &be) {
+		t.Fatalf("expected *BuildError, got %v", err)
+	}
+	if err := Do(MakeGet(srv.URL, "/x", nil, nil), client, None()); err != nil {
+		t.Fatal(err)
+	}
+}
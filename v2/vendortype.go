@@ -0,0 +1,128 @@
+package httpsimp
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+/*
+VendorType is a vendor-specific media type carrying an API version, like
+application/vnd.company.resource.v2+json:
+
+    userV2 := httpsimp.VendorType{Name: "company.user", Version: "v2", Suffix: "json"}
+    r := httpsimp.MakeGet(baseURL, path, nil, http.Header{"Accept": {userV2.String()}})
+    err := httpsimp.Do(r, client, httpsimp.JSON(&user, httpsimp.Vendor(userV2)))
+
+Use ParseVendorType to obtain one from a Content-Type value.
+*/
+type VendorType struct {
+	// Name is the part after "vnd." not including the version,
+	// e.g. "company.resource".
+	Name string
+
+	// Version is like "v2"; can be empty.
+	Version string
+
+	// Suffix is the structured syntax suffix like "json" or "xml"; can be empty.
+	Suffix string
+}
+
+/*
+String returns the full media type, e.g. application/vnd.company.resource.v2+json.
+*/
+func (t VendorType) String() string {
+	var buf strings.Builder
+	buf.WriteString("application/vnd.")
+	buf.WriteString(t.Name)
+	if t.Version != "" {
+		buf.WriteString(".")
+		buf.WriteString(t.Version)
+	}
+	if t.Suffix != "" {
+		buf.WriteString("+")
+		buf.WriteString(t.Suffix)
+	}
+	return buf.String()
+}
+
+/*
+ParseVendorType parses a media type like application/vnd.company.resource.v2+json
+(parameters are allowed and ignored). The version is the last dot-separated
+component looking like v1, v2 etc, if any. Returns false if the media type
+isn't a vendor-specific one.
+*/
+func ParseVendorType(mediaType string) (VendorType, bool) {
+	if mt, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = mt
+	}
+	mediaType = strings.ToLower(mediaType)
+	const prefix = "application/vnd."
+	if !strings.HasPrefix(mediaType, prefix) || len(mediaType) == len(prefix) {
+		return VendorType{}, false
+	}
+	rest := mediaType[len(prefix):]
+
+	var t VendorType
+	if i := strings.LastIndexByte(rest, '+'); i >= 0 {
+		rest, t.Suffix = rest[:i], rest[i+1:]
+	}
+
+	comps := strings.Split(rest, ".")
+	for i := len(comps) - 1; i > 0; i-- {
+		if isVersionComponent(comps[i]) {
+			t.Version = comps[i]
+			comps = append(comps[:i:i], comps[i+1:]...)
+			break
+		}
+	}
+	t.Name = strings.Join(comps, ".")
+	return t, true
+}
+
+func isVersionComponent(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Vendor causes the parser to only match responses of the given vendor-specific
+media type. When the server responds with the same type, but a different
+version, Do and Parse return a *VersionMismatchError (unless another parser
+handles the response).
+*/
+func Vendor(t VendorType) ParseOption {
+	return matchOptionFunc(func(m *Parser) {
+		m.ctype = strings.ToLower(t.String())
+		m.vendor = &t
+	})
+}
+
+/*
+VersionMismatchError is returned when the server responds with a different
+version of the vendor-specific media type than requested via Vendor.
+*/
+type VersionMismatchError struct {
+	StatusCode int
+	Wanted     VendorType
+	Actual     VendorType
+}
+
+func (err *VersionMismatchError) Error() string {
+	return fmt.Sprintf("HTTP %d, unexpected version %q of vnd.%s, wanted %q", err.StatusCode, err.Actual.Version, err.Actual.Name, err.Wanted.Version)
+}
+
+func vendorVersionMismatch(statusCode int, wanted *VendorType, ctype string) error {
+	actual, ok := ParseVendorType(ctype)
+	if !ok || actual.Name != strings.ToLower(wanted.Name) || actual.Version == strings.ToLower(wanted.Version) {
+		return nil
+	}
+	return &VersionMismatchError{statusCode, *wanted, actual}
+}
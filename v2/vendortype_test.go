@@ -0,0 +1,57 @@
+package httpsimp
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestParseVendorType(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected VendorType
+		ok       bool
+	}{
+		{"application/vnd.company.resource.v2+json", VendorType{"company.resource", "v2", "json"}, true},
+		{"application/vnd.github.v3.raw+json; charset=utf-8", VendorType{"github.raw", "v3", "json"}, true},
+		{"application/vnd.ms-excel", VendorType{"ms-excel", "", ""}, true},
+		{"application/vnd.v2", VendorType{"v2", "", ""}, true},
+		{"application/json", VendorType{}, false},
+	}
+	for _, tt := range tests {
+		actual, ok := ParseVendorType(tt.input)
+		if ok != tt.ok || actual != tt.expected {
+			t.Errorf("ParseVendorType(%q) = %+v, %v, wanted %+v, %v", tt.input, actual, ok, tt.expected, tt.ok)
+		}
+	}
+
+	if s := (VendorType{"company.resource", "v2", "json"}).String(); s != "application/vnd.company.resource.v2+json" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestVendor(t *testing.T) {
+	v2 := VendorType{Name: "company.user", Version: "v2", Suffix: "json"}
+
+	var resp struct {
+		Name string `json:"name"`
+	}
+	err := get(http.StatusOK, "application/vnd.company.user.v2+json", []byte(`{"name": "foo"}`), JSON(&resp, Vendor(v2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Name != "foo" {
+		t.Fatalf("invalid value of Name: %q", resp.Name)
+	}
+
+	err = get(http.StatusOK, "application/vnd.company.user.v3+json", []byte(`{"name": "foo"}`), JSON(&resp, Vendor(v2)))
+	var vme *VersionMismatchError
+	if !errors.As(err, &vme) || vme.Actual.Version != "v3" || vme.Wanted.Version != "v2" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = get(http.StatusOK, ContentTypeJSON, []byte(`{"name": "foo"}`), JSON(&resp, Vendor(v2)))
+	if errors.As(err, &vme) || StatusCode(err) != http.StatusOK {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
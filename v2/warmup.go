@@ -0,0 +1,92 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+)
+
+/*
+Warmup pre-establishes n connections (including TLS handshakes) to each of
+the given hosts, so that a burst of requests started afterwards doesn't
+pay the connection setup cost. Hosts are either URLs like
+"https://api.example.com:8443" or bare host names (HTTPS is assumed).
+
+Connections are opened by sending concurrent HEAD / requests, whose
+responses are ignored. Note that the client keeps at most 2 idle connections
+per host unless configured otherwise via MaxIdleConnsPerHost, and that
+HTTP/2 servers multiplex all requests over a single connection anyway.
+
+Returns the first error encountered, if any.
+*/
+func (c *Client) Warmup(ctx context.Context, hosts []string, n int) error {
+	httpClient := c.load().httpClient
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(hosts)*n)
+	for _, host := range hosts {
+		if !strings.Contains(host, "://") {
+			host = "https://" + host
+		}
+		base := strings.TrimSuffix(host, "/")
+
+		var ready sync.WaitGroup
+		ready.Add(n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- warmupConn(ctx, httpClient, base, &ready)
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// warmupConn sends a request, holding on to its connection until all
+// other requests to the same host have obtained theirs, so that each of
+// them is forced to open a new one.
+func warmupConn(ctx context.Context, client *http.Client, base string, ready *sync.WaitGroup) error {
+	var once sync.Once
+	done := func() { once.Do(ready.Done) }
+	defer done()
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			done()
+			ready.Wait()
+		},
+	}
+	r, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodHead, base+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	drainAndClose(resp.Body)
+	return nil
+}
+
+/*
+MaxIdleConnsPerHost sets the maximum number of idle keep-alive connections
+kept per host (2 by default). Raise it for highly concurrent workloads,
+and when using Warmup.
+*/
+func MaxIdleConnsPerHost(n int) ClientOption {
+	return clientOptionFunc(func(c *clientConfig) {
+		c.maxIdleConnsPerHost = n
+		c.transportChanged = true
+	})
+}
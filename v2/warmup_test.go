@@ -0,0 +1,54 @@
+package httpsimp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWarmup(t *testing.T) {
+	var conns int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	srv.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	client := NewClient(TLSConfig(&tls.Config{RootCAs: pool}), MaxIdleConnsPerHost(4))
+
+	err := client.Warmup(context.Background(), []string{srv.URL}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&conns); n != 4 {
+		t.Fatalf("warmed up %d connections, wanted 4", n)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Do(MakeGet(srv.URL, "", nil, nil), client, None()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&conns); n != 4 {
+		t.Fatalf("opened %d connections in total, wanted 4", n)
+	}
+}
@@ -0,0 +1,162 @@
+package httpsimp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*
+SignatureFormat selects how SignWebhook formats the signature header.
+*/
+type SignatureFormat int
+
+const (
+	// SignatureTimestamped signs "<timestamp>.<body>" and sends
+	// "t=<timestamp>,v1=<hex HMAC>", like Stripe does. The timestamp lets
+	// receivers reject replayed deliveries.
+	SignatureTimestamped SignatureFormat = iota
+
+	// SignatureBodyOnly signs the body and sends "sha256=<hex HMAC>",
+	// like GitHub's X-Hub-Signature-256 header.
+	SignatureBodyOnly
+)
+
+// Default signature header names of the signature formats.
+const (
+	WebhookSignatureHeader = "Webhook-Signature"
+	HubSignatureHeader     = "X-Hub-Signature-256"
+)
+
+/*
+SignWebhook computes an HMAC-SHA256 signature of body with the given secret
+at the given time, and returns the header value in the given format.
+*/
+func SignWebhook(body, secret []byte, format SignatureFormat, now time.Time) string {
+	mac := hmac.New(sha256.New, secret)
+	switch format {
+	case SignatureBodyOnly:
+		mac.Write(body)
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	default:
+		ts := strconv.FormatInt(now.Unix(), 10)
+		mac.Write([]byte(ts))
+		mac.Write([]byte{'.'})
+		mac.Write(body)
+		return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+	}
+}
+
+/*
+WebhookSender delivers signed JSON webhooks to subscribers:
+
+    sender := &httpsimp.WebhookSender{Client: client, Secret: subscriber.Secret}
+    result, err := sender.Send(ctx, subscriber.URL, event)
+    if result.Permanent {
+        disableSubscription(subscriber)
+    }
+
+Failed deliveries are retried with exponential backoff when IsRetryable
+says so, each attempt signed with a fresh timestamp.
+*/
+type WebhookSender struct {
+	Client HTTPClient
+	Secret []byte
+	Format SignatureFormat
+
+	// Header holds extra headers to send, e.g. an event type.
+	Header http.Header
+
+	// SignatureHeader is the name of the signature header; defaults to
+	// WebhookSignatureHeader for SignatureTimestamped and HubSignatureHeader
+	// for SignatureBodyOnly.
+	SignatureHeader string
+
+	// MaxAttempts defaults to 3.
+	MaxAttempts int
+
+	now  func() time.Time
+	wait func(ctx context.Context, d time.Duration) error
+}
+
+/*
+WebhookResult describes the outcome of WebhookSender.Send.
+*/
+type WebhookResult struct {
+	// StatusCode is the status of the last response, or 0 if none.
+	StatusCode int
+
+	// Attempts is the number of deliveries attempted.
+	Attempts int
+
+	// Delivered is true if the receiver responded with 2xx.
+	Delivered bool
+
+	// Permanent is true if the receiver rejected the webhook in a way
+	// retrying cannot fix, like 404 Not Found or 410 Gone.
+	Permanent bool
+}
+
+/*
+Send encodes event as JSON using JSONCodec, and POSTs it to url with the
+signature header, retrying as needed. On failure it returns the error of
+the last attempt.
+*/
+func (s *WebhookSender) Send(ctx context.Context, url string, event interface{}) (WebhookResult, error) {
+	var result WebhookResult
+	body, err := JSONCodec.Marshal(event)
+	if err != nil {
+		return result, &BuildError{fmt.Errorf("cannot encode JSON body: %w", err)}
+	}
+	now, wait := s.now, s.wait
+	if now == nil {
+		now = time.Now
+	}
+	if wait == nil {
+		wait = sleepContext
+	}
+	maxAttempts := s.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	header := s.SignatureHeader
+	if header == "" {
+		header = WebhookSignatureHeader
+		if s.Format == SignatureBodyOnly {
+			header = HubSignatureHeader
+		}
+	}
+
+	for {
+		result.Attempts++
+		h := s.Header.Clone()
+		if h == nil {
+			h = make(http.Header)
+		}
+		h.Set("Content-Type", ContentTypeJSON)
+		h.Set(header, SignWebhook(body, s.Secret, s.Format, now()))
+		r := MakeWith(http.MethodPost, url, "", WithContext(ctx), WithHeaders(h), WithBody(body))
+
+		result.StatusCode = 0
+		err = Do(r, s.Client, None(), CaptureStatus(&result.StatusCode))
+		if err == nil {
+			result.Delivered = true
+			return result, nil
+		}
+		if !IsRetryable(err) {
+			result.Permanent = result.StatusCode != 0
+			return result, err
+		}
+		if result.Attempts >= maxAttempts {
+			return result, err
+		}
+		if werr := wait(ctx, retryDelay("", result.Attempts, now())); werr != nil {
+			return result, err
+		}
+	}
+}
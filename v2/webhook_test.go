@@ -0,0 +1,106 @@
+package httpsimp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	body, secret := []byte(`{"id":1}`), []byte("s3cret")
+	now := time.Unix(1700000000, 0)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("1700000000." + string(body)))
+	if a, e := SignWebhook(body, secret, SignatureTimestamped, now), "t=1700000000,v1="+hex.EncodeToString(mac.Sum(nil)); a != e {
+		t.Errorf("timestamped = %q, wanted %q", a, e)
+	}
+
+	mac = hmac.New(sha256.New, secret)
+	mac.Write(body)
+	if a, e := SignWebhook(body, secret, SignatureBodyOnly, now), "sha256="+hex.EncodeToString(mac.Sum(nil)); a != e {
+		t.Errorf("body only = %q, wanted %q", a, e)
+	}
+}
+
+func TestWebhookSender(t *testing.T) {
+	secret := []byte("s3cret")
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := ioutil.ReadAll(r.Body)
+		if a, e := r.Header.Get(HubSignatureHeader), SignWebhook(body, secret, SignatureBodyOnly, time.Time{}); a != e {
+			t.Errorf("signature = %q, wanted %q", a, e)
+		}
+		if r.Header.Get("X-Event") != "ping" || r.Header.Get("Content-Type") != ContentTypeJSON || string(body) != `{"zen":"yes"}` {
+			t.Errorf("unexpected request: %v %s", r.Header, body)
+		}
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s := &WebhookSender{
+		Client: http.DefaultClient,
+		Secret: secret,
+		Format: SignatureBodyOnly,
+		Header: http.Header{"X-Event": {"ping"}},
+		wait:   func(context.Context, time.Duration) error { return nil },
+	}
+	result, err := s.Send(context.Background(), srv.URL, map[string]string{"zen": "yes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (result != WebhookResult{StatusCode: http.StatusAccepted, Attempts: 2, Delivered: true}) {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestWebhookSenderPermanentFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get(WebhookSignatureHeader), "t=") {
+			t.Errorf("signature = %q", r.Header.Get(WebhookSignatureHeader))
+		}
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer srv.Close()
+
+	s := &WebhookSender{Client: http.DefaultClient, Secret: []byte("x")}
+	result, err := s.Send(context.Background(), srv.URL, 42)
+	if StatusCode(err) != http.StatusGone {
+		t.Errorf("err = %v", err)
+	}
+	if (result != WebhookResult{StatusCode: http.StatusGone, Attempts: 1, Permanent: true}) {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestWebhookSenderMalformedURL(t *testing.T) {
+	s := &WebhookSender{
+		Client: http.DefaultClient,
+		Secret: []byte("x"),
+		wait: func(context.Context, time.Duration) error {
+			t.Error("unexpected retry")
+			return nil
+		},
+	}
+	result, err := s.Send(context.Background(), "://bad", 42)
+	var be *BuildError
+	if !errors.As(err, &be) {
+		t.Fatalf("err = %v, wanted a *BuildError", err)
+	}
+	if (result != WebhookResult{Attempts: 1}) {
+		t.Errorf("result = %+v", result)
+	}
+}
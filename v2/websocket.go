@@ -0,0 +1,72 @@
+package httpsimp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+/*
+WebSocketTarget returns the URL (with a ws or wss scheme) and the headers
+to open a WebSocket connection for the given request, applying the same
+configuration the client applies to regular requests: base URL, bearer
+token, default params, request ID, trace context and cookies from the jar.
+Build the request like any other, e.g. with MakeWith and WithBasicAuth.
+
+See DialWebSocket for a shortcut that also establishes the connection.
+*/
+func (c *Client) WebSocketTarget(r *http.Request) (string, http.Header, error) {
+	if be := requestBuildError(r); be != nil {
+		return "", nil, be
+	}
+	s := c.load()
+	r, err := s.config.prepare(r)
+	if err != nil {
+		return "", nil, err
+	}
+	h := r.Header.Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+	h.Del("Accept-Encoding") // negotiated by the WebSocket library, if at all
+	if jar := s.config.jar; jar != nil {
+		cr := &http.Request{Header: h}
+		for _, cookie := range jar.Cookies(r.URL) {
+			cr.AddCookie(cookie)
+		}
+	}
+
+	u := *r.URL
+	switch u.Scheme {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		return "", nil, &BuildError{fmt.Errorf("unsupported scheme %q for a WebSocket URL", u.Scheme)}
+	}
+	return u.String(), h, nil
+}
+
+/*
+DialWebSocket establishes a WebSocket connection for the given request
+(see Client.WebSocketTarget), so that services mixing REST and WebSocket
+APIs can share the endpoint and auth configuration. The connection is
+established by dial, which adapts the WebSocket library of your choice,
+e.g. nhooyr.io/websocket:
+
+    conn, err := httpsimp.DialWebSocket(ctx, client,
+        httpsimp.MakeWith(http.MethodGet, "", "/events", httpsimp.WithQuery("topic", "orders")),
+        func(ctx context.Context, url string, h http.Header) (*websocket.Conn, error) {
+            conn, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{HTTPHeader: h})
+            return conn, err
+        })
+*/
+func DialWebSocket[Conn any](ctx context.Context, client *Client, r *http.Request, dial func(ctx context.Context, url string, header http.Header) (Conn, error)) (Conn, error) {
+	u, h, err := client.WebSocketTarget(r)
+	if err != nil {
+		var zero Conn
+		return zero, err
+	}
+	return dial(ctx, u, h)
+}
@@ -0,0 +1,45 @@
+package httpsimp
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+)
+
+func TestDialWebSocket(t *testing.T) {
+	jar, _ := cookiejar.New(nil)
+	jar.SetCookies(&url.URL{Scheme: "https", Host: "api.example.com"}, []*http.Cookie{{Name: "session", Value: "s1"}})
+	client := NewClient(BaseURL("https://api.example.com/v1"), BearerToken("tok"), Jar(jar))
+
+	type fakeConn struct {
+		url    string
+		header http.Header
+	}
+	conn, err := DialWebSocket(context.Background(), client,
+		MakeWith(http.MethodGet, "", "/events", WithQuery("topic", "orders"), WithHeader("X-Client", "test")),
+		func(ctx context.Context, url string, h http.Header) (*fakeConn, error) {
+			return &fakeConn{url, h}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, e := conn.url, "wss://api.example.com/v1/events?topic=orders"; a != e {
+		t.Errorf("url = %q, wanted %q", a, e)
+	}
+	if conn.header.Get("Authorization") != "Bearer tok" || conn.header.Get("X-Client") != "test" || conn.header.Get("Cookie") != "session=s1" {
+		t.Errorf("header = %v", conn.header)
+	}
+}
+
+func TestWebSocketTargetScheme(t *testing.T) {
+	client := NewClient()
+	u, _, err := client.WebSocketTarget(MakeGet("http://localhost:8080", "/ws", nil, nil))
+	if err != nil || u != "ws://localhost:8080/ws" {
+		t.Errorf("u = %q, err = %v", u, err)
+	}
+	if _, _, err := client.WebSocketTarget(MakeGet("ftp://localhost", "/ws", nil, nil)); err == nil {
+		t.Error("expected an error for ftp scheme")
+	}
+}